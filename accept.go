@@ -0,0 +1,223 @@
+package scanner
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrUnexpected is returned when the text at the scanner position is not what was expected.
+var ErrUnexpected = errors.New("unexpected input")
+
+// ErrUnexpectedEOF is returned when the text ends before the expected input is complete.
+var ErrUnexpectedEOF = errors.New("unexpected end of text")
+
+// Accept consumes the rune at the current scanner position if it is r and reports whether it did.
+// The rune is compared after line break normalization, so Accept('\n') also consumes CR and CRLF line breaks.
+// As there is nothing to consume at the end of the text, Accept(EOF) always returns false.
+func (scanner *Scanner) Accept(r rune) bool {
+	_, ok := scanner.AcceptFunc(func(next rune) bool { return next == r })
+	return ok
+}
+
+// AcceptFunc consumes the rune at the current scanner position if pred returns true for it and returns the rune and whether it was consumed.
+// The predicate is never called for EOF. If the rune is not consumed, the state of the Scanner is left unchanged.
+func (scanner *Scanner) AcceptFunc(pred func(rune) bool) (rune, bool) {
+	savedPos := scanner.TextPosition
+	savedComplex := scanner.isComplexSinceMark
+
+	r := scanner.Pop()
+	if r != EOF && pred(r) {
+		return r, true
+	}
+
+	scanner.TextPosition = savedPos
+	scanner.isComplexSinceMark = savedComplex
+	return r, false
+}
+
+// AcceptTable consumes the rune at the current scanner position if it is contained in table, e.g. unicode.Letter,
+// and returns the rune and whether it was consumed, like Scanner.AcceptFunc.
+func (scanner *Scanner) AcceptTable(table *unicode.RangeTable) (rune, bool) {
+	return scanner.AcceptFunc(func(r rune) bool { return unicode.Is(table, r) })
+}
+
+// Expect consumes the rune at the current scanner position if it is r. Otherwise, an error wrapping ErrUnexpected
+// describing both runes and the position is returned, e.g. "expected ';' but found '}' at 3:14", and nothing is consumed.
+func (scanner *Scanner) Expect(r rune) error {
+	return scanner.ExpectFunc(func(next rune) bool { return next == r }, strconv.QuoteRune(r))
+}
+
+// ExpectFunc consumes the rune at the current scanner position if pred returns true for it. Otherwise, an error wrapping
+// ErrUnexpected is returned and nothing is consumed. The error describes the expected rune by what, e.g. "expected digit but found 'x' at 1:3".
+func (scanner *Scanner) ExpectFunc(pred func(rune) bool, what string) error {
+	savedComplex := scanner.isComplexSinceMark
+	if _, ok := scanner.AcceptFunc(pred); ok {
+		return nil
+	}
+
+	next := scanner.PopSpan()
+	scanner.TextPosition = next.Start
+	scanner.isComplexSinceMark = savedComplex
+	return scanner.ErrorfAt(next.Span, "%w: expected %s but found %s", ErrUnexpected, what, describeRune(next.Rune))
+}
+
+// ExpectString consumes s if the upcoming runes match it exactly like Scanner.ConsumeString does. Otherwise, an error wrapping
+// ErrUnexpected is returned that points at the first mismatching rune, e.g. `expected "<=" but found '-' at 1:2`, and nothing is consumed.
+func (scanner *Scanner) ExpectString(s string) error {
+	savedPos := scanner.TextPosition
+	savedComplex := scanner.isComplexSinceMark
+
+	for _, r := range s {
+		if span := scanner.PopSpan(); span.Rune != r {
+			scanner.TextPosition = savedPos
+			scanner.isComplexSinceMark = savedComplex
+			return scanner.ErrorfAt(span.Span, "%w: expected %q but found %s", ErrUnexpected, s, describeRune(span.Rune))
+		}
+	}
+	return nil
+}
+
+// describeRune returns the rune quoted for use in error messages, or "EOF".
+func describeRune(r rune) string {
+	if r == EOF {
+		return "EOF"
+	}
+	return strconv.QuoteRune(r)
+}
+
+// AcceptRun consumes the longest run of runes contained in set starting at the current scanner position and returns it.
+// Line breaks are normalized to LF before being looked up in set and escaped line breaks are skipped.
+func (scanner *Scanner) AcceptRun(set string) string {
+	text, _ := scanner.TakeWhile(func(r rune) bool { return strings.ContainsRune(set, r) })
+	return text
+}
+
+// TakeWhile consumes the longest run of runes starting at the current scanner position for which pred returns true.
+// It returns the consumed text, normalized the same way Scanner.Slice does, and the span it covers.
+// The predicate is never called for EOF. If no rune is consumed, an empty string and an empty span are returned.
+func (scanner *Scanner) TakeWhile(pred func(rune) bool) (string, Span) {
+	start := scanner.TextPosition
+	complex := false
+
+	for !scanner.IsEOF() {
+		r, w := utf8.DecodeRuneInString(scanner.text[scanner.Offset:])
+		if r != '\r' && r != '\n' && r != '\\' {
+			// fast path for runes that Scanner.Pop returns as they are
+			if !pred(r) {
+				break
+			}
+			scanner.Offset += w
+			scanner.Col += scanner.columnMode.columnWidth(r)
+			if scanner.runeIndex {
+				scanner.RuneIdx++
+			}
+			scanner.trackProgress()
+			continue
+		}
+
+		savedPos := scanner.TextPosition
+		savedComplex := scanner.isComplexSinceMark
+		if r = scanner.Pop(); r == EOF || !pred(r) {
+			scanner.TextPosition = savedPos
+			scanner.isComplexSinceMark = savedComplex
+			break
+		}
+		// only a lone LF or backslash is returned as it appears in the text
+		complex = complex || scanner.Offset-savedPos.Offset != 1 || r != rune(scanner.text[savedPos.Offset])
+	}
+
+	span := Span{Start: start, End: scanner.TextPosition}
+	text := scanner.text[start.Offset:scanner.Offset]
+	if complex {
+		text = normalize(text)
+	}
+	return text, span
+}
+
+// TakeExactly consumes exactly n runes starting at the current scanner position and returns them, normalized the same way Scanner.Slice does,
+// along with the span they cover, e.g. for the fields of fixed-width records. Runes are counted like by Scanner.PopN.
+// If fewer than n runes are left, an error wrapping ErrUnexpectedEOF naming how many are available is returned
+// along with the span of the remaining runes, and nothing is consumed.
+func (scanner *Scanner) TakeExactly(n int) (string, Span, error) {
+	start := scanner.TextPosition
+	savedComplex := scanner.isComplexSinceMark
+
+	available := 0
+	for available < n && scanner.Pop() != EOF {
+		available++
+	}
+
+	span := Span{Start: start, End: scanner.TextPosition}
+	if available < n {
+		scanner.TextPosition = start
+		scanner.isComplexSinceMark = savedComplex
+		return "", span, scanner.ErrorfAt(span, "%w: expected %d runes but only %d available", ErrUnexpectedEOF, n, available)
+	}
+	return scanner.TextOf(span), span, nil
+}
+
+// TakeWhileTable consumes the longest run of runes contained in table starting at the current scanner position
+// and returns the consumed text and its span, like Scanner.TakeWhile.
+func (scanner *Scanner) TakeWhileTable(table *unicode.RangeTable) (string, Span) {
+	return scanner.TakeWhile(func(r rune) bool { return unicode.Is(table, r) })
+}
+
+// ConsumeString consumes s if the upcoming runes match it exactly and reports whether it did.
+// The upcoming runes are compared after line break normalization and escaped line break skipping,
+// so line breaks within s must be written as LF. If s does not match, the state of the Scanner is left unchanged.
+func (scanner *Scanner) ConsumeString(s string) bool {
+	return scanner.consumePrefix(s, func(a, b rune) bool { return a == b })
+}
+
+// ConsumeStringFold consumes s if the upcoming runes match it under Unicode simple case folding and reports whether it did,
+// e.g. ConsumeStringFold("select") also consumes "SELECT" and "Select".
+// The upcoming runes are normalized the same way as for Scanner.ConsumeString. If s does not match, the state of the Scanner is left unchanged.
+func (scanner *Scanner) ConsumeStringFold(s string) bool {
+	return scanner.consumePrefix(s, equalFold)
+}
+
+// HasPrefix returns whether the upcoming runes match s exactly, without advancing.
+// Unlike comparing against Scanner.PeekN, s is compared rune by rune without allocating,
+// after the same normalization as for Scanner.ConsumeString.
+func (scanner *Scanner) HasPrefix(s string) bool {
+	savedPos := scanner.TextPosition
+	savedComplex := scanner.isComplexSinceMark
+
+	matched := scanner.ConsumeString(s)
+
+	scanner.TextPosition = savedPos
+	scanner.isComplexSinceMark = savedComplex
+	return matched
+}
+
+// consumePrefix consumes s if each of the upcoming runes is equal to the rune of s at the same index according to equal.
+// Otherwise, the state of the Scanner is left unchanged.
+func (scanner *Scanner) consumePrefix(s string, equal func(a, b rune) bool) bool {
+	savedPos := scanner.TextPosition
+	savedComplex := scanner.isComplexSinceMark
+
+	for _, r := range s {
+		if next := scanner.Pop(); next == EOF || !equal(next, r) {
+			scanner.TextPosition = savedPos
+			scanner.isComplexSinceMark = savedComplex
+			return false
+		}
+	}
+	return true
+}
+
+// equalFold returns whether a and b are equal under Unicode simple case folding, like strings.EqualFold does for single runes.
+func equalFold(a, b rune) bool {
+	if a == b {
+		return true
+	}
+	for folded := unicode.SimpleFold(a); folded != a; folded = unicode.SimpleFold(folded) {
+		if folded == b {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,385 @@
+package scanner
+
+import (
+	"errors"
+	"testing"
+	"unicode"
+)
+
+func TestScannerAccept(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		r        rune
+		expected bool
+		offset   int
+	}{
+		{name: "match", input: "ab", r: 'a', expected: true, offset: 1},
+		{name: "mismatch", input: "ab", r: 'b', expected: false, offset: 0},
+		{name: "multi-byte", input: "世界", r: '世', expected: true, offset: 3},
+		{name: "CRLF as LF", input: "\r\nx", r: '\n', expected: true, offset: 2},
+		{name: "escaped line break skipped", input: "\\\nx", r: 'x', expected: true, offset: 3},
+		{name: "EOF", input: "", r: EOF, expected: false, offset: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			if result := scanner.Accept(tt.r); result != tt.expected {
+				t.Errorf("Accept(%q) = %v, expected %v", tt.r, result, tt.expected)
+			}
+			if scanner.Offset != tt.offset {
+				t.Errorf("expected offset %d, got %d", tt.offset, scanner.Offset)
+			}
+		})
+	}
+}
+
+func TestScannerAcceptFunc(t *testing.T) {
+	scanner := NewScanner("1a")
+
+	if r, ok := scanner.AcceptFunc(unicode.IsDigit); !ok || r != '1' {
+		t.Errorf("AcceptFunc(IsDigit) = %q, %v, expected '1', true", r, ok)
+	}
+	if r, ok := scanner.AcceptFunc(unicode.IsDigit); ok || r != 'a' {
+		t.Errorf("AcceptFunc(IsDigit) = %q, %v, expected 'a', false", r, ok)
+	}
+	if scanner.Offset != 1 {
+		t.Errorf("expected offset 1 after rejection, got %d", scanner.Offset)
+	}
+
+	scanner.Pop()
+	called := false
+	if r, ok := scanner.AcceptFunc(func(rune) bool { called = true; return true }); ok || r != EOF {
+		t.Errorf("AcceptFunc() at EOF = %q, %v, expected EOF, false", r, ok)
+	}
+	if called {
+		t.Errorf("predicate was called for EOF")
+	}
+}
+
+func TestScannerAcceptKeepsSliceSimple(t *testing.T) {
+	scanner := NewScanner("ab\r\n")
+	scanner.Mark()
+	scanner.Pop()
+	scanner.Pop()
+
+	// rejecting the CRLF must not mark the slice as needing normalization
+	if scanner.Accept('x') {
+		t.Fatalf("Accept('x') = true, expected false")
+	}
+	if scanner.isComplexSinceMark {
+		t.Errorf("isComplexSinceMark = true after rejected Accept")
+	}
+}
+
+func TestScannerTakeWhile(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		pred     func(rune) bool
+		expected string
+		end      int
+	}{
+		{name: "letters", input: "abc123", pred: unicode.IsLetter, expected: "abc", end: 3},
+		{name: "none", input: "123", pred: unicode.IsLetter, expected: "", end: 0},
+		{name: "whole text", input: "äöü", pred: unicode.IsLetter, expected: "äöü", end: 6},
+		{name: "escaped line break spliced", input: "ab\\\ncd!", pred: unicode.IsLetter, expected: "abcd", end: 6},
+		{name: "CRLF normalized", input: "a\r\n\r\nb", pred: unicode.IsSpace, expected: "", end: 0},
+		{name: "line breaks", input: "\r\n\r\n\nb", pred: unicode.IsSpace, expected: "\n\n\n", end: 5},
+		{name: "lone backslash", input: "\\\\x", pred: func(r rune) bool { return r == '\\' }, expected: "\\\\", end: 2},
+		{name: "stops before rejected line break", input: "a\\\n\nb", pred: unicode.IsLetter, expected: "a", end: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			text, span := scanner.TakeWhile(tt.pred)
+			if text != tt.expected {
+				t.Errorf("TakeWhile() text = %q, expected %q", text, tt.expected)
+			}
+			if span.Start.Offset != 0 || span.End.Offset != tt.end || scanner.Offset != tt.end {
+				t.Errorf("TakeWhile() span = %s-%s at offset %d, expected to end at offset %d", span.Start, span.End, scanner.Offset, tt.end)
+			}
+		})
+	}
+}
+
+func TestScannerTakeWhileMatchesPop(t *testing.T) {
+	input := "ab\tä世\r\nc\\\nd\re\\f"
+	options := []Option{WithRuneIndex(), WithColumnMode(ColumnDisplayWidth)}
+
+	fast := NewScanner(input, options...)
+	fast.TakeWhile(func(rune) bool { return true })
+
+	slow := NewScanner(input, options...)
+	for slow.Pop() != EOF {
+	}
+
+	if fast.Pos() != slow.Pos() {
+		t.Errorf("TakeWhile() ended at %+v, expected %+v", fast.Pos(), slow.Pos())
+	}
+}
+
+func TestScannerAcceptRun(t *testing.T) {
+	scanner := NewScanner("0x1F\nfg")
+	if result := scanner.AcceptRun("0"); result != "0" {
+		t.Errorf("AcceptRun(\"0\") = %q, expected %q", result, "0")
+	}
+	if result := scanner.AcceptRun("xX"); result != "x" {
+		t.Errorf("AcceptRun(\"xX\") = %q, expected %q", result, "x")
+	}
+	if result := scanner.AcceptRun("0123456789abcdefABCDEF\n"); result != "1F\nf" {
+		t.Errorf("AcceptRun(hex) = %q, expected %q", result, "1F\nf")
+	}
+	if r := scanner.Peek(); r != 'g' {
+		t.Errorf("Peek() = %q, expected 'g'", r)
+	}
+}
+
+func TestScannerConsumeString(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		s        string
+		expected bool
+		offset   int
+	}{
+		{name: "match", input: "func main", s: "func", expected: true, offset: 4},
+		{name: "mismatch", input: "fun main", s: "func", expected: false, offset: 0},
+		{name: "partial at EOF", input: "fu", s: "func", expected: false, offset: 0},
+		{name: "empty", input: "abc", s: "", expected: true, offset: 0},
+		{name: "multi-byte", input: "→x", s: "→", expected: true, offset: 3},
+		{name: "CRLF matches LF", input: "a\r\nb", s: "a\nb", expected: true, offset: 4},
+		{name: "escaped line break skipped", input: "<\\\n=", s: "<=", expected: true, offset: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			if result := scanner.ConsumeString(tt.s); result != tt.expected {
+				t.Errorf("ConsumeString(%q) = %v, expected %v", tt.s, result, tt.expected)
+			}
+			if scanner.Offset != tt.offset {
+				t.Errorf("expected offset %d, got %d", tt.offset, scanner.Offset)
+			}
+		})
+	}
+}
+
+func TestScannerConsumeStringFold(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		s        string
+		expected bool
+		offset   int
+	}{
+		{name: "same case", input: "select *", s: "select", expected: true, offset: 6},
+		{name: "upper case", input: "SELECT *", s: "select", expected: true, offset: 6},
+		{name: "mixed case", input: "Content-Type:", s: "content-type", expected: true, offset: 12},
+		{name: "mismatch", input: "selekt", s: "select", expected: false, offset: 0},
+		{name: "non-ASCII", input: "ÄRGER", s: "ärger", expected: true, offset: 6},
+		{name: "kelvin sign", input: "\u212A", s: "k", expected: true, offset: 3},
+		{name: "partial at EOF", input: "SEL", s: "select", expected: false, offset: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			if result := scanner.ConsumeStringFold(tt.s); result != tt.expected {
+				t.Errorf("ConsumeStringFold(%q) = %v, expected %v", tt.s, result, tt.expected)
+			}
+			if scanner.Offset != tt.offset {
+				t.Errorf("expected offset %d, got %d", tt.offset, scanner.Offset)
+			}
+		})
+	}
+}
+
+func TestScannerHasPrefix(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		s        string
+		expected bool
+	}{
+		{name: "match", input: "// comment", s: "//", expected: true},
+		{name: "mismatch", input: "/* comment */", s: "//", expected: false},
+		{name: "longer than text", input: "/", s: "//", expected: false},
+		{name: "empty", input: "", s: "", expected: true},
+		{name: "multi-byte counted as runes", input: "äöü!", s: "äöü", expected: true},
+		{name: "CRLF matches LF", input: "\r\n\r\n", s: "\n\n", expected: true},
+		{name: "escaped line break skipped", input: "/\\\n/", s: "//", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			if result := scanner.HasPrefix(tt.s); result != tt.expected {
+				t.Errorf("HasPrefix(%q) = %v, expected %v", tt.s, result, tt.expected)
+			}
+			if scanner.Offset != 0 || scanner.isComplexSinceMark {
+				t.Errorf("HasPrefix(%q) changed the scanner state", tt.s)
+			}
+		})
+	}
+}
+
+func TestScannerExpect(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		r       rune
+		message string
+		offset  int
+	}{
+		{name: "match", input: ";x", r: ';', offset: 1},
+		{name: "mismatch", input: "}", r: ';', message: "unexpected input: expected ';' but found '}' at a.go:1:1"},
+		{name: "EOF", input: "", r: ';', message: "unexpected input: expected ';' but found EOF at a.go:1:1"},
+		{name: "line break", input: "\r\n", r: ')', message: `unexpected input: expected ')' but found '\n' at a.go:1:1`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input, WithFilename("a.go"))
+			err := scanner.Expect(tt.r)
+			if tt.message == "" {
+				if err != nil {
+					t.Errorf("Expect(%q) unexpected error: %v", tt.r, err)
+				}
+			} else {
+				if !errors.Is(err, ErrUnexpected) {
+					t.Fatalf("Expect(%q) error = %v, expected ErrUnexpected", tt.r, err)
+				}
+				if err.Error() != tt.message {
+					t.Errorf("Expect(%q) error = %q, expected %q", tt.r, err.Error(), tt.message)
+				}
+			}
+			if scanner.Offset != tt.offset {
+				t.Errorf("expected offset %d, got %d", tt.offset, scanner.Offset)
+			}
+		})
+	}
+}
+
+func TestScannerExpectFunc(t *testing.T) {
+	scanner := NewScanner("1\n2x")
+	for range 2 {
+		if err := scanner.ExpectFunc(unicode.IsDigit, "digit"); err != nil {
+			t.Fatalf("ExpectFunc() unexpected error: %v", err)
+		}
+		scanner.Accept('\n')
+	}
+
+	err := scanner.ExpectFunc(unicode.IsDigit, "digit")
+	if expected := "unexpected input: expected digit but found 'x' at 2:2"; err == nil || err.Error() != expected {
+		t.Errorf("ExpectFunc() error = %v, expected %q", err, expected)
+	}
+}
+
+func TestScannerExpectString(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		s       string
+		message string
+		offset  int
+	}{
+		{name: "match", input: "<=x", s: "<=", offset: 2},
+		{name: "mismatch in the middle", input: "<-", s: "<=", message: `unexpected input: expected "<=" but found '-' at 1:2`},
+		{name: "mismatch at start", input: "=", s: "<=", message: `unexpected input: expected "<=" but found '=' at 1:1`},
+		{name: "EOF", input: "<", s: "<=", message: `unexpected input: expected "<=" but found EOF at 1:2`},
+		{name: "across line break", input: "end\r\nbegun", s: "end\nbegin", message: `unexpected input: expected "end\nbegin" but found 'u' at 2:4`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			err := scanner.ExpectString(tt.s)
+			if tt.message == "" {
+				if err != nil {
+					t.Errorf("ExpectString(%q) unexpected error: %v", tt.s, err)
+				}
+			} else {
+				if !errors.Is(err, ErrUnexpected) {
+					t.Fatalf("ExpectString(%q) error = %v, expected ErrUnexpected", tt.s, err)
+				}
+				if err.Error() != tt.message {
+					t.Errorf("ExpectString(%q) error = %q, expected %q", tt.s, err.Error(), tt.message)
+				}
+			}
+			if scanner.Offset != tt.offset {
+				t.Errorf("expected offset %d, got %d", tt.offset, scanner.Offset)
+			}
+		})
+	}
+}
+
+func TestScannerTakeExactly(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		n        int
+		expected string
+		end      int
+		err      string
+	}{
+		{name: "exact", input: "abcdef", n: 3, expected: "abc", end: 3},
+		{name: "whole text", input: "abc", n: 3, expected: "abc", end: 3},
+		{name: "zero", input: "abc", n: 0, expected: "", end: 0},
+		{name: "multi-byte", input: "日本語!", n: 3, expected: "日本語", end: 9},
+		{name: "line breaks normalized", input: "a\r\nb", n: 3, expected: "a\nb", end: 4},
+		{name: "short", input: "ab", n: 5, end: 2, err: "unexpected end of text: expected 5 runes but only 2 available at 1:1"},
+		{name: "empty", input: "", n: 1, end: 0, err: "unexpected end of text: expected 1 runes but only 0 available at 1:1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			result, span, err := scanner.TakeExactly(tt.n)
+			if tt.err != "" {
+				if !errors.Is(err, ErrUnexpectedEOF) || err.Error() != tt.err {
+					t.Errorf("TakeExactly() error = %v, expected %q", err, tt.err)
+				}
+				if scanner.Offset != 0 {
+					t.Errorf("expected nothing consumed on error, got offset %d", scanner.Offset)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("TakeExactly() unexpected error: %v", err)
+				}
+				if scanner.Offset != tt.end {
+					t.Errorf("expected offset %d, got %d", tt.end, scanner.Offset)
+				}
+			}
+			if result != tt.expected || span.End.Offset != tt.end {
+				t.Errorf("TakeExactly() = %q ending at %d, expected %q ending at %d", result, span.End.Offset, tt.expected, tt.end)
+			}
+		})
+	}
+}
+
+func TestScannerAcceptTable(t *testing.T) {
+	scanner := NewScanner("aé1_")
+
+	if r, ok := scanner.AcceptTable(unicode.Digit); ok {
+		t.Errorf("AcceptTable(Digit) = %q, true, expected false", r)
+	}
+	if r, ok := scanner.AcceptTable(unicode.Letter); !ok || r != 'a' {
+		t.Errorf("AcceptTable(Letter) = %q, %v, expected 'a', true", r, ok)
+	}
+
+	text, span := scanner.TakeWhileTable(unicode.Letter)
+	if text != "é" || span.Start.Offset != 1 || span.End.Offset != 3 {
+		t.Errorf("TakeWhileTable(Letter) = %q [%d, %d), expected \"é\" [1, 3)", text, span.Start.Offset, span.End.Offset)
+	}
+
+	digits := &unicode.RangeTable{R16: []unicode.Range16{{Lo: '0', Hi: '9', Stride: 1}, {Lo: '_', Hi: '_', Stride: 1}}}
+	if text, _ := scanner.TakeWhileTable(digits); text != "1_" {
+		t.Errorf("TakeWhileTable(custom) = %q, expected \"1_\"", text)
+	}
+	if _, ok := scanner.AcceptTable(unicode.Letter); ok {
+		t.Errorf("AcceptTable() at EOF = true, expected false")
+	}
+}
@@ -0,0 +1,108 @@
+package scanner
+
+import "unicode/utf8"
+
+// runeBefore returns the start offset of the rune in front of the given offset, normalizing line breaks to LF.
+// CRLF line breaks are treated as a single rune. The offset must be greater than 0.
+func (scanner *Scanner) runeBefore(offset int) (int, rune) {
+	text := scanner.text
+	switch {
+	case offset >= 2 && text[offset-2:offset] == "\r\n":
+		return offset - 2, '\n'
+	case text[offset-1] == '\n' || text[offset-1] == '\r':
+		return offset - 1, '\n'
+	}
+
+	r, w := utf8.DecodeLastRuneInString(text[:offset])
+	return offset - w, r
+}
+
+// isEscapedBreak returns whether a line break starting at the given offset is escaped by a backslash.
+func (scanner *Scanner) isEscapedBreak(offset int) bool {
+	return offset > 0 && scanner.text[offset-1] == '\\'
+}
+
+// Prev moves the scanner position back to the previous rune and returns it, undoing Scanner.Pop.
+// If the current position is at the beginning of the text or past its end, EOF is returned and the position is not changed.
+// All line breaks (CR, LF and CRLF) are normalized to LF.
+// Escaped line breaks are skipped, so moving back over a rune preceded by one also moves back over the escaped line break.
+func (scanner *Scanner) Prev() rune {
+	return scanner.PrevSpan().Rune
+}
+
+// PrevSpan moves the scanner position back to the previous rune and returns its RuneSpan, undoing Scanner.PopSpan.
+// If the current position is at the beginning of the text or past its end, EOF is returned and the position is not changed.
+// All line breaks (CR, LF and CRLF) are normalized to LF.
+// Escaped line breaks are skipped, so moving back over a rune preceded by one also moves back over the escaped line break.
+func (scanner *Scanner) PrevSpan() RuneSpan {
+	end := scanner.TextPosition
+	eof := newRuneSpan(EOF, end, end)
+	if end.Offset <= 0 || end.Offset > len(scanner.text) {
+		return eof
+	}
+
+	offset := end.Offset
+	start, r := scanner.runeBefore(offset)
+	crossedBreak := r == '\n'
+
+	// escaped line breaks right in front of the position don't belong to any rune
+	for r == '\n' && scanner.isEscapedBreak(start) {
+		offset = start - 1
+		if offset == 0 {
+			return eof
+		}
+		start, r = scanner.runeBefore(offset)
+	}
+
+	// the rune was popped starting at the backslash of any escaped line breaks in front of it
+	for start > 0 {
+		breakStart, br := scanner.runeBefore(start)
+		if br != '\n' || !scanner.isEscapedBreak(breakStart) {
+			break
+		}
+		start = breakStart - 1
+		crossedBreak = true
+	}
+
+	pos := end
+	if crossedBreak {
+		// the column in front of a line break is only known from the text itself
+		pos, _ = scanner.positionOf(start)
+	} else {
+		pos.Offset = start
+		pos.Col -= scanner.columnMode.columnWidth(r)
+		if scanner.runeIndex {
+			pos.RuneIdx--
+		}
+	}
+
+	scanner.TextPosition = pos
+	return newRuneSpan(r, pos, end)
+}
+
+// Rewind moves the scanner position back by up to n runes, undoing that many calls to Scanner.Pop.
+// It returns the number of runes actually moved back, which is less than n if the beginning of the text is reached.
+func (scanner *Scanner) Rewind(n int) int {
+	for i := range max(n, 0) {
+		if scanner.Prev() == EOF {
+			return i
+		}
+	}
+	return max(n, 0)
+}
+
+// PeekBack returns the rune in front of the current scanner position, that is the rune last returned by Scanner.Pop, without moving.
+// If the current position is at the beginning of the text or past its end, EOF is returned.
+// All line breaks (CR, LF and CRLF) are normalized to LF and escaped line breaks are skipped.
+func (scanner *Scanner) PeekBack() rune {
+	return scanner.PeekBackSpan().Rune
+}
+
+// PeekBackSpan returns the RuneSpan in front of the current scanner position, that is the RuneSpan last returned by Scanner.PopSpan, without moving.
+// If the current position is at the beginning of the text or past its end, EOF is returned.
+// All line breaks (CR, LF and CRLF) are normalized to LF and escaped line breaks are skipped.
+func (scanner *Scanner) PeekBackSpan() RuneSpan {
+	span := scanner.PrevSpan()
+	scanner.TextPosition = span.End
+	return span
+}
@@ -0,0 +1,209 @@
+package scanner
+
+import "testing"
+
+func TestScannerPrev(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []rune // runes returned by Prev from the end of the text
+	}{
+		{name: "empty string", input: "", expected: []rune{EOF}},
+		{name: "simple ASCII", input: "abc", expected: []rune{'c', 'b', 'a', EOF}},
+		{name: "UTF-8 characters", input: "α😀γ", expected: []rune{'γ', '😀', 'α', EOF}},
+		{name: "LF", input: "a\nb", expected: []rune{'b', '\n', 'a', EOF}},
+		{name: "CR", input: "a\rb", expected: []rune{'b', '\n', 'a', EOF}},
+		{name: "CRLF", input: "a\r\nb", expected: []rune{'b', '\n', 'a', EOF}},
+		{name: "escaped line break", input: "a\\\nb", expected: []rune{'b', 'a', EOF}},
+		{name: "escaped CRLF", input: "a\\\r\nb", expected: []rune{'b', 'a', EOF}},
+		{name: "multiple escaped line breaks", input: "a\\\n\\\nb", expected: []rune{'b', 'a', EOF}},
+		{name: "regular backslash", input: "a\\b", expected: []rune{'b', '\\', 'a', EOF}},
+		{name: "trailing escaped line break", input: "a\\\n", expected: []rune{'a', EOF}},
+		{name: "only escaped line break", input: "\\\n", expected: []rune{EOF}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			if err := scanner.SetOffset(len(tt.input)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var result []rune
+			for {
+				r := scanner.Prev()
+				result = append(result, r)
+				if r == EOF {
+					break
+				}
+			}
+
+			if len(result) != len(tt.expected) {
+				t.Fatalf("expected %q, got %q", tt.expected, result)
+			}
+			for i, expected := range tt.expected {
+				if result[i] != expected {
+					t.Errorf("at position %d: expected %q, got %q", i, expected, result[i])
+				}
+			}
+		})
+	}
+}
+
+func TestScannerPrevUndoesPop(t *testing.T) {
+	inputs := []string{
+		"abc",
+		"α😀\nγ",
+		"a\nb\rc\r\nd",
+		"ab\\\ncd\\\r\ne",
+		"a\\b\\\\c",
+		"\n\n\r\r\n",
+	}
+
+	for _, input := range inputs {
+		for _, options := range [][]Option{nil, {WithRuneIndex(), WithColumnMode(ColumnUTF16)}} {
+			scanner := NewScanner(input, options...)
+			var spans []RuneSpan
+			for {
+				span := scanner.PopSpan()
+				if span.Rune == EOF {
+					break
+				}
+				spans = append(spans, span)
+			}
+
+			for i := len(spans) - 1; i >= 0; i-- {
+				if span := scanner.PrevSpan(); span != spans[i] {
+					t.Errorf("input %q: PrevSpan() = %+v, expected %+v", input, span, spans[i])
+				}
+			}
+			if r := scanner.Prev(); r != EOF {
+				t.Errorf("input %q: expected EOF at beginning, got %q", input, r)
+			}
+		}
+	}
+}
+
+func TestScannerPrevOutOfRange(t *testing.T) {
+	scanner := NewScanner("abc")
+	scanner.SetPos(TextPosition{Offset: 10, Line: 1, Col: 11})
+
+	span := scanner.PrevSpan()
+	if span.Rune != EOF {
+		t.Errorf("expected EOF, got %q", span.Rune)
+	}
+	if scanner.Offset != 10 {
+		t.Errorf("expected position not to change, got %+v", scanner.Pos())
+	}
+}
+
+func TestScannerRewind(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		pops          int
+		n             int
+		expectedCount int
+		expectedPos   TextPosition
+	}{
+		{
+			name:          "rewind one",
+			input:         "123abc",
+			pops:          4,
+			n:             1,
+			expectedCount: 1,
+			expectedPos:   TextPosition{Offset: 3, Line: 1, Col: 4},
+		},
+		{
+			name:          "rewind zero",
+			input:         "abc",
+			pops:          2,
+			n:             0,
+			expectedCount: 0,
+			expectedPos:   TextPosition{Offset: 2, Line: 1, Col: 3},
+		},
+		{
+			name:          "rewind negative",
+			input:         "abc",
+			pops:          2,
+			n:             -3,
+			expectedCount: 0,
+			expectedPos:   TextPosition{Offset: 2, Line: 1, Col: 3},
+		},
+		{
+			name:          "rewind across line breaks",
+			input:         "ab\r\ncd\\\nef",
+			pops:          6,
+			n:             4,
+			expectedCount: 4,
+			expectedPos:   TextPosition{Offset: 2, Line: 1, Col: 3},
+		},
+		{
+			name:          "rewind past beginning",
+			input:         "abc",
+			pops:          2,
+			n:             5,
+			expectedCount: 2,
+			expectedPos:   TextPosition{Offset: 0, Line: 1, Col: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			for range tt.pops {
+				scanner.Pop()
+			}
+
+			if count := scanner.Rewind(tt.n); count != tt.expectedCount {
+				t.Errorf("Rewind(%d) = %d, expected %d", tt.n, count, tt.expectedCount)
+			}
+			if scanner.Pos() != tt.expectedPos {
+				t.Errorf("position: expected %+v, got %+v", tt.expectedPos, scanner.Pos())
+			}
+		})
+	}
+}
+
+func TestScannerPeekBack(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		pops     int
+		expected rune
+	}{
+		{name: "at beginning", input: "abc", pops: 0, expected: EOF},
+		{name: "after first rune", input: "abc", pops: 1, expected: 'a'},
+		{name: "at end", input: "abc", pops: 3, expected: 'c'},
+		{name: "after line break", input: "a\r\nb", pops: 2, expected: '\n'},
+		{name: "after escaped line break", input: "a\\\nb", pops: 2, expected: 'b'},
+		{name: "UTF-8 characters", input: "αβ", pops: 2, expected: 'β'},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			var last RuneSpan
+			for range tt.pops {
+				last = scanner.PopSpan()
+			}
+			before := scanner.Pos()
+
+			if r := scanner.PeekBack(); r != tt.expected {
+				t.Errorf("PeekBack() = %q, expected %q", r, tt.expected)
+			}
+			if scanner.Pos() != before {
+				t.Errorf("position changed: expected %+v, got %+v", before, scanner.Pos())
+			}
+
+			if tt.pops > 0 {
+				if span := scanner.PeekBackSpan(); span != last {
+					t.Errorf("PeekBackSpan() = %+v, expected %+v", span, last)
+				}
+				if scanner.Pos() != before {
+					t.Errorf("position changed: expected %+v, got %+v", before, scanner.Pos())
+				}
+			}
+		})
+	}
+}
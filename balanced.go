@@ -0,0 +1,181 @@
+package scanner
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrUnbalanced is returned when a delimited region of the text is not properly balanced.
+var ErrUnbalanced = errors.New("unbalanced delimiters")
+
+// A BalancedOption configures how Scanner.ReadBalanced reads a balanced region.
+type BalancedOption func(*balancedOptions)
+
+// balancedOptions holds the configuration of Scanner.ReadBalanced.
+type balancedOptions struct {
+	quotes string
+	escape rune
+}
+
+// BalancedQuotes makes Scanner.ReadBalanced skip string literals enclosed in any of the given quote runes,
+// so that delimiters within them are not counted.
+func BalancedQuotes(quotes string) BalancedOption {
+	return func(options *balancedOptions) {
+		options.quotes = quotes
+	}
+}
+
+// BalancedEscape sets the rune that escapes the following rune within string literals, so that an escaped quote
+// does not end the literal. The default is a backslash, EOF disables escaping.
+func BalancedEscape(escape rune) BalancedOption {
+	return func(options *balancedOptions) {
+		options.escape = escape
+	}
+}
+
+// ReadBalanced consumes a region enclosed in the open and close delimiters starting at the current scanner position,
+// including any nested regions enclosed in the same delimiters, e.g. a parenthesized expression.
+// It returns the text between the outermost delimiters, normalized the same way Scanner.Slice does,
+// and the span of the whole region including the delimiters.
+// If the position does not start with open, a delimiter or string literal is not closed, an error wrapping ErrUnbalanced
+// is returned along with the span of the unclosed delimiter or literal, and nothing is consumed.
+func (scanner *Scanner) ReadBalanced(open, close rune, opts ...BalancedOption) (string, Span, error) {
+	options := balancedOptions{escape: '\\'}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	savedPos := scanner.TextPosition
+	savedComplex := scanner.isComplexSinceMark
+	fail := func(span Span, format string, args ...any) (string, Span, error) {
+		scanner.TextPosition = savedPos
+		scanner.isComplexSinceMark = savedComplex
+		return "", span, scanner.ErrorfAt(span, "%w: "+format, append([]any{ErrUnbalanced}, args...)...)
+	}
+
+	first := scanner.PopSpan()
+	if first.Rune == EOF || first.Rune != open {
+		return fail(Span{Start: first.Start, End: first.Start}, "expected %q", open)
+	}
+
+	// the spans of the delimiters opened but not closed yet
+	unclosed := []Span{first.Span}
+	var last RuneSpan
+	for len(unclosed) > 0 {
+		span := scanner.PopSpan()
+		last = span
+		switch {
+		case span.Rune == EOF:
+			innermost := unclosed[len(unclosed)-1]
+			return fail(innermost, "%q is never closed", open)
+		case span.Rune == close:
+			unclosed = unclosed[:len(unclosed)-1]
+		case span.Rune == open:
+			unclosed = append(unclosed, span.Span)
+		case strings.ContainsRune(options.quotes, span.Rune):
+			if !scanner.skipQuoted(span.Rune, options.escape) {
+				return fail(Span{Start: span.Start, End: scanner.TextPosition}, "string is never closed")
+			}
+		}
+	}
+
+	// the last rune popped is the outermost closing delimiter
+	inner := Span{Start: first.End, End: last.Start}
+	return scanner.TextOf(inner), Span{Start: first.Start, End: last.End}, nil
+}
+
+// skipQuoted consumes the rest of a string literal enclosed in quote up to and including the closing quote.
+// It returns false if the end of the text is reached before the closing quote.
+func (scanner *Scanner) skipQuoted(quote, escape rune) bool {
+	for {
+		switch scanner.Pop() {
+		case EOF:
+			return false
+		case quote:
+			return true
+		case escape:
+			if scanner.Pop() == EOF {
+				return false
+			}
+		}
+	}
+}
+
+// A DelimitedOption configures how Scanner.ReadDelimited reads a delimited region.
+type DelimitedOption func(*delimitedOptions)
+
+// delimitedOptions holds the configuration of Scanner.ReadDelimited.
+type delimitedOptions struct {
+	nested      bool
+	keepEscapes bool
+}
+
+// DelimitedNested makes Scanner.ReadDelimited count nested pairs of the open and close delimiters, as in Ruby's %q(a (b) c).
+// Nesting only applies if the delimiters differ.
+func DelimitedNested() DelimitedOption {
+	return func(options *delimitedOptions) {
+		options.nested = true
+	}
+}
+
+// DelimitedKeepEscapes makes Scanner.ReadDelimited return the content with escape runes kept as they appear in the text.
+// Escaped delimiters still do not end the region.
+func DelimitedKeepEscapes() DelimitedOption {
+	return func(options *delimitedOptions) {
+		options.keepEscapes = true
+	}
+}
+
+// ReadDelimited consumes a region enclosed in the open and close delimiters starting at the current scanner position, e.g. a quoted string,
+// a bracketed attribute or a percent literal, and returns its content along with the span of the whole region including the delimiters.
+// Within the region, escape followed by open, close or escape stands for that rune, and escape followed by any other rune is kept as it is,
+// like for Scanner.ReadUntilUnescaped. EOF as escape disables escaping. Nesting and escape decoding are configured with DelimitedNested
+// and DelimitedKeepEscapes. Line breaks are normalized and escaped line breaks are skipped the same way Scanner.Pop does.
+// If the position does not start with open or the region is not closed, an error wrapping ErrUnbalanced is returned
+// along with the span of the offending part, and nothing is consumed.
+func (scanner *Scanner) ReadDelimited(open, close, escape rune, opts ...DelimitedOption) (string, Span, error) {
+	var options delimitedOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	start := scanner.TextPosition
+	savedComplex := scanner.isComplexSinceMark
+	fail := func(span Span, format string, args ...any) (string, Span, error) {
+		scanner.TextPosition = start
+		scanner.isComplexSinceMark = savedComplex
+		return "", span, scanner.ErrorfAt(span, "%w: "+format, append([]any{ErrUnbalanced}, args...)...)
+	}
+
+	if !scanner.Accept(open) {
+		return fail(Span{Start: start, End: start}, "expected %q", open)
+	}
+
+	var content strings.Builder
+	depth := 0
+	for {
+		span := scanner.PopSpan()
+		switch {
+		case span.Rune == EOF:
+			return fail(Span{Start: start, End: span.End}, "%q is never closed", open)
+		case span.Rune == escape && scanner.isDelimitedEscape(open, close, escape):
+			if options.keepEscapes {
+				content.WriteRune(span.Rune)
+			}
+			span.Rune = scanner.Pop()
+		case span.Rune == close && depth == 0:
+			return content.String(), Span{Start: start, End: span.End}, nil
+		case span.Rune == close:
+			depth--
+		case span.Rune == open && options.nested && open != close:
+			depth++
+		}
+		content.WriteRune(span.Rune)
+	}
+}
+
+// isDelimitedEscape returns whether the upcoming rune is escaped by an escape rune in front of it within Scanner.ReadDelimited.
+func (scanner *Scanner) isDelimitedEscape(open, close, escape rune) bool {
+	next := scanner.Peek()
+	return next != EOF && (next == open || next == close || next == escape)
+}
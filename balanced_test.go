@@ -0,0 +1,144 @@
+package scanner
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestScannerReadBalanced(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		options  []BalancedOption
+		expected string
+		end      int
+	}{
+		{name: "simple", input: "(a + b) * c", expected: "a + b", end: 7},
+		{name: "empty", input: "()", expected: "", end: 2},
+		{name: "nested", input: "(f(x, (y)) + 1)z", expected: "f(x, (y)) + 1", end: 15},
+		{name: "quoted delimiter", input: `(a ")" b)`, options: []BalancedOption{BalancedQuotes(`"'`)}, expected: `a ")" b`, end: 9},
+		{name: "escaped quote", input: `("\")" x)`, options: []BalancedOption{BalancedQuotes(`"`)}, expected: `"\")" x`, end: 9},
+		{name: "custom escape", input: `('a'')' )`, options: []BalancedOption{BalancedQuotes(`'`), BalancedEscape('\'')}, expected: `'a'')' `, end: 9},
+		{name: "line breaks normalized", input: "(a\r\nb)", expected: "a\nb", end: 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			result, span, err := scanner.ReadBalanced('(', ')', tt.options...)
+			if err != nil {
+				t.Fatalf("ReadBalanced() unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("ReadBalanced() = %q, expected %q", result, tt.expected)
+			}
+			if span.Start.Offset != 0 || span.End.Offset != tt.end || scanner.Offset != tt.end {
+				t.Errorf("ReadBalanced() span [%d, %d) at offset %d, expected [0, %d)", span.Start.Offset, span.End.Offset, scanner.Offset, tt.end)
+			}
+		})
+	}
+}
+
+func TestScannerReadBalancedErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		start, end int
+		message    string
+	}{
+		{name: "no open", input: "a)", start: 0, end: 0, message: `unbalanced delimiters: expected '(' at 1:1`},
+		{name: "EOF", input: "", start: 0, end: 0, message: `unbalanced delimiters: expected '(' at 1:1`},
+		{name: "unclosed", input: "(a", start: 0, end: 1, message: `unbalanced delimiters: '(' is never closed at 1:1`},
+		{name: "unclosed nested", input: "(a (b)\n(c", start: 7, end: 8, message: `unbalanced delimiters: '(' is never closed at 2:1`},
+		{name: "unclosed string", input: `(a "b)`, start: 3, end: 6, message: `unbalanced delimiters: string is never closed at 1:4`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			_, span, err := scanner.ReadBalanced('(', ')', BalancedQuotes(`"`))
+			if !errors.Is(err, ErrUnbalanced) {
+				t.Fatalf("ReadBalanced() error = %v, expected ErrUnbalanced", err)
+			}
+			if err.Error() != tt.message {
+				t.Errorf("ReadBalanced() error = %q, expected %q", err.Error(), tt.message)
+			}
+			if span.Start.Offset != tt.start || span.End.Offset != tt.end {
+				t.Errorf("ReadBalanced() span [%d, %d), expected [%d, %d)", span.Start.Offset, span.End.Offset, tt.start, tt.end)
+			}
+			if scanner.Offset != 0 {
+				t.Errorf("expected nothing consumed on error, got offset %d", scanner.Offset)
+			}
+		})
+	}
+}
+
+func TestScannerReadDelimited(t *testing.T) {
+	tests := []struct {
+		name              string
+		input             string
+		open, close, escp rune
+		options           []DelimitedOption
+		expected          string
+		end               int
+	}{
+		{name: "quoted", input: `"a\"b" x`, open: '"', close: '"', escp: '\\', expected: `a"b`, end: 6},
+		{name: "other escapes kept", input: `"a\nb"`, open: '"', close: '"', escp: '\\', expected: `a\nb`, end: 6},
+		{name: "keep escapes", input: `"a\"b"`, open: '"', close: '"', escp: '\\', options: []DelimitedOption{DelimitedKeepEscapes()}, expected: `a\"b`, end: 6},
+		{name: "bracketed", input: "[a=b]c", open: '[', close: ']', escp: EOF, expected: "a=b", end: 5},
+		{name: "not nested by default", input: "(a (b) c)", open: '(', close: ')', escp: EOF, expected: "a (b", end: 6},
+		{name: "nested", input: "(a (b) c)", open: '(', close: ')', escp: EOF, options: []DelimitedOption{DelimitedNested()}, expected: "a (b) c", end: 9},
+		{name: "nested escaped", input: `(a \( b)`, open: '(', close: ')', escp: '\\', options: []DelimitedOption{DelimitedNested()}, expected: "a ( b", end: 8},
+		{name: "custom escape", input: "|a||b|", open: '|', close: '|', escp: '|', expected: "a|b", end: 6},
+		{name: "line breaks normalized", input: "{a\r\nb}", open: '{', close: '}', escp: EOF, expected: "a\nb", end: 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			result, span, err := scanner.ReadDelimited(tt.open, tt.close, tt.escp, tt.options...)
+			if err != nil {
+				t.Fatalf("ReadDelimited() unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("ReadDelimited() = %q, expected %q", result, tt.expected)
+			}
+			if span.Start.Offset != 0 || span.End.Offset != tt.end || scanner.Offset != tt.end {
+				t.Errorf("ReadDelimited() span [%d, %d) at offset %d, expected [0, %d)", span.Start.Offset, span.End.Offset, scanner.Offset, tt.end)
+			}
+		})
+	}
+}
+
+func TestScannerReadDelimitedErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		end     int
+		message string
+	}{
+		{name: "no open", input: "a]", end: 0, message: `unbalanced delimiters: expected '[' at 1:1`},
+		{name: "unclosed", input: "[ab", end: 3, message: `unbalanced delimiters: '[' is never closed at 1:1`},
+		{name: "unclosed nested", input: "[a[b]", end: 5, message: `unbalanced delimiters: '[' is never closed at 1:1`},
+		{name: "escaped close", input: `[a\]`, end: 4, message: `unbalanced delimiters: '[' is never closed at 1:1`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			_, span, err := scanner.ReadDelimited('[', ']', '\\', DelimitedNested())
+			if !errors.Is(err, ErrUnbalanced) {
+				t.Fatalf("ReadDelimited() error = %v, expected ErrUnbalanced", err)
+			}
+			if err.Error() != tt.message {
+				t.Errorf("ReadDelimited() error = %q, expected %q", err.Error(), tt.message)
+			}
+			if span.Start.Offset != 0 || span.End.Offset != tt.end {
+				t.Errorf("ReadDelimited() span [%d, %d), expected [0, %d)", span.Start.Offset, span.End.Offset, tt.end)
+			}
+			if scanner.Offset != 0 {
+				t.Errorf("expected nothing consumed on error, got offset %d", scanner.Offset)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package scanner
+
+import "slices"
+
+// A Checkpoint is a saved state of a Scanner, created by Scanner.Save.
+type Checkpoint struct {
+	state *checkpointState
+}
+
+// checkpointState is the state of a Scanner captured by a Checkpoint.
+type checkpointState struct {
+	scannerState
+	discarded bool
+}
+
+// scannerState is the position, mark, named marks and mark stack of a Scanner.
+type scannerState struct {
+	pos                TextPosition
+	markedPos          TextPosition
+	isComplexSinceMark bool
+	namedMarks         map[string]TextPosition // shared with the Scanner until either side writes to it
+	markStack          []TextPosition
+}
+
+// state returns the current position, mark, named marks and mark stack of the Scanner. Unlike Scanner.Save, it does not allocate
+// unless marks were pushed.
+func (scanner *Scanner) state() scannerState {
+	scanner.namedMarksShared = scanner.namedMarks != nil
+	return scannerState{
+		pos:                scanner.TextPosition,
+		markedPos:          scanner.markedPos,
+		isComplexSinceMark: scanner.isComplexSinceMark,
+		namedMarks:         scanner.namedMarks,
+		markStack:          slices.Clone(scanner.markStack),
+	}
+}
+
+// restoreState resets the position, mark, named marks and mark stack of the Scanner to the given state.
+func (scanner *Scanner) restoreState(state scannerState) {
+	scanner.TextPosition = state.pos
+	scanner.markedPos = state.markedPos
+	scanner.isComplexSinceMark = state.isComplexSinceMark
+	scanner.namedMarks, scanner.namedMarksShared = state.namedMarks, state.namedMarks != nil
+	scanner.markStack = slices.Clone(state.markStack)
+}
+
+// Save returns a Checkpoint capturing the current position, mark, named marks and mark stack of the Scanner, to be restored with Scanner.Restore.
+// Unlike saving the position with Scanner.Pos, the checkpoint also keeps the information needed by Scanner.Slice
+// to normalize the text since the mark correctly.
+func (scanner *Scanner) Save() Checkpoint {
+	return Checkpoint{state: &checkpointState{scannerState: scanner.state()}}
+}
+
+// Restore resets the position, mark, named marks and mark stack of the Scanner to the state captured by the given Checkpoint.
+// Results memoized with Scanner.Memo are kept, so that backtracking does not apply a rule at the same position again.
+// A checkpoint can be restored any number of times until it is discarded.
+// Restore panics if the checkpoint was discarded or not created by Scanner.Save.
+func (scanner *Scanner) Restore(checkpoint Checkpoint) {
+	state := checkpoint.state
+	if state == nil {
+		panic("scanner: restore of zero Checkpoint")
+	}
+	if state.discarded {
+		panic("scanner: restore of discarded Checkpoint")
+	}
+
+	scanner.restoreState(state.scannerState)
+}
+
+// Discard releases the given Checkpoint once it is no longer needed, e.g. after the speculatively scanned text was accepted.
+// The checkpoint must not be restored afterwards. Discarding a checkpoint more than once has no effect.
+func (scanner *Scanner) Discard(checkpoint Checkpoint) {
+	if checkpoint.state != nil {
+		checkpoint.state.discarded = true
+	}
+}
+
+// Try runs fn on the Scanner and returns its result. If fn returns false, the position, mark, named marks and mark stack of the Scanner
+// are rolled back to the state before the call, as if fn had never run. If fn returns true, its changes are kept.
+func (scanner *Scanner) Try(fn func(*Scanner) bool) bool {
+	checkpoint := scanner.Save()
+	defer scanner.Discard(checkpoint)
+
+	if !fn(scanner) {
+		scanner.Restore(checkpoint)
+		return false
+	}
+	return true
+}
+
+// Attempt runs fn on the Scanner and returns its error. If fn returns an error, the position, mark, named marks and mark stack of the Scanner
+// are rolled back to the state before the call, as if fn had never run. If fn returns nil, its changes are kept.
+func (scanner *Scanner) Attempt(fn func(*Scanner) error) error {
+	checkpoint := scanner.Save()
+	defer scanner.Discard(checkpoint)
+
+	if err := fn(scanner); err != nil {
+		scanner.Restore(checkpoint)
+		return err
+	}
+	return nil
+}
+
+// Longest runs each of the given alternatives on the Scanner from the same starting state and keeps the changes of the one
+// that returned true after consuming the most input, as if only that alternative had run. Ties are won by the earlier alternative.
+// It returns the index of the chosen alternative, or -1 if no alternative returned true, in which case the state is left unchanged.
+func (scanner *Scanner) Longest(alternatives ...func(*Scanner) bool) int {
+	start := scanner.Save()
+	defer scanner.Discard(start)
+
+	best, bestEnd := -1, Checkpoint{}
+	for i, alternative := range alternatives {
+		scanner.Restore(start)
+		if !alternative(scanner) {
+			continue
+		}
+		if best < 0 || scanner.Offset > bestEnd.state.pos.Offset {
+			scanner.Discard(bestEnd)
+			best, bestEnd = i, scanner.Save()
+		}
+	}
+
+	if best < 0 {
+		scanner.Restore(start)
+		return -1
+	}
+	scanner.Restore(bestEnd)
+	scanner.Discard(bestEnd)
+	return best
+}
@@ -0,0 +1,327 @@
+package scanner
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestScannerCheckpoint(t *testing.T) {
+	scanner := NewScanner("ab\r\ncd\\\nef")
+	scanner.Pop()
+	scanner.Mark()
+
+	checkpoint := scanner.Save()
+	saved := scanner.Pos()
+
+	// scan across complex text and move the mark
+	for range 4 {
+		scanner.Pop()
+	}
+	scanner.Mark()
+	scanner.Pop()
+
+	scanner.Restore(checkpoint)
+	if scanner.Pos() != saved {
+		t.Errorf("position: expected %+v, got %+v", saved, scanner.Pos())
+	}
+	if scanner.Marked() != saved {
+		t.Errorf("mark: expected %+v, got %+v", saved, scanner.Marked())
+	}
+
+	// the restored state must know the slice is simple again
+	scanner.Pop()
+	if result := scanner.Slice(); result != "b" {
+		t.Errorf("Slice() = %q, expected %q", result, "b")
+	}
+
+	// restoring again works until discarded
+	scanner.Pop()
+	scanner.Pop()
+	scanner.Restore(checkpoint)
+	if scanner.Pos() != saved {
+		t.Errorf("position after second restore: expected %+v, got %+v", saved, scanner.Pos())
+	}
+}
+
+func TestScannerCheckpointComplexFlag(t *testing.T) {
+	scanner := NewScanner("a\r\nbc")
+	scanner.Mark()
+	scanner.Pop()
+	scanner.Pop()
+
+	checkpoint := scanner.Save()
+	scanner.Mark()
+	scanner.Pop()
+	scanner.Restore(checkpoint)
+
+	// the CRLF since the mark must still be normalized
+	if result := scanner.Slice(); result != "a\n" {
+		t.Errorf("Slice() = %q, expected %q", result, "a\n")
+	}
+}
+
+func TestScannerCheckpointDiscard(t *testing.T) {
+	scanner := NewScanner("abc")
+	checkpoint := scanner.Save()
+	scanner.Discard(checkpoint)
+	scanner.Discard(checkpoint)
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected Restore of discarded checkpoint to panic")
+		}
+	}()
+	scanner.Restore(checkpoint)
+}
+
+func TestScannerCheckpointZero(t *testing.T) {
+	scanner := NewScanner("abc")
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected Restore of zero checkpoint to panic")
+		}
+	}()
+	scanner.Restore(Checkpoint{})
+}
+
+func TestScannerTry(t *testing.T) {
+	consumeKeyword := func(keyword string) func(*Scanner) bool {
+		return func(scanner *Scanner) bool {
+			for _, r := range keyword {
+				if scanner.Pop() != r {
+					return false
+				}
+			}
+			return true
+		}
+	}
+
+	tests := []struct {
+		name        string
+		input       string
+		keyword     string
+		expected    bool
+		expectedPos TextPosition
+	}{
+		{
+			name:        "success commits",
+			input:       "for x",
+			keyword:     "for",
+			expected:    true,
+			expectedPos: TextPosition{Offset: 3, Line: 1, Col: 4},
+		},
+		{
+			name:        "failure rolls back",
+			input:       "foo x",
+			keyword:     "for",
+			expected:    false,
+			expectedPos: TextPosition{Offset: 0, Line: 1, Col: 1},
+		},
+		{
+			name:        "failure at EOF rolls back",
+			input:       "fo",
+			keyword:     "for",
+			expected:    false,
+			expectedPos: TextPosition{Offset: 0, Line: 1, Col: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+
+			if result := scanner.Try(consumeKeyword(tt.keyword)); result != tt.expected {
+				t.Errorf("Try() = %t, expected %t", result, tt.expected)
+			}
+			if scanner.Pos() != tt.expectedPos {
+				t.Errorf("position: expected %+v, got %+v", tt.expectedPos, scanner.Pos())
+			}
+		})
+	}
+}
+
+func TestScannerTryRollsBackMark(t *testing.T) {
+	scanner := NewScanner("a\r\nbc")
+	scanner.Mark()
+	scanner.Pop()
+
+	scanner.Try(func(scanner *Scanner) bool {
+		scanner.Pop()
+		scanner.Mark()
+		scanner.Pop()
+		return false
+	})
+
+	if scanner.Marked() != (TextPosition{Offset: 0, Line: 1, Col: 1}) {
+		t.Errorf("mark: expected beginning of text, got %+v", scanner.Marked())
+	}
+	scanner.Pop()
+	if result := scanner.Slice(); result != "a\n" {
+		t.Errorf("Slice() = %q, expected %q", result, "a\n")
+	}
+}
+
+func TestScannerTryRollsBackNamedMarks(t *testing.T) {
+	scanner := NewScanner("abc")
+	scanner.MarkNamed("kept")
+	saved := scanner.Save()
+
+	scanner.Try(func(scanner *Scanner) bool {
+		scanner.Pop()
+		scanner.MarkNamed("kept")
+		scanner.MarkNamed("leaked")
+		return false
+	})
+
+	if pos, _ := scanner.MarkedNamed("kept"); pos.Offset != 0 {
+		t.Errorf("mark \"kept\": expected offset 0, got %+v", pos)
+	}
+	if _, ok := scanner.MarkedNamed("leaked"); ok {
+		t.Errorf("mark \"leaked\" set inside a failed Try was kept")
+	}
+
+	// marking again after the rollback must not change the saved checkpoint
+	scanner.Pop()
+	scanner.MarkNamed("kept")
+	scanner.Restore(saved)
+	if pos, _ := scanner.MarkedNamed("kept"); pos.Offset != 0 {
+		t.Errorf("mark \"kept\" after Restore: expected offset 0, got %+v", pos)
+	}
+}
+
+func TestScannerTryNested(t *testing.T) {
+	scanner := NewScanner("abc")
+
+	result := scanner.Try(func(scanner *Scanner) bool {
+		scanner.Pop()
+		scanner.Try(func(scanner *Scanner) bool {
+			scanner.Pop()
+			return false
+		})
+		return scanner.Pop() == 'b'
+	})
+
+	if !result {
+		t.Errorf("expected outer Try to succeed")
+	}
+	if scanner.Offset != 2 {
+		t.Errorf("expected offset 2, got %d", scanner.Offset)
+	}
+}
+
+func TestScannerAttempt(t *testing.T) {
+	errNotDigit := errors.New("not a digit")
+	digit := func(scanner *Scanner) error {
+		if r := scanner.Pop(); r < '0' || r > '9' {
+			return errNotDigit
+		}
+		return nil
+	}
+
+	scanner := NewScanner("1a")
+
+	if err := scanner.Attempt(digit); err != nil {
+		t.Errorf("first Attempt: unexpected error: %v", err)
+	}
+	if scanner.Offset != 1 {
+		t.Errorf("expected offset 1 after success, got %d", scanner.Offset)
+	}
+
+	if err := scanner.Attempt(digit); !errors.Is(err, errNotDigit) {
+		t.Errorf("second Attempt: expected errNotDigit, got %v", err)
+	}
+	if scanner.Offset != 1 {
+		t.Errorf("expected offset 1 after failure, got %d", scanner.Offset)
+	}
+}
+
+func TestScannerAttemptRestoresMarkStack(t *testing.T) {
+	scanner := NewScanner("abcd")
+	scanner.PushMark()
+	scanner.Pop()
+
+	err := scanner.Attempt(func(scanner *Scanner) error {
+		scanner.PopMark()
+		scanner.Pop()
+		scanner.PushMark()
+		scanner.PushMark()
+		return errors.New("failed")
+	})
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+
+	if scanner.MarkDepth() != 1 {
+		t.Errorf("MarkDepth() = %d, expected 1", scanner.MarkDepth())
+	}
+	scanner.Pop()
+	if result := scanner.PopMark(); result != "ab" {
+		t.Errorf("PopMark() = %q, expected %q", result, "ab")
+	}
+}
+
+func TestScannerLongest(t *testing.T) {
+	consume := func(s string) func(*Scanner) bool {
+		return func(scanner *Scanner) bool {
+			for _, r := range s {
+				if scanner.Pop() != r {
+					return false
+				}
+			}
+			return true
+		}
+	}
+
+	tests := []struct {
+		name         string
+		input        string
+		alternatives []func(*Scanner) bool
+		expected     int
+		offset       int
+	}{
+		{name: "longest wins", input: "<<=", alternatives: []func(*Scanner) bool{consume("<"), consume("<<="), consume("<<")}, expected: 1, offset: 3},
+		{name: "tie goes to first", input: "ab", alternatives: []func(*Scanner) bool{consume("a"), consume("b"), consume("a")}, expected: 0, offset: 1},
+		{name: "failing longer is ignored", input: "abc", alternatives: []func(*Scanner) bool{consume("ab"), consume("abd")}, expected: 0, offset: 2},
+		{name: "no match", input: "abc", alternatives: []func(*Scanner) bool{consume("b"), consume("c")}, expected: -1, offset: 0},
+		{name: "no alternatives", input: "abc", alternatives: nil, expected: -1, offset: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			if result := scanner.Longest(tt.alternatives...); result != tt.expected {
+				t.Errorf("Longest() = %d, expected %d", result, tt.expected)
+			}
+			if scanner.Offset != tt.offset {
+				t.Errorf("expected offset %d, got %d", tt.offset, scanner.Offset)
+			}
+		})
+	}
+}
+
+func TestScannerLongestKeepsWinnerState(t *testing.T) {
+	scanner := NewScanner("a\r\nbc")
+	scanner.Mark()
+
+	result := scanner.Longest(
+		func(scanner *Scanner) bool {
+			scanner.Pop()
+			scanner.PushMark()
+			return true
+		},
+		func(scanner *Scanner) bool {
+			scanner.PopN(3)
+			return true
+		},
+	)
+	if result != 1 {
+		t.Fatalf("Longest() = %d, expected 1", result)
+	}
+	if scanner.MarkDepth() != 0 {
+		t.Errorf("MarkDepth() = %d, expected 0", scanner.MarkDepth())
+	}
+	if slice := scanner.Slice(); slice != "a\nb" {
+		t.Errorf("Slice() = %q, expected %q", slice, "a\nb")
+	}
+}
@@ -0,0 +1,135 @@
+package scanner
+
+import (
+	"unicode"
+	"unicode/utf16"
+)
+
+// ColumnMode determines how the column component of a TextPosition is counted.
+type ColumnMode int
+
+const (
+	// ColumnRunes counts columns in runes (Unicode code points). This is the default.
+	ColumnRunes ColumnMode = iota
+	// ColumnUTF16 counts columns in UTF-16 code units, as required by the Language Server Protocol.
+	// Runes outside the Basic Multilingual Plane advance the column by 2.
+	ColumnUTF16
+	// ColumnDisplayWidth counts columns in terminal cells.
+	// East Asian wide and fullwidth runes advance the column by 2, combining marks and format characters by 0.
+	ColumnDisplayWidth
+)
+
+// WithColumnMode sets how the Scanner counts columns. The default is ColumnRunes.
+func WithColumnMode(mode ColumnMode) Option {
+	return func(scanner *Scanner) {
+		scanner.columnMode = mode
+	}
+}
+
+// ColumnMode returns how the Scanner counts columns.
+func (scanner *Scanner) ColumnMode() ColumnMode {
+	return scanner.columnMode
+}
+
+// positionOptions returns the options making another Scanner advance positions the same way as the Scanner.
+func (scanner *Scanner) positionOptions() []Option {
+	options := []Option{WithColumnMode(scanner.columnMode)}
+	if scanner.colBase == 0 {
+		options = append(options, WithZeroBasedColumns())
+	}
+	if scanner.runeIndex {
+		options = append(options, WithRuneIndex())
+	}
+	return options
+}
+
+// columnWidth returns by how much the given rune advances the column in the given mode.
+func (mode ColumnMode) columnWidth(r rune) int {
+	switch mode {
+	case ColumnUTF16:
+		if n := utf16.RuneLen(r); n > 0 {
+			return n
+		}
+	case ColumnDisplayWidth:
+		return displayWidth(r)
+	}
+	return 1
+}
+
+// displayWidth returns the number of terminal cells the given rune occupies.
+func displayWidth(r rune) int {
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case unicode.Is(wideRunes, r):
+		return 2
+	}
+	return 1
+}
+
+// wideRunes contains the runes with the East Asian Width property Wide (W) or Fullwidth (F),
+// including the emoji presented as wide by terminals.
+var wideRunes = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x1100, Hi: 0x115F, Stride: 1},
+		{Lo: 0x231A, Hi: 0x231B, Stride: 1},
+		{Lo: 0x2329, Hi: 0x232A, Stride: 1},
+		{Lo: 0x23E9, Hi: 0x23EC, Stride: 1},
+		{Lo: 0x23F0, Hi: 0x23F3, Stride: 3},
+		{Lo: 0x25FD, Hi: 0x25FE, Stride: 1},
+		{Lo: 0x2614, Hi: 0x2615, Stride: 1},
+		{Lo: 0x2648, Hi: 0x2653, Stride: 1},
+		{Lo: 0x267F, Hi: 0x2693, Stride: 20},
+		{Lo: 0x26A1, Hi: 0x26A1, Stride: 1},
+		{Lo: 0x26AA, Hi: 0x26AB, Stride: 1},
+		{Lo: 0x26BD, Hi: 0x26BE, Stride: 1},
+		{Lo: 0x26C4, Hi: 0x26C5, Stride: 1},
+		{Lo: 0x26CE, Hi: 0x26D4, Stride: 6},
+		{Lo: 0x26EA, Hi: 0x26EA, Stride: 1},
+		{Lo: 0x26F2, Hi: 0x26F3, Stride: 1},
+		{Lo: 0x26F5, Hi: 0x26FA, Stride: 5},
+		{Lo: 0x26FD, Hi: 0x26FD, Stride: 1},
+		{Lo: 0x2705, Hi: 0x2705, Stride: 1},
+		{Lo: 0x270A, Hi: 0x270B, Stride: 1},
+		{Lo: 0x2728, Hi: 0x2728, Stride: 1},
+		{Lo: 0x274C, Hi: 0x274E, Stride: 2},
+		{Lo: 0x2753, Hi: 0x2755, Stride: 1},
+		{Lo: 0x2757, Hi: 0x2757, Stride: 1},
+		{Lo: 0x2795, Hi: 0x2797, Stride: 1},
+		{Lo: 0x27B0, Hi: 0x27BF, Stride: 15},
+		{Lo: 0x2B1B, Hi: 0x2B1C, Stride: 1},
+		{Lo: 0x2B50, Hi: 0x2B55, Stride: 5},
+		{Lo: 0x2E80, Hi: 0x303E, Stride: 1},
+		{Lo: 0x3041, Hi: 0x33FF, Stride: 1},
+		{Lo: 0x3400, Hi: 0x4DBF, Stride: 1},
+		{Lo: 0x4E00, Hi: 0x9FFF, Stride: 1},
+		{Lo: 0xA000, Hi: 0xA4CF, Stride: 1},
+		{Lo: 0xA960, Hi: 0xA97F, Stride: 1},
+		{Lo: 0xAC00, Hi: 0xD7A3, Stride: 1},
+		{Lo: 0xF900, Hi: 0xFAFF, Stride: 1},
+		{Lo: 0xFE10, Hi: 0xFE19, Stride: 1},
+		{Lo: 0xFE30, Hi: 0xFE6F, Stride: 1},
+		{Lo: 0xFF00, Hi: 0xFF60, Stride: 1},
+		{Lo: 0xFFE0, Hi: 0xFFE6, Stride: 1},
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x16FE0, Hi: 0x16FE4, Stride: 1},
+		{Lo: 0x17000, Hi: 0x18AFF, Stride: 1},
+		{Lo: 0x1B000, Hi: 0x1B2FF, Stride: 1},
+		{Lo: 0x1F004, Hi: 0x1F004, Stride: 1},
+		{Lo: 0x1F0CF, Hi: 0x1F0CF, Stride: 1},
+		{Lo: 0x1F18E, Hi: 0x1F18E, Stride: 1},
+		{Lo: 0x1F191, Hi: 0x1F19A, Stride: 1},
+		{Lo: 0x1F200, Hi: 0x1F202, Stride: 1},
+		{Lo: 0x1F210, Hi: 0x1F23B, Stride: 1},
+		{Lo: 0x1F240, Hi: 0x1F248, Stride: 1},
+		{Lo: 0x1F250, Hi: 0x1F251, Stride: 1},
+		{Lo: 0x1F260, Hi: 0x1F265, Stride: 1},
+		{Lo: 0x1F300, Hi: 0x1F64F, Stride: 1},
+		{Lo: 0x1F680, Hi: 0x1F6FF, Stride: 1},
+		{Lo: 0x1F900, Hi: 0x1F9FF, Stride: 1},
+		{Lo: 0x1FA70, Hi: 0x1FAFF, Stride: 1},
+		{Lo: 0x20000, Hi: 0x2FFFD, Stride: 1},
+		{Lo: 0x30000, Hi: 0x3FFFD, Stride: 1},
+	},
+}
@@ -0,0 +1,217 @@
+package scanner
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestScannerColumnModeUTF16(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []TextPosition
+	}{
+		{
+			name:  "ASCII",
+			input: "ab",
+			expected: []TextPosition{
+				{Offset: 1, Line: 1, Col: 2},
+				{Offset: 2, Line: 1, Col: 3},
+			},
+		},
+		{
+			name:  "BMP characters",
+			input: "αβ",
+			expected: []TextPosition{
+				{Offset: 2, Line: 1, Col: 2},
+				{Offset: 4, Line: 1, Col: 3},
+			},
+		},
+		{
+			name:  "supplementary characters",
+			input: "a😀b",
+			expected: []TextPosition{
+				{Offset: 1, Line: 1, Col: 2},
+				{Offset: 5, Line: 1, Col: 4},
+				{Offset: 6, Line: 1, Col: 5},
+			},
+		},
+		{
+			name:  "line break resets column",
+			input: "😀\n😀",
+			expected: []TextPosition{
+				{Offset: 4, Line: 1, Col: 3},
+				{Offset: 5, Line: 2, Col: 1},
+				{Offset: 9, Line: 2, Col: 3},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input, WithColumnMode(ColumnUTF16))
+
+			for i, expectedPos := range tt.expected {
+				scanner.Pop()
+				if scanner.Pos() != expectedPos {
+					t.Errorf("at step %d: expected position %+v, got %+v", i, expectedPos, scanner.Pos())
+				}
+			}
+		})
+	}
+}
+
+func TestScannerColumnModeDefault(t *testing.T) {
+	scanner := NewScanner("😀b")
+
+	if scanner.ColumnMode() != ColumnRunes {
+		t.Errorf("ColumnMode() = %d, expected ColumnRunes", scanner.ColumnMode())
+	}
+
+	scanner.Pop()
+	expected := TextPosition{Offset: 4, Line: 1, Col: 2}
+	if scanner.Pos() != expected {
+		t.Errorf("expected position %+v, got %+v", expected, scanner.Pos())
+	}
+}
+
+func TestScannerColumnModeUTF16Lookup(t *testing.T) {
+	input := "a😀b\nc😀"
+
+	popScanner := NewScanner(input, WithColumnMode(ColumnUTF16))
+	lookupScanner := NewScanner(input, WithColumnMode(ColumnUTF16))
+	for {
+		pos := popScanner.Pos()
+
+		offset, err := lookupScanner.OffsetOf(pos.Line, pos.Col)
+		if err != nil {
+			t.Errorf("OffsetOf(%d, %d): unexpected error: %v", pos.Line, pos.Col, err)
+		} else if offset != pos.Offset {
+			t.Errorf("OffsetOf(%d, %d): expected %d, got %d", pos.Line, pos.Col, pos.Offset, offset)
+		}
+
+		if err := lookupScanner.SetOffset(pos.Offset); err != nil {
+			t.Errorf("SetOffset(%d): unexpected error: %v", pos.Offset, err)
+		} else if lookupScanner.Pos() != pos {
+			t.Errorf("SetOffset(%d): expected %+v, got %+v", pos.Offset, pos, lookupScanner.Pos())
+		}
+
+		if popScanner.Pop() == EOF {
+			break
+		}
+	}
+
+	// column 3 is between the two surrogates of 😀
+	if _, err := lookupScanner.OffsetOf(1, 3); !errors.Is(err, ErrInvalidPosition) {
+		t.Errorf("OffsetOf(1, 3): expected ErrInvalidPosition, got %v", err)
+	}
+}
+
+func TestScannerColumnModeDisplayWidth(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []int // column after each pop
+	}{
+		{
+			name:     "ASCII",
+			input:    "ab",
+			expected: []int{2, 3},
+		},
+		{
+			name:     "CJK ideographs",
+			input:    "a漢字b",
+			expected: []int{2, 4, 6, 7},
+		},
+		{
+			name:     "Hangul syllables",
+			input:    "한글",
+			expected: []int{3, 5},
+		},
+		{
+			name:     "fullwidth forms",
+			input:    "ＡＢ",
+			expected: []int{3, 5},
+		},
+		{
+			name:     "wide emoji",
+			input:    "😀x",
+			expected: []int{3, 4},
+		},
+		{
+			name:     "combining mark",
+			input:    "e\u0301x",
+			expected: []int{2, 2, 3},
+		},
+		{
+			name:     "zero width space",
+			input:    "a\u200bb",
+			expected: []int{2, 2, 3},
+		},
+		{
+			name:     "narrow non-ASCII",
+			input:    "αβ",
+			expected: []int{2, 3},
+		},
+		{
+			name:     "line break resets column",
+			input:    "漢\n字",
+			expected: []int{3, 1, 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input, WithColumnMode(ColumnDisplayWidth))
+
+			for i, expectedCol := range tt.expected {
+				scanner.Pop()
+				if scanner.Col != expectedCol {
+					t.Errorf("at step %d: expected column %d, got %d", i, expectedCol, scanner.Col)
+				}
+			}
+		})
+	}
+}
+
+func TestScannerColumnModeDisplayWidthLookup(t *testing.T) {
+	input := "a漢b\n字e\u0301"
+
+	popScanner := NewScanner(input, WithColumnMode(ColumnDisplayWidth))
+	seekScanner := NewScanner(input, WithColumnMode(ColumnDisplayWidth))
+	for {
+		pos := popScanner.Pos()
+
+		if err := seekScanner.SetOffset(pos.Offset); err != nil {
+			t.Errorf("SetOffset(%d): unexpected error: %v", pos.Offset, err)
+		} else if seekScanner.Pos() != pos {
+			t.Errorf("SetOffset(%d): expected %+v, got %+v", pos.Offset, pos, seekScanner.Pos())
+		}
+
+		if popScanner.Pop() == EOF {
+			break
+		}
+	}
+
+	tests := []struct {
+		line, col int
+		expected  int
+	}{
+		{line: 1, col: 2, expected: 1},
+		{line: 1, col: 4, expected: 4},
+		{line: 2, col: 3, expected: 9},
+	}
+	for _, tt := range tests {
+		offset, err := seekScanner.OffsetOf(tt.line, tt.col)
+		if err != nil {
+			t.Errorf("OffsetOf(%d, %d): unexpected error: %v", tt.line, tt.col, err)
+		} else if offset != tt.expected {
+			t.Errorf("OffsetOf(%d, %d): expected %d, got %d", tt.line, tt.col, tt.expected, offset)
+		}
+	}
+
+	// column 3 is the second cell of 漢
+	if _, err := seekScanner.OffsetOf(1, 3); !errors.Is(err, ErrInvalidPosition) {
+		t.Errorf("OffsetOf(1, 3): expected ErrInvalidPosition, got %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package scanner
+
+import (
+	"errors"
+)
+
+// ErrUnterminatedComment is returned when a block comment is not closed before the end of the text.
+var ErrUnterminatedComment = errors.New("unterminated comment")
+
+// blockComment holds the markers of a block comment syntax.
+type blockComment struct {
+	open, close string
+}
+
+// WithLineComments adds markers that start a comment extending to the end of the line, e.g. "//" or "#", for Scanner.SkipComment.
+func WithLineComments(markers ...string) Option {
+	return func(scanner *Scanner) {
+		scanner.lineComments = append(scanner.lineComments, markers...)
+	}
+}
+
+// WithBlockComments adds a block comment syntax enclosed in the open and close markers, e.g. "/*" and "*/", for Scanner.SkipComment.
+// Block comments do not nest.
+func WithBlockComments(open, close string) Option {
+	return func(scanner *Scanner) {
+		scanner.blockComments = append(scanner.blockComments, blockComment{open: open, close: close})
+	}
+}
+
+// SkipComment consumes the comment starting at the current scanner position, if any, and returns its span and whether there was one.
+// The comment syntaxes are configured with WithLineComments and WithBlockComments; if several markers match, the first configured one wins,
+// line comments being checked before block comments. A line comment extends up to, but not including, the line break ending it.
+// If a block comment is not closed, an error wrapping ErrUnterminatedComment is returned along with the span from its start to the end of the text,
+// and nothing is consumed.
+func (scanner *Scanner) SkipComment() (Span, bool, error) {
+	start := scanner.TextPosition
+	savedComplex := scanner.isComplexSinceMark
+	for _, marker := range scanner.lineComments {
+		if marker != "" && scanner.ConsumeString(marker) {
+			return Span{Start: start, End: scanner.SkipUntil('\n').End}, true, nil
+		}
+	}
+
+	for _, comment := range scanner.blockComments {
+		if comment.open == "" || !scanner.ConsumeString(comment.open) {
+			continue
+		}
+
+		end := scanner.SkipUntilString(comment.close).End
+		if !scanner.ConsumeString(comment.close) {
+			scanner.TextPosition = start
+			scanner.isComplexSinceMark = savedComplex
+			span := Span{Start: start, End: end}
+			return span, true, scanner.ErrorfAt(span, "%w: %q is never closed", ErrUnterminatedComment, comment.open)
+		}
+		return Span{Start: start, End: scanner.TextPosition}, true, nil
+	}
+
+	return Span{Start: start, End: start}, false, nil
+}
+
+// SkipTrivia consumes any sequence of whitespace, as skipped by Scanner.SkipWhitespace, and comments, as skipped by Scanner.SkipComment,
+// starting at the current scanner position and returns the span it covers.
+// If a block comment is not closed, the error of Scanner.SkipComment is returned and the scanner stays in front of the comment.
+func (scanner *Scanner) SkipTrivia() (Span, error) {
+	start := scanner.TextPosition
+	for {
+		scanner.SkipWhitespace()
+		_, ok, err := scanner.SkipComment()
+		if err != nil || !ok {
+			return Span{Start: start, End: scanner.TextPosition}, err
+		}
+	}
+}
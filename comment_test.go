@@ -0,0 +1,79 @@
+package scanner
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestScannerSkipComment(t *testing.T) {
+	options := []Option{WithLineComments("//", "#"), WithBlockComments("/*", "*/"), WithBlockComments("{-", "-}")}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected int
+		ok       bool
+	}{
+		{name: "none", input: "x // y", expected: 0, ok: false},
+		{name: "line", input: "// comment\nx", expected: 10, ok: true},
+		{name: "second line marker", input: "# comment\r\nx", expected: 9, ok: true},
+		{name: "line at EOF", input: "//", expected: 2, ok: true},
+		{name: "block", input: "/* a\n b */x", expected: 10, ok: true},
+		{name: "block not nested", input: "/* /* */ */", expected: 8, ok: true},
+		{name: "second block syntax", input: "{- x -}y", expected: 7, ok: true},
+		{name: "block closing across escaped line break", input: "/* *\\\n/x", expected: 7, ok: true},
+		{name: "lone slash", input: "/ 2", expected: 0, ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input, options...)
+			span, ok, err := scanner.SkipComment()
+			if err != nil {
+				t.Fatalf("SkipComment() unexpected error: %v", err)
+			}
+			if ok != tt.ok || span.Start.Offset != 0 || span.End.Offset != tt.expected {
+				t.Errorf("SkipComment() = [%d, %d), %v, expected [0, %d), %v", span.Start.Offset, span.End.Offset, ok, tt.expected, tt.ok)
+			}
+			if scanner.Offset != tt.expected {
+				t.Errorf("expected offset %d, got %d", tt.expected, scanner.Offset)
+			}
+		})
+	}
+}
+
+func TestScannerSkipCommentUnterminated(t *testing.T) {
+	scanner := NewScanner("x /* abc", WithBlockComments("/*", "*/"))
+	scanner.Pop()
+
+	span, err := scanner.SkipTrivia()
+	if !errors.Is(err, ErrUnterminatedComment) {
+		t.Fatalf("SkipTrivia() error = %v, expected ErrUnterminatedComment", err)
+	}
+	if message := `unterminated comment: "/*" is never closed at 1:3`; err.Error() != message {
+		t.Errorf("SkipTrivia() error = %q, expected %q", err.Error(), message)
+	}
+	if span.End.Offset != 2 || scanner.Offset != 2 {
+		t.Errorf("SkipTrivia() = [%d, %d) at offset %d, expected to stop in front of the comment at 2", span.Start.Offset, span.End.Offset, scanner.Offset)
+	}
+
+	commentSpan, ok, _ := scanner.SkipComment()
+	if !ok || commentSpan.Start.Offset != 2 || commentSpan.End.Offset != 8 {
+		t.Errorf("SkipComment() = [%d, %d), %v, expected [2, 8), true", commentSpan.Start.Offset, commentSpan.End.Offset, ok)
+	}
+}
+
+func TestScannerSkipTrivia(t *testing.T) {
+	scanner := NewScanner("  // a\n\t/* b */ # c\n  x", WithLineComments("//", "#"), WithBlockComments("/*", "*/"))
+
+	span, err := scanner.SkipTrivia()
+	if err != nil {
+		t.Fatalf("SkipTrivia() unexpected error: %v", err)
+	}
+	if span.Start.Offset != 0 || span.End.Offset != 22 {
+		t.Errorf("SkipTrivia() = [%d, %d), expected [0, 22)", span.Start.Offset, span.End.Offset)
+	}
+	if r := scanner.Peek(); r != 'x' {
+		t.Errorf("Peek() = %q, expected 'x'", r)
+	}
+}
@@ -0,0 +1,52 @@
+package scanner
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalidCSV is returned when the text at the scanner position is not a well-formed CSV field.
+var ErrInvalidCSV = errors.New("invalid CSV field")
+
+// ReadCSVField consumes a field of delimiter-separated values starting at the current scanner position, following the quoting rules of RFC 4180,
+// and returns its value along with the span of the field including any quotes. The delimiter or line break ending the field is not consumed,
+// so a record is read by calling ReadCSVField until Scanner.Accept(delim) returns false.
+// A field enclosed in double quotes may contain the delimiter and line breaks, two double quotes within it stand for one.
+// Line breaks are normalized and escaped line breaks are skipped the same way Scanner.Pop does.
+// If a quoted field is unterminated or followed by anything but the delimiter, a line break or the end of the text,
+// or an unquoted field contains a double quote, an error wrapping ErrInvalidCSV is returned along with the span of the offending part,
+// and nothing is consumed.
+func (scanner *Scanner) ReadCSVField(delim rune) (string, Span, error) {
+	start := scanner.TextPosition
+	savedComplex := scanner.isComplexSinceMark
+	fail := func(span Span, format string, args ...any) (string, Span, error) {
+		scanner.TextPosition = start
+		scanner.isComplexSinceMark = savedComplex
+		return "", span, scanner.ErrorfAt(span, "%w: "+format, append([]any{ErrInvalidCSV}, args...)...)
+	}
+
+	if !scanner.Accept('"') {
+		text, span := scanner.TakeWhile(func(r rune) bool { return r != delim && r != '\n' && r != '"' })
+		if quote := scanner.PeekSpan(); quote.Rune == '"' {
+			return fail(quote.Span, "bare quote in unquoted field")
+		}
+		return text, span, nil
+	}
+
+	var value strings.Builder
+	for {
+		span := scanner.PopSpan()
+		switch {
+		case span.Rune == EOF:
+			return fail(Span{Start: start, End: span.End}, "unterminated quoted field")
+		case span.Rune == '"' && !scanner.Accept('"'):
+			if next := scanner.PeekSpan(); next.Rune != delim && next.Rune != '\n' && next.Rune != EOF {
+				return fail(next.Span, "unexpected %s after quoted field", describeRune(next.Rune))
+			}
+			return value.String(), Span{Start: start, End: span.End}, nil
+		default:
+			// an escaped quote was consumed by the Accept above and stands for itself
+			value.WriteRune(span.Rune)
+		}
+	}
+}
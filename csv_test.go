@@ -0,0 +1,98 @@
+package scanner
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestScannerReadCSVField(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		delim    rune
+		expected string
+		end      int
+	}{
+		{name: "unquoted", input: "abc,def", delim: ',', expected: "abc", end: 3},
+		{name: "empty", input: ",x", delim: ',', expected: "", end: 0},
+		{name: "ends at line break", input: "abc\r\ndef", delim: ',', expected: "abc", end: 3},
+		{name: "custom delimiter", input: "a,b;c", delim: ';', expected: "a,b", end: 3},
+		{name: "quoted", input: `"a,b",c`, delim: ',', expected: "a,b", end: 5},
+		{name: "quoted escaped quote", input: `"say ""hi"""`, delim: ',', expected: `say "hi"`, end: 12},
+		{name: "quoted line break", input: "\"a\r\nb\"\n", delim: ',', expected: "a\nb", end: 6},
+		{name: "quoted empty", input: `""`, delim: ',', expected: "", end: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			result, span, err := scanner.ReadCSVField(tt.delim)
+			if err != nil {
+				t.Fatalf("ReadCSVField() unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("ReadCSVField() = %q, expected %q", result, tt.expected)
+			}
+			if span.Start.Offset != 0 || span.End.Offset != tt.end || scanner.Offset != tt.end {
+				t.Errorf("ReadCSVField() span [%d, %d) at offset %d, expected [0, %d)", span.Start.Offset, span.End.Offset, scanner.Offset, tt.end)
+			}
+		})
+	}
+}
+
+func TestScannerReadCSVFieldErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		start, end int
+		message    string
+	}{
+		{name: "bare quote", input: `ab"c`, start: 2, end: 3, message: `invalid CSV field: bare quote in unquoted field at 1:3`},
+		{name: "unterminated", input: `"abc`, start: 0, end: 4, message: `invalid CSV field: unterminated quoted field at 1:1`},
+		{name: "text after quote", input: `"ab"c,`, start: 4, end: 5, message: `invalid CSV field: unexpected 'c' after quoted field at 1:5`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			_, span, err := scanner.ReadCSVField(',')
+			if !errors.Is(err, ErrInvalidCSV) {
+				t.Fatalf("ReadCSVField() error = %v, expected ErrInvalidCSV", err)
+			}
+			if err.Error() != tt.message {
+				t.Errorf("ReadCSVField() error = %q, expected %q", err.Error(), tt.message)
+			}
+			if span.Start.Offset != tt.start || span.End.Offset != tt.end {
+				t.Errorf("ReadCSVField() span [%d, %d), expected [%d, %d)", span.Start.Offset, span.End.Offset, tt.start, tt.end)
+			}
+			if scanner.Offset != 0 {
+				t.Errorf("expected nothing consumed on error, got offset %d", scanner.Offset)
+			}
+		})
+	}
+}
+
+func TestScannerReadCSVFieldRecords(t *testing.T) {
+	scanner := NewScanner("id,name\n1,\"Doe, Jane\"\n")
+
+	var records [][]string
+	for !scanner.IsEOF() {
+		var record []string
+		for {
+			field, _, err := scanner.ReadCSVField(',')
+			if err != nil {
+				t.Fatalf("ReadCSVField() unexpected error: %v", err)
+			}
+			record = append(record, field)
+			if !scanner.Accept(',') {
+				break
+			}
+		}
+		scanner.Accept('\n')
+		records = append(records, record)
+	}
+
+	if len(records) != 2 || len(records[1]) != 2 || records[1][1] != "Doe, Jane" {
+		t.Errorf("got records %q, expected [[id name] [1 Doe, Jane]]", records)
+	}
+}
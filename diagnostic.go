@@ -0,0 +1,286 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A RenderOption configures how Scanner.Render and Scanner.RenderSpan format a diagnostic.
+type RenderOption func(*renderOptions)
+
+// renderOptions holds the configuration of Scanner.Render and Scanner.RenderSpan.
+type renderOptions struct {
+	color    bool
+	severity Severity
+}
+
+// ANSI escape sequences used by colored diagnostics.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiGutter = "\x1b[1;34m"
+)
+
+// severityColors holds the ANSI escape sequences coloring the label and marks of diagnostics of each severity.
+var severityColors = map[Severity]string{
+	SeverityError:   "\x1b[1;31m",
+	SeverityWarning: "\x1b[1;33m",
+	SeverityInfo:    "\x1b[1;36m",
+	SeverityHint:    "\x1b[1;32m",
+}
+
+// RenderColor makes diagnostics colored with ANSI escape sequences: the header is bold, the severity and the marks under the span
+// are colored by severity, e.g. red for errors and yellow for warnings, and the line numbers are blue, like in the output of modern compilers.
+func RenderColor() RenderOption {
+	return func(options *renderOptions) {
+		options.color = true
+	}
+}
+
+// RenderColorAuto makes diagnostics colored like RenderColor if they are written to w and w is a terminal,
+// unless the NO_COLOR environment variable is set to a non-empty value (see https://no-color.org).
+func RenderColorAuto(w io.Writer) RenderOption {
+	return func(options *renderOptions) {
+		options.color = os.Getenv("NO_COLOR") == "" && isTerminal(w)
+	}
+}
+
+// RenderSeverity sets the severity Scanner.RenderSpan labels the diagnostic with. The default is SeverityError.
+// Scanner.Render uses the severity of the *Error instead.
+func RenderSeverity(severity Severity) RenderOption {
+	return func(options *renderOptions) {
+		options.severity = severity
+	}
+}
+
+// isTerminal returns whether w is a file referring to a terminal.
+func isTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// paint returns s enclosed in the given ANSI escape sequence and a reset if colors are enabled, and s as it is otherwise.
+func (options *renderOptions) paint(style, s string) string {
+	if !options.color || style == "" || s == "" {
+		return s
+	}
+	return style + s + ansiReset
+}
+
+// label returns the severity followed by a colon, colored by severity if colors are enabled.
+func (options *renderOptions) label() string {
+	return options.paint(severityColors[options.severity], options.severity.String()+":")
+}
+
+// Render formats err like a compiler diagnostic: a header with the position and the message, followed by the line the error
+// is reported at and a caret under the offending rune, continued by tildes under the rest of a longer span, e.g.
+//
+//	main.go:3:9: error: invalid string literal: unterminated string
+//	  3 |     x := "abc
+//	    |          ^~~~
+//
+// If err is or wraps an *Error, its span is underlined, its position is moved from the end of the message into the header
+// and it is labeled with its severity. Other errors are rendered as an error message alone.
+// The rendered text always ends with a line break, except for a nil err, which is rendered as an empty string.
+// Colors are enabled with RenderColor or RenderColorAuto.
+func (scanner *Scanner) Render(err error, opts ...RenderOption) string {
+	if err == nil {
+		return ""
+	}
+	var positional *Error
+	if !errors.As(err, &positional) {
+		var options renderOptions
+		for _, opt := range opts {
+			opt(&options)
+		}
+		return options.label() + " " + options.paint(ansiBold, err.Error()) + "\n"
+	}
+	msg := strings.TrimSuffix(err.Error(), " at "+positional.Pos().String())
+	return scanner.RenderSpan(positional.Span, msg, slices.Concat(opts, []RenderOption{RenderSeverity(positional.Severity)})...)
+}
+
+// RenderSpan formats msg as a diagnostic about span in the text of the Scanner like Scanner.Render does.
+// Lines are looked up by the offsets of the span, so it must refer to the text of the Scanner; offsets out of range are clamped.
+// A span crossing lines is shown by its first line, underlined from the caret to the end of the line, and its last line,
+// underlined up to the end of the span. Lines in between are elided with a line of "...".
+// Tabs are expanded to the tab width set by WithTabWidth and wide runes are underlined with two cells.
+func (scanner *Scanner) RenderSpan(span Span, msg string, opts ...RenderOption) string {
+	var options renderOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var out strings.Builder
+	out.WriteString(options.paint(ansiBold, span.Start.String()+":") + " " + options.label() + " " + options.paint(ansiBold, msg) + "\n")
+
+	start := max(0, min(span.Start.Offset, len(scanner.text)))
+	end := max(start, min(span.End.Offset, len(scanner.text)))
+
+	// a line is the last one starting at or before an offset, a span ending right after a line break ends on the line of the break
+	starts := scanner.lineIndex()
+	first := sort.SearchInts(starts, start+1)
+	last := first
+	if end > start {
+		last = sort.SearchInts(starts, end)
+	}
+
+	gutter := len(strconv.Itoa(scanner.lineNumber(last)))
+	write := func(line int, source, marks string) {
+		number := strconv.Itoa(scanner.lineNumber(line))
+		out.WriteString(options.paint(ansiGutter, strings.Repeat(" ", 1+gutter-len(number))+number+" |") + " " + strings.TrimRight(source, " ") + "\n")
+		out.WriteString(options.paint(ansiGutter, strings.Repeat(" ", 1+gutter)+" |") + " " + marks[:len(marks)-len(strings.TrimLeft(marks, " "))] + options.paint(severityColors[options.severity], strings.TrimLeft(marks, " ")) + "\n")
+	}
+
+	lineStart, lineEnd := scanner.lineBounds(first)
+	source, marks := scanner.underline(lineStart, lineEnd, start, min(end, lineEnd), true)
+	write(first, source, marks)
+	if last == first {
+		return out.String()
+	}
+
+	if last > first+1 {
+		out.WriteString(options.paint(ansiGutter, "...") + "\n")
+	}
+	lineStart, lineEnd = scanner.lineBounds(last)
+	source, marks = scanner.underline(lineStart, lineEnd, lineStart, min(end, lineEnd), false)
+	write(last, source, marks)
+	return out.String()
+}
+
+// underline returns the text between the offsets lineStart and lineEnd with tabs expanded, along with the line marking the part
+// between the offsets from and to with tildes. With caret, the first mark is a caret instead, and an empty part is marked with a caret alone.
+func (scanner *Scanner) underline(lineStart, lineEnd, from, to int, caret bool) (source, marks string) {
+	var text, under strings.Builder
+	col := 0
+	for i, r := range scanner.text[lineStart:lineEnd] {
+		offset := lineStart + i
+		width := displayWidth(r)
+		if r == '\t' {
+			width = 1
+			if scanner.tabWidth > 0 {
+				width = scanner.tabWidth - col%scanner.tabWidth
+			}
+			text.WriteString(strings.Repeat(" ", width))
+		} else {
+			text.WriteRune(r)
+		}
+		col += width
+
+		switch {
+		case offset < from:
+			under.WriteString(strings.Repeat(" ", width))
+		case offset < to:
+			under.WriteString(strings.Repeat("~", width))
+		}
+	}
+
+	marks = under.String()
+	if !caret {
+		return text.String(), marks
+	}
+	if from == to {
+		return text.String(), marks + "^"
+	}
+	if i := strings.IndexByte(marks, '~'); i >= 0 {
+		marks = marks[:i] + "^" + marks[i+1:]
+	} else {
+		// the part consists of zero-width runes only
+		marks += "^"
+	}
+	return text.String(), marks
+}
+
+// ErrTooManyErrors is returned by Diagnostics.Add once the maximum number of errors is reached.
+var ErrTooManyErrors = errors.New("too many errors")
+
+// Diagnostics collects the errors found while scanning, so a front-end can report all problems of a text in one pass
+// instead of failing on the first. Besides errors, it collects the non-fatal findings of lint-like tools, which are *Error
+// with a Severity other than SeverityError. Scanning should be aborted once Diagnostics.Add returns an error.
+type Diagnostics struct {
+	max        int
+	errors     []error
+	errorCount int // number of errors of SeverityError
+}
+
+// NewDiagnostics returns an empty Diagnostics collecting up to max errors of SeverityError, along with any number of errors
+// of lesser severity. A max of 0 or less means no limit.
+func NewDiagnostics(max int) *Diagnostics {
+	return &Diagnostics{max: max}
+}
+
+// Add records err, which usually is or wraps an *Error, and returns nil. Errors not wrapping an *Error count as SeverityError.
+// If the maximum number of errors of SeverityError has been reached, such an err is recorded only if it is the last one still fitting,
+// and an error wrapping ErrTooManyErrors is returned. Errors of lesser severity are always recorded. A nil err is ignored.
+func (diagnostics *Diagnostics) Add(err error) error {
+	if err == nil {
+		return nil
+	}
+	if severityOf(err) != SeverityError {
+		diagnostics.errors = append(diagnostics.errors, err)
+		return nil
+	}
+	if diagnostics.max > 0 && diagnostics.errorCount >= diagnostics.max {
+		return diagnostics.tooMany()
+	}
+
+	diagnostics.errors = append(diagnostics.errors, err)
+	diagnostics.errorCount++
+	if diagnostics.max > 0 && diagnostics.errorCount == diagnostics.max {
+		return diagnostics.tooMany()
+	}
+	return nil
+}
+
+// severityOf returns the severity of the *Error err is or wraps, or SeverityError if there is none.
+func severityOf(err error) Severity {
+	var positional *Error
+	if errors.As(err, &positional) {
+		return positional.Severity
+	}
+	return SeverityError
+}
+
+// tooMany returns the error reporting that the maximum number of errors has been reached.
+func (diagnostics *Diagnostics) tooMany() error {
+	return fmt.Errorf("%w: stopped after %d", ErrTooManyErrors, diagnostics.max)
+}
+
+// Len returns the number of errors recorded, of any severity.
+func (diagnostics *Diagnostics) Len() int {
+	return len(diagnostics.errors)
+}
+
+// HasErrors returns whether any error of SeverityError has been recorded, i.e. whether the text failed and not only had warnings.
+func (diagnostics *Diagnostics) HasErrors() bool {
+	return diagnostics.errorCount > 0
+}
+
+// Sorted returns the recorded errors ordered by the position they are reported at, see TextPosition.Compare.
+// Errors without a position, i.e. that neither are nor wrap an *Error, come first. Errors at the same position keep the order they were added in.
+func (diagnostics *Diagnostics) Sorted() []error {
+	sorted := slices.Clone(diagnostics.errors)
+	slices.SortStableFunc(sorted, func(a, b error) int {
+		var first, second *Error
+		switch {
+		case !errors.As(a, &first):
+			if !errors.As(b, &second) {
+				return 0
+			}
+			return -1
+		case !errors.As(b, &second):
+			return 1
+		}
+		return first.Pos().Compare(second.Pos())
+	})
+	return sorted
+}
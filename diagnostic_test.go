@@ -0,0 +1,244 @@
+package scanner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestScannerRenderSpan(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		options  []Option
+		start    int
+		end      int
+		expected string
+	}{
+		{name: "single rune", input: "a = b;", start: 4, end: 5, expected: "1:5: error: msg\n 1 | a = b;\n   |     ^\n"},
+		{name: "tildes", input: "x := \"abc\ny", start: 5, end: 9, expected: "1:6: error: msg\n 1 | x := \"abc\n   |      ^~~~\n"},
+		{name: "empty span", input: "f(", start: 2, end: 2, expected: "1:3: error: msg\n 1 | f(\n   |   ^\n"},
+		{name: "second line", input: "a\r\nbc d", start: 6, end: 7, expected: "2:4: error: msg\n 2 | bc d\n   |    ^\n"},
+		{name: "ending after line break", input: "ab\ncd", start: 1, end: 3, expected: "1:2: error: msg\n 1 | ab\n   |  ^\n"},
+		{name: "two lines", input: "ab\ncd", start: 1, end: 4, expected: "1:2: error: msg\n 1 | ab\n   |  ^\n 2 | cd\n   | ~\n"},
+		{name: "middle lines elided", input: "x = \"a\nb\nc\nd\" y", start: 4, end: 13, expected: "1:5: error: msg\n 1 | x = \"a\n   |     ^~\n...\n 4 | d\" y\n   | ~~\n"},
+		{name: "starting at line break", input: "/*\n*/", start: 2, end: 5, expected: "1:3: error: msg\n 1 | /*\n   |   ^\n 2 | */\n   | ~~\n"},
+		{name: "gutter of last line", input: "\n\n\n\n\n\n\n\nab\ncd", start: 8, end: 13, expected: "9:1: error: msg\n  9 | ab\n    | ^~\n 10 | cd\n    | ~~\n"},
+		{name: "tabs expanded", input: "\tx\ty", options: []Option{WithTabWidth(4)}, start: 3, end: 4, expected: "1:4: error: msg\n 1 |     x   y\n   |         ^\n"},
+		{name: "wide runes", input: "世界!", start: 3, end: 6, expected: "1:2: error: msg\n 1 | 世界!\n   |   ^~\n"},
+		{name: "filename and gutter", input: "\n\n\n\n\n\n\n\n\nabc", options: []Option{WithFilename("f.txt")}, start: 10, end: 12, expected: "f.txt:10:2: error: msg\n 10 | abc\n    |  ^~\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input, tt.options...)
+			start, err := scanner.positionOf(tt.start)
+			if err != nil {
+				t.Fatal(err)
+			}
+			end, err := scanner.positionOf(tt.end)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result := scanner.RenderSpan(Span{Start: start, End: end}, "msg"); result != tt.expected {
+				t.Errorf("RenderSpan() =\n%s\nexpected\n%s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestScannerRenderSpanFromStart(t *testing.T) {
+	scanner := NewScannerAt("ab\ncd", TextPosition{Line: 9, Col: 3})
+	scanner.Pop()
+	start := scanner.Pos()
+	for scanner.Pop() != 'c' {
+	}
+
+	expected := "9:4: error: msg\n  9 | ab\n    |  ^\n 10 | cd\n    | ~\n"
+	if result := scanner.RenderSpan(Span{Start: start, End: scanner.Pos()}, "msg"); result != expected {
+		t.Errorf("RenderSpan() =\n%s\nexpected\n%s", result, expected)
+	}
+}
+
+func TestScannerRender(t *testing.T) {
+	scanner := NewScanner("x := \"abc\n")
+	scanner.PopN(5)
+	_, _, err := scanner.ReadQuotedString('"')
+
+	expected := "1:6: error: invalid string literal: unterminated string before line break\n 1 | x := \"abc\n   |      ^~~~\n"
+	if result := scanner.Render(err); result != expected {
+		t.Errorf("Render() =\n%s\nexpected\n%s", result, expected)
+	}
+
+	wrapped := fmt.Errorf("reading config: %w", err)
+	if result, expected := scanner.Render(wrapped), "1:6: error: reading config: "+expected[len("1:6: error: "):]; result != expected {
+		t.Errorf("Render(wrapped) =\n%s\nexpected\n%s", result, expected)
+	}
+
+	if result := scanner.Render(nil); result != "" {
+		t.Errorf("Render(nil) = %q, expected an empty string", result)
+	}
+
+	// the severity of the error must not be appended to the spare capacity of the options
+	opts := make([]RenderOption, 1, 2)
+	opts[0] = RenderColor()
+	scanner.Render(err, opts...)
+	if opts[:2][1] != nil {
+		t.Errorf("Render() wrote to the options of the caller")
+	}
+
+	if result := scanner.Render(errors.New("no position")); result != "error: no position\n" {
+		t.Errorf("Render(plain) = %q, expected %q", result, "error: no position\n")
+	}
+}
+
+func TestScannerRenderColor(t *testing.T) {
+	scanner := NewScanner("a = b;")
+	span := Span{Start: TextPosition{Offset: 4, Line: 1, Col: 5}, End: TextPosition{Offset: 5, Line: 1, Col: 6}}
+	colored := "\x1b[1m1:5:\x1b[0m \x1b[1;31merror:\x1b[0m \x1b[1mmsg\x1b[0m\n" +
+		"\x1b[1;34m 1 |\x1b[0m a = b;\n" +
+		"\x1b[1;34m   |\x1b[0m     \x1b[1;31m^\x1b[0m\n"
+	plain := "1:5: error: msg\n 1 | a = b;\n   |     ^\n"
+
+	tests := []struct {
+		name     string
+		noColor  string
+		options  []RenderOption
+		expected string
+	}{
+		{name: "default", expected: plain},
+		{name: "forced", options: []RenderOption{RenderColor()}, expected: colored},
+		{name: "forced despite NO_COLOR", noColor: "1", options: []RenderOption{RenderColor()}, expected: colored},
+		{name: "auto without terminal", options: []RenderOption{RenderColorAuto(&bytes.Buffer{})}, expected: plain},
+		{name: "auto with NO_COLOR", noColor: "1", options: []RenderOption{RenderColorAuto(os.Stderr)}, expected: plain},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NO_COLOR", tt.noColor)
+			if result := scanner.RenderSpan(span, "msg", tt.options...); result != tt.expected {
+				t.Errorf("RenderSpan() = %q, expected %q", result, tt.expected)
+			}
+		})
+	}
+
+	if result, expected := scanner.Render(errors.New("no position"), RenderColor()), "\x1b[1;31merror:\x1b[0m \x1b[1mno position\x1b[0m\n"; result != expected {
+		t.Errorf("Render(plain) = %q, expected %q", result, expected)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	scanner := NewScanner("abc\ndef")
+	at := func(offset int, msg string) error {
+		pos, _ := scanner.positionOf(offset)
+		return scanner.ErrorfAt(Span{Start: pos, End: pos}, "%s", msg)
+	}
+
+	tests := []struct {
+		name    string
+		max     int
+		added   []error
+		aborted int // index of the first Add returning an error, -1 if none
+		sorted  []string
+	}{
+		{name: "empty", aborted: -1},
+		{name: "sorted by position", added: []error{at(5, "b"), at(1, "a"), errors.New("plain"), at(5, "c")}, aborted: -1,
+			sorted: []string{"plain", "a at 1:2", "b at 2:2", "c at 2:2"}},
+		{name: "wrapped errors", added: []error{fmt.Errorf("second: %w", at(6, "b")), at(0, "a")}, aborted: -1,
+			sorted: []string{"a at 1:1", "second: b at 2:3"}},
+		{name: "nil ignored", added: []error{nil, at(0, "a")}, aborted: -1, sorted: []string{"a at 1:1"}},
+		{name: "limit reached", max: 2, added: []error{at(2, "a"), at(1, "b"), at(0, "c")}, aborted: 1, sorted: []string{"b at 1:2", "a at 1:3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagnostics := NewDiagnostics(tt.max)
+			aborted := -1
+			for i, err := range tt.added {
+				if err := diagnostics.Add(err); err != nil {
+					if !errors.Is(err, ErrTooManyErrors) {
+						t.Errorf("Add() error = %v, expected ErrTooManyErrors", err)
+					}
+					if aborted < 0 {
+						aborted = i
+					}
+				}
+			}
+			if aborted != tt.aborted {
+				t.Errorf("first Add() error at %d, expected %d", aborted, tt.aborted)
+			}
+
+			if diagnostics.Len() != len(tt.sorted) || diagnostics.HasErrors() != (len(tt.sorted) > 0) {
+				t.Errorf("Len() = %d, HasErrors() = %t, expected %d errors", diagnostics.Len(), diagnostics.HasErrors(), len(tt.sorted))
+			}
+			var sorted []string
+			for _, err := range diagnostics.Sorted() {
+				sorted = append(sorted, err.Error())
+			}
+			if !slices.Equal(sorted, tt.sorted) {
+				t.Errorf("Sorted() = %q, expected %q", sorted, tt.sorted)
+			}
+		})
+	}
+}
+
+func TestScannerRenderSeverity(t *testing.T) {
+	scanner := NewScanner("x == nil")
+	warning := scanner.ErrorfAt(Span{Start: TextPosition{Offset: 2, Line: 1, Col: 3}, End: TextPosition{Offset: 4, Line: 1, Col: 5}}, "comparison is always false")
+	warning.Severity = SeverityWarning
+
+	if result, expected := scanner.Render(warning), "1:3: warning: comparison is always false\n 1 | x == nil\n   |   ^~\n"; result != expected {
+		t.Errorf("Render() = %q, expected %q", result, expected)
+	}
+	colored := "\x1b[1m1:3:\x1b[0m \x1b[1;33mwarning:\x1b[0m \x1b[1mcomparison is always false\x1b[0m\n" +
+		"\x1b[1;34m 1 |\x1b[0m x == nil\n" +
+		"\x1b[1;34m   |\x1b[0m   \x1b[1;33m^~\x1b[0m\n"
+	if result := scanner.Render(warning, RenderColor()); result != colored {
+		t.Errorf("Render(RenderColor()) = %q, expected %q", result, colored)
+	}
+	if result, expected := scanner.RenderSpan(warning.Span, "use x.IsNil()", RenderSeverity(SeverityHint)), "1:3: hint: use x.IsNil()\n 1 | x == nil\n   |   ^~\n"; result != expected {
+		t.Errorf("RenderSpan(RenderSeverity()) = %q, expected %q", result, expected)
+	}
+}
+
+func TestDiagnosticsSeverities(t *testing.T) {
+	scanner := NewScanner("abc")
+	finding := func(offset int, severity Severity) error {
+		pos, _ := scanner.positionOf(offset)
+		err := scanner.ErrorfAt(Span{Start: pos, End: pos}, "%s", severity)
+		err.Severity = severity
+		return err
+	}
+
+	diagnostics := NewDiagnostics(2)
+	for _, err := range []error{finding(0, SeverityWarning), finding(1, SeverityHint), finding(2, SeverityInfo)} {
+		if err := diagnostics.Add(err); err != nil {
+			t.Fatalf("Add() unexpected error: %v", err)
+		}
+	}
+	if diagnostics.HasErrors() || diagnostics.Len() != 3 {
+		t.Errorf("HasErrors() = %t, Len() = %d after findings only, expected false, 3", diagnostics.HasErrors(), diagnostics.Len())
+	}
+
+	if err := diagnostics.Add(finding(0, SeverityError)); err != nil {
+		t.Fatalf("Add() unexpected error: %v", err)
+	}
+	if !diagnostics.HasErrors() {
+		t.Errorf("HasErrors() = false after an error")
+	}
+	if err := diagnostics.Add(errors.New("no position")); !errors.Is(err, ErrTooManyErrors) {
+		t.Errorf("Add() error = %v, expected ErrTooManyErrors for the second error", err)
+	}
+	if err := diagnostics.Add(finding(1, SeverityError)); !errors.Is(err, ErrTooManyErrors) {
+		t.Errorf("Add() error = %v, expected ErrTooManyErrors after the limit", err)
+	}
+	if err := diagnostics.Add(finding(1, SeverityWarning)); err != nil {
+		t.Errorf("Add() error = %v for a warning after the limit, expected nil", err)
+	}
+	if diagnostics.Len() != 6 {
+		t.Errorf("Len() = %d, expected 6", diagnostics.Len())
+	}
+}
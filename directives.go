@@ -0,0 +1,64 @@
+package scanner
+
+import "sort"
+
+// A lineDirective remaps the positions from an offset onwards to another file and line, see Scanner.AddLineDirective.
+type lineDirective struct {
+	offset   int
+	line     int // the line of offset in the text of the Scanner
+	filename string
+	newLine  int
+}
+
+// AddLineDirective records that the text from the given byte offset onwards originates from the given file,
+// with the line containing the offset being line newLine of that file, like a `#line newLine "newFile"` directive would.
+// The offset is usually the beginning of the line following the directive. An empty newFile keeps the current filename.
+// Recorded directives only affect positions returned by Scanner.AdjustedPos, the positions tracked by the Scanner
+// itself always refer to its own text. Directives at invalid offsets are ignored.
+// A directive at the same offset as a previously added one replaces it.
+func (scanner *Scanner) AddLineDirective(atOffset int, newFile string, newLine int) {
+	pos, err := scanner.positionOf(atOffset)
+	if err != nil {
+		return
+	}
+
+	directive := lineDirective{offset: atOffset, line: pos.Line, filename: newFile, newLine: newLine}
+	directives := scanner.lineDirectives
+	i := sort.Search(len(directives), func(i int) bool { return directives[i].offset >= atOffset })
+	if i < len(directives) && directives[i].offset == atOffset {
+		directives[i] = directive
+		return
+	}
+
+	scanner.lineDirectives = append(directives[:i], append([]lineDirective{directive}, directives[i:]...)...)
+}
+
+// AdjustedPos returns the given position remapped to where the text at it originates from.
+// If a SourceMap was set with WithSourceMap and contains the position, the position is mapped to the original text.
+// Otherwise it is remapped according to the directives added with Scanner.AddLineDirective, leaving the offset and column unchanged.
+// Positions covered by neither are returned as is.
+func (scanner *Scanner) AdjustedPos(pos TextPosition) TextPosition {
+	if original, ok := scanner.mapToOriginal(pos); ok {
+		return original
+	}
+
+	directives := scanner.lineDirectives
+	i := sort.Search(len(directives), func(i int) bool { return directives[i].offset > pos.Offset }) - 1
+
+	// empty filenames keep the filename of the preceding directive, or the filename of the position
+	for j := i; j >= 0; j-- {
+		if directives[j].filename != "" {
+			pos.Filename = directives[j].filename
+			break
+		}
+	}
+	if i >= 0 {
+		pos.Line = directives[i].newLine + pos.Line - directives[i].line
+	}
+	return pos
+}
+
+// AdjustedSpan returns the given span with both of its positions remapped by Scanner.AdjustedPos.
+func (scanner *Scanner) AdjustedSpan(span Span) Span {
+	return Span{Start: scanner.AdjustedPos(span.Start), End: scanner.AdjustedPos(span.End)}
+}
@@ -0,0 +1,101 @@
+package scanner
+
+import "testing"
+
+func TestScannerAddLineDirective(t *testing.T) {
+	// #line 20 "orig.c" precedes line 3
+	text := "int a;\n#line 20 \"orig.c\"\nint b;\nint c;\n#line 5\nint d;"
+	scanner := NewScanner(text, WithFilename("out.i"))
+	scanner.AddLineDirective(25, "orig.c", 20)
+	scanner.AddLineDirective(47, "", 5)
+
+	tests := []struct {
+		name     string
+		offset   int
+		expected TextPosition
+	}{
+		{
+			name:     "before first directive",
+			offset:   4,
+			expected: TextPosition{Filename: "out.i", Offset: 4, Line: 1, Col: 5},
+		},
+		{
+			name:     "directive line itself",
+			offset:   7,
+			expected: TextPosition{Filename: "out.i", Offset: 7, Line: 2, Col: 1},
+		},
+		{
+			name:     "at first directive",
+			offset:   25,
+			expected: TextPosition{Filename: "orig.c", Offset: 25, Line: 20, Col: 1},
+		},
+		{
+			name:     "after first directive",
+			offset:   33,
+			expected: TextPosition{Filename: "orig.c", Offset: 33, Line: 21, Col: 2},
+		},
+		{
+			name:     "second directive keeps filename",
+			offset:   49,
+			expected: TextPosition{Filename: "orig.c", Offset: 49, Line: 5, Col: 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := scanner.SetOffset(tt.offset); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if result := scanner.AdjustedPos(scanner.Pos()); result != tt.expected {
+				t.Errorf("AdjustedPos(%+v) = %+v, expected %+v", scanner.Pos(), result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestScannerAddLineDirectiveReplace(t *testing.T) {
+	scanner := NewScanner("a\nb\nc")
+	scanner.AddLineDirective(4, "x.c", 10)
+	scanner.AddLineDirective(2, "y.c", 100)
+	scanner.AddLineDirective(4, "z.c", 50)
+
+	expected := TextPosition{Filename: "z.c", Offset: 4, Line: 50, Col: 1}
+	if result := scanner.AdjustedPos(TextPosition{Offset: 4, Line: 3, Col: 1}); result != expected {
+		t.Errorf("AdjustedPos() = %+v, expected %+v", result, expected)
+	}
+
+	expected = TextPosition{Filename: "y.c", Offset: 2, Line: 100, Col: 1}
+	if result := scanner.AdjustedPos(TextPosition{Offset: 2, Line: 2, Col: 1}); result != expected {
+		t.Errorf("AdjustedPos() = %+v, expected %+v", result, expected)
+	}
+}
+
+func TestScannerAddLineDirectiveInvalidOffset(t *testing.T) {
+	scanner := NewScanner("αβ")
+	scanner.AddLineDirective(-1, "x.c", 10)
+	scanner.AddLineDirective(1, "x.c", 10)
+	scanner.AddLineDirective(5, "x.c", 10)
+
+	pos := TextPosition{Offset: 2, Line: 1, Col: 2}
+	if result := scanner.AdjustedPos(pos); result != pos {
+		t.Errorf("AdjustedPos() = %+v, expected %+v", result, pos)
+	}
+}
+
+func TestScannerAdjustedSpan(t *testing.T) {
+	scanner := NewScanner("a\nbc")
+	scanner.AddLineDirective(2, "orig.c", 7)
+
+	span := Span{
+		Start: TextPosition{Offset: 0, Line: 1, Col: 1},
+		End:   TextPosition{Offset: 4, Line: 2, Col: 3},
+	}
+	expected := Span{
+		Start: TextPosition{Offset: 0, Line: 1, Col: 1},
+		End:   TextPosition{Filename: "orig.c", Offset: 4, Line: 7, Col: 3},
+	}
+	if result := scanner.AdjustedSpan(span); result != expected {
+		t.Errorf("AdjustedSpan() = %+v, expected %+v", result, expected)
+	}
+}
@@ -0,0 +1,44 @@
+package scanner
+
+// Embed returns a copy of the rule whose text is handed to a nested Tokenizer with the given rules instead of being produced
+// as a single token, e.g. for a code block in another language. The tokens of the nested Tokenizer, including its error tokens
+// but not its TokenEOF token, are spliced into the tokens of the parent Tokenizer in its place. Their positions refer to the text
+// of the parent Scanner and the nested Tokenizer inherits the resync and trivia settings of the parent.
+func (rule TokenRule) Embed(rules ...TokenRule) TokenRule {
+	return rule.EmbedFunc(func(Token) []TokenRule { return rules })
+}
+
+// EmbedFunc is like TokenRule.Embed, but the rules of the nested Tokenizer are chosen by the given function for each region,
+// given the token the rule would otherwise produce, e.g. by the language named by the info string of a fenced code block.
+// If choose returns no rules, the token is produced as is.
+func (rule TokenRule) EmbedFunc(choose func(region Token) []TokenRule) TokenRule {
+	rule.embed = choose
+	return rule
+}
+
+// tokenizeRegion tokenizes the given region of the text with a nested Tokenizer with the given rules and returns its tokens.
+// The trivia in front of the TokenEOF token of the nested Tokenizer trails the last token, or is returned on its own if there are no tokens.
+func (tokenizer *Tokenizer) tokenizeRegion(region Span, rules []TokenRule) (tokens, trivia []Token) {
+	nested := NewTokenizer(tokenizer.scanner.region(region), rules...)
+	nested.resync, nested.trivia, nested.firstMatch = tokenizer.resync, tokenizer.trivia, tokenizer.firstMatch
+
+	for {
+		token := nested.next(true)
+		if token.Kind != TokenEOF {
+			tokens = append(tokens, token)
+			continue
+		}
+		if len(tokens) == 0 {
+			return nil, token.Leading
+		}
+		last := &tokens[len(tokens)-1]
+		last.Trailing = append(last.Trailing, token.Leading...)
+		return tokens, nil
+	}
+}
+
+// region returns a Scanner configured like the Scanner, positioned at the start of the given span and ending at its end.
+// Positions within the region are the same as within the text of the Scanner.
+func (scanner *Scanner) region(span Span) *Scanner {
+	return scanner.derive(scanner.text[:span.End.Offset], span.Start)
+}
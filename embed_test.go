@@ -0,0 +1,119 @@
+package scanner
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func TestTokenizerEmbed(t *testing.T) {
+	fence := NewTokenKind("Fence")
+	code := NewTokenKind("Code")
+
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name:  "known language",
+			input: "text\n```go\nx <= 1\n```\nmore",
+			expected: []string{
+				`Ident "text" at 1:1`, `Fence "` + "```go\\n" + `" at 2:1`, `Ident "x" at 3:1`, `Operator "<=" at 3:3`, `Number "1" at 3:6`,
+				`Fence "` + "```" + `" at 4:1`, `Ident "more" at 5:1`,
+			},
+		},
+		{
+			name:     "unknown language",
+			input:    "```txt\nx <= 1\n```",
+			expected: []string{`Fence "` + "```txt\\n" + `" at 1:1`, `Code "x <= 1\n" at 2:1`, `Fence "` + "```" + `" at 3:1`},
+		},
+		{
+			name:     "errors in the region",
+			input:    "```go\n? x\n```",
+			expected: []string{`Fence "` + "```go\\n" + `" at 1:1`, `Error "?" at 2:1`, `Ident "x" at 2:3`, `Fence "` + "```" + `" at 3:1`},
+		},
+		{
+			name:     "region of trivia",
+			input:    "```go\n  \n``` y",
+			expected: []string{`Fence "` + "```go\\n" + `" at 1:1`, `Fence "` + "```" + `" at 3:1`, `Ident "y" at 3:5`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var language string
+			opener := FuncRule(fence, func(s *Scanner) bool {
+				if !s.ConsumeString("```") {
+					return false
+				}
+				name, _ := s.TakeWhile(unicode.IsLetter)
+				language = name
+				return s.ConsumeString("\n")
+			}).EnterMode("code")
+			region := FuncRule(code, func(s *Scanner) bool {
+				for !s.IsEOF() && !s.HasPrefix("```") {
+					s.SkipUntil('\n')
+					s.ConsumeString("\n")
+				}
+				return true
+			}).EmbedFunc(func(Token) []TokenRule {
+				if language == "go" {
+					return testRules()
+				}
+				return nil
+			})
+
+			tokenizer := NewTokenizer(NewScanner(tt.input), PredicateRule(testSpace, unicode.IsSpace).Skipped(), opener, PredicateRule(testIdent, unicode.IsLetter))
+			tokenizer.DefineMode("code", region, LiteralRule(fence, "```").ExitMode())
+
+			var result []string
+			for token := range tokenizer.Tokens() {
+				result = append(result, token.String())
+				if lexeme := tokenizer.Scanner().TextOf(token.Span); lexeme != token.Lexeme {
+					t.Errorf("token %s covers %q in the parent text", token, lexeme)
+				}
+			}
+			if strings.Join(result, ", ") != strings.Join(tt.expected, ", ") {
+				t.Errorf("Tokens() = %s\nexpected %s", strings.Join(result, ", "), strings.Join(tt.expected, ", "))
+			}
+		})
+	}
+}
+
+func TestTokenizerEmbedTrivia(t *testing.T) {
+	brackets := append(testRules(), LiteralRule(testOperator, "["), LiteralRule(testOperator, "]"))
+	tokenizer := NewTokenizer(NewScanner("[a b] // c"),
+		PredicateRule(testSpace, unicode.IsSpace).Skipped(),
+		FuncRule(testSpace, func(s *Scanner) bool {
+			if !s.ConsumeString("//") {
+				return false
+			}
+			s.SkipUntil('\n')
+			return true
+		}).Skipped(),
+		FuncRule(testString, func(s *Scanner) bool {
+			_, _, err := s.ReadBalanced('[', ']')
+			return err == nil
+		}).Embed(brackets...),
+	)
+	tokenizer.SetAttachTrivia(true)
+
+	var trivia []string
+	for token := range tokenizer.Tokens() {
+		var leading, trailing []string
+		for _, t := range token.Leading {
+			leading = append(leading, strconv.Quote(t.Lexeme))
+		}
+		for _, t := range token.Trailing {
+			trailing = append(trailing, strconv.Quote(t.Lexeme))
+		}
+		trivia = append(trivia, token.Lexeme+" "+strings.Join(leading, "")+"|"+strings.Join(trailing, ""))
+	}
+
+	expected := `[ |, a |" ", b |, ] |" ""// c"`
+	if result := strings.Join(trivia, ", "); result != expected {
+		t.Errorf("trivia = %s, expected %s", result, expected)
+	}
+}
@@ -0,0 +1,96 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Severity classifies how serious an Error is. The zero value is SeverityError, so errors are fatal unless said otherwise.
+type Severity int
+
+const (
+	// SeverityError marks a failure the text cannot be processed past, e.g. a syntax error.
+	SeverityError Severity = iota
+	// SeverityWarning marks a likely problem that does not prevent processing the text, e.g. a deprecated construct.
+	SeverityWarning
+	// SeverityInfo marks a finding that is worth knowing but no problem, e.g. a statistic.
+	SeverityInfo
+	// SeverityHint marks a suggestion, e.g. a simpler way to write something.
+	SeverityHint
+)
+
+// String returns the lower case name of the severity as used in diagnostics, e.g. "warning".
+func (severity Severity) String() string {
+	switch severity {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityInfo:
+		return "info"
+	case SeverityHint:
+		return "hint"
+	}
+	return "Severity(" + strconv.Itoa(int(severity)) + ")"
+}
+
+// An Error is a failure at a span of the text of a Scanner. The reading helpers of the Scanner report their failures as *Error,
+// wrapping one of the sentinel errors such as ErrInvalidString, so that errors.Is identifies the kind of failure
+// and errors.As gives access to where it happened.
+type Error struct {
+	// Span is the part of the text the error is about. Its start is the position the error is reported at.
+	Span Span
+	// Msg describes the error, without the position.
+	Msg string
+	// Code optionally identifies the error for tools, e.g. "E0042". It is not part of the message.
+	Code string
+	// Err is the error wrapped by the Error, if any.
+	Err error
+	// Line is the text of the line the error is reported at, without the line break, if the Scanner was created WithErrorLines.
+	Line string
+	// Severity is how serious the error is, SeverityError unless set otherwise, e.g. by lint-like tools reporting findings as warnings.
+	Severity Severity
+}
+
+// Pos returns the position the error is reported at, the start of its span.
+func (err *Error) Pos() TextPosition {
+	return err.Span.Start
+}
+
+// Error returns the message followed by the position, e.g. "invalid string literal: unterminated string at main.go:3:5".
+func (err *Error) Error() string {
+	return err.Msg + " at " + err.Span.Start.String()
+}
+
+// Unwrap returns the error wrapped by the Error.
+func (err *Error) Unwrap() error {
+	return err.Err
+}
+
+// ErrorfAt returns an *Error about the given span, whose message is formatted like by fmt.Errorf.
+// If the format contains a %w verb, the Error wraps the corresponding argument, e.g.
+// scanner.ErrorfAt(span, "%w: unknown directive %q", ErrUnexpected, name).
+func (scanner *Scanner) ErrorfAt(span Span, format string, args ...any) *Error {
+	err := fmt.Errorf(format, args...)
+	result := &Error{Span: span, Msg: err.Error(), Err: errors.Unwrap(err)}
+	if scanner.errorLines {
+		result.Line, _ = scanner.LineAt(span.Start.Line)
+	}
+	return result
+}
+
+// WithErrorLines makes the errors created by Scanner.Errorf and Scanner.ErrorfAt record the text of the line they are reported at in Error.Line,
+// so they can be shown along with the offending line after the text is gone.
+func WithErrorLines() Option {
+	return func(scanner *Scanner) {
+		scanner.errorLines = true
+	}
+}
+
+// Errorf returns an *Error at the current scanner position whose message is formatted like by Scanner.ErrorfAt, e.g.
+// scanner.Errorf("%w: expected ';'", ErrUnexpected). The span of the error is empty.
+func (scanner *Scanner) Errorf(format string, args ...any) error {
+	pos := scanner.TextPosition
+	return scanner.ErrorfAt(Span{Start: pos, End: pos}, format, args...)
+}
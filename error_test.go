@@ -0,0 +1,126 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestScannerErrorfAt(t *testing.T) {
+	scanner := NewScanner("let x = 1")
+	span := Span{Start: TextPosition{Offset: 4, Line: 1, Col: 5}, End: TextPosition{Offset: 5, Line: 1, Col: 6}}
+	err := scanner.ErrorfAt(span, "%w: %q is not declared", ErrUnexpected, "x")
+
+	if message := `unexpected input: "x" is not declared at 1:5`; err.Error() != message {
+		t.Errorf("Error() = %q, expected %q", err.Error(), message)
+	}
+	if err.Msg != `unexpected input: "x" is not declared` {
+		t.Errorf("Msg = %q", err.Msg)
+	}
+	if err.Pos() != span.Start || err.Span != span {
+		t.Errorf("Pos() = %v, Span = %v, expected span %v", err.Pos(), err.Span, span)
+	}
+	if !errors.Is(err, ErrUnexpected) {
+		t.Errorf("errors.Is(err, ErrUnexpected) = false")
+	}
+
+	plain := scanner.ErrorfAt(span, "no %s here", "x")
+	if plain.Unwrap() != nil {
+		t.Errorf("Unwrap() = %v, expected nil without %%w", plain.Unwrap())
+	}
+}
+
+func TestScannerHelperErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		read     func(scanner *Scanner) error
+		sentinel error
+		start    int
+		end      int
+	}{
+		{name: "Expect", input: "x", read: func(scanner *Scanner) error { return scanner.Expect(';') }, sentinel: ErrUnexpected, start: 0, end: 1},
+		{name: "ExpectString", input: "<-", read: func(scanner *Scanner) error { return scanner.ExpectString("<=") }, sentinel: ErrUnexpected, start: 1, end: 2},
+		{name: "TakeExactly", input: "ab", read: func(scanner *Scanner) error { _, _, err := scanner.TakeExactly(3); return err }, sentinel: ErrUnexpectedEOF, start: 0, end: 2},
+		{name: "ReadQuotedString", input: `"abc`, read: func(scanner *Scanner) error { _, _, err := scanner.ReadQuotedString('"'); return err }, sentinel: ErrInvalidString, start: 0, end: 4},
+		{name: "ReadJSONString", input: `"a\q"`, read: func(scanner *Scanner) error { _, _, err := scanner.ReadJSONString(); return err }, sentinel: ErrInvalidString, start: 2, end: 4},
+		{name: "ReadEscapeSequence", input: `\q`, read: func(scanner *Scanner) error { _, _, err := scanner.ReadEscapeSequence(); return err }, sentinel: ErrInvalidEscape, start: 0, end: 2},
+		{name: "ReadBalanced", input: "(a", read: func(scanner *Scanner) error { _, _, err := scanner.ReadBalanced('(', ')'); return err }, sentinel: ErrUnbalanced, start: 0, end: 1},
+		{name: "ReadCSVField", input: `a"b`, read: func(scanner *Scanner) error { _, _, err := scanner.ReadCSVField(','); return err }, sentinel: ErrInvalidCSV, start: 1, end: 2},
+		{name: "ReadInt", input: "99999999999999999999", read: func(scanner *Scanner) error { _, _, _, err := scanner.ReadInt(); return err }, sentinel: ErrInvalidNumber, start: 0, end: 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.read(NewScanner(tt.input))
+			var positional *Error
+			if !errors.As(err, &positional) {
+				t.Fatalf("error %v (%T) is not an *Error", err, err)
+			}
+			if !errors.Is(err, tt.sentinel) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tt.sentinel)
+			}
+			if positional.Span.Start.Offset != tt.start || positional.Span.End.Offset != tt.end {
+				t.Errorf("Span = [%d, %d), expected [%d, %d)", positional.Span.Start.Offset, positional.Span.End.Offset, tt.start, tt.end)
+			}
+			if suffix := fmt.Sprintf(" at %s", positional.Pos()); err.Error() != positional.Msg+suffix {
+				t.Errorf("Error() = %q, expected message followed by %q", err.Error(), suffix)
+			}
+		})
+	}
+}
+
+func TestScannerErrorf(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []Option
+		line    string
+	}{
+		{name: "without line", line: ""},
+		{name: "with line", options: []Option{WithErrorLines()}, line: "b := 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner("a := 1\r\nb := 2\nc", tt.options...)
+			scanner.PopN(10)
+			err := scanner.Errorf("%w: expected %q", ErrUnexpected, ";")
+
+			if message := `unexpected input: expected ";" at 2:4`; err.Error() != message {
+				t.Errorf("Error() = %q, expected %q", err.Error(), message)
+			}
+			if !errors.Is(err, ErrUnexpected) {
+				t.Errorf("errors.Is(err, ErrUnexpected) = false")
+			}
+			var positional *Error
+			if !errors.As(err, &positional) {
+				t.Fatalf("error %v (%T) is not an *Error", err, err)
+			}
+			if positional.Span.Start != scanner.TextPosition || positional.Span.End != scanner.TextPosition {
+				t.Errorf("Span = %v, expected empty span at %v", positional.Span, scanner.TextPosition)
+			}
+			if positional.Line != tt.line {
+				t.Errorf("Line = %q, expected %q", positional.Line, tt.line)
+			}
+		})
+	}
+}
+
+func TestSeverityString(t *testing.T) {
+	tests := []struct {
+		severity Severity
+		expected string
+	}{
+		{severity: SeverityError, expected: "error"},
+		{severity: SeverityWarning, expected: "warning"},
+		{severity: SeverityInfo, expected: "info"},
+		{severity: SeverityHint, expected: "hint"},
+		{severity: Severity(7), expected: "Severity(7)"},
+	}
+
+	for _, tt := range tests {
+		if result := tt.severity.String(); result != tt.expected {
+			t.Errorf("Severity(%d).String() = %q, expected %q", int(tt.severity), result, tt.expected)
+		}
+	}
+}
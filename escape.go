@@ -0,0 +1,101 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// ErrInvalidEscape is returned when the text at the scanner position is not a well-formed escape sequence.
+var ErrInvalidEscape = errors.New("invalid escape sequence")
+
+// ReadEscapeSequence consumes a single escape sequence starting with a backslash at the current scanner position
+// and returns the rune it stands for along with its span. The escape sequences of Go are understood: \a, \b, \f, \n, \r, \t, \v, \\, \', \",
+// \x followed by 2 hexadecimal digits, \u by 4, \U by 8 and a backslash followed by 3 octal digits, as well as \u{...} with 1 to 6
+// hexadecimal digits and \0 not followed by further octal digits. \x and octal escapes stand for the rune with the given value, e.g. \xFF for U+00FF.
+// Scanner.ReadQuotedString decodes escape sequences the same way.
+// If the position does not start with a backslash or the escape sequence is malformed, an error wrapping ErrInvalidEscape
+// is returned along with the span of the malformed sequence, and nothing is consumed.
+func (scanner *Scanner) ReadEscapeSequence() (rune, Span, error) {
+	start := scanner.TextPosition
+	savedComplex := scanner.isComplexSinceMark
+	if !scanner.Accept('\\') {
+		return EOF, Span{Start: start, End: start}, scanner.Errorf("%w: expected '\\\\'", ErrInvalidEscape)
+	}
+
+	value, err := scanner.decodeEscape()
+	span := Span{Start: start, End: scanner.TextPosition}
+	if err != nil {
+		scanner.TextPosition = start
+		scanner.isComplexSinceMark = savedComplex
+		return EOF, span, scanner.ErrorfAt(span, "%w: %v", ErrInvalidEscape, err)
+	}
+	return value, span, nil
+}
+
+// decodeEscape consumes the escape sequence following a backslash, which is already consumed, and returns the rune it stands for
+// as described for Scanner.ReadEscapeSequence. An error describing the sequence is returned if it is invalid.
+func (scanner *Scanner) decodeEscape() (rune, error) {
+	r := scanner.Pop()
+	if value, ok := simpleEscapes[r]; ok {
+		return value, nil
+	}
+
+	var digits, base int
+	var value rune
+	switch {
+	case r == 'x':
+		digits, base = 2, 16
+	case r == 'u' && scanner.Accept('{'):
+		return scanner.decodeBracedEscape()
+	case r == 'u':
+		digits, base = 4, 16
+	case r == 'U':
+		digits, base = 8, 16
+	case r == '0' && digitValue36(scanner.Peek()) >= 8:
+		return 0, nil
+	case '0' <= r && r <= '7':
+		// the first of the 3 octal digits is already consumed
+		digits, base, value = 2, 8, r-'0'
+	case r == EOF:
+		return EOF, errors.New("unterminated escape sequence")
+	default:
+		return EOF, fmt.Errorf("unknown escape sequence \\%c", r)
+	}
+
+	for range digits {
+		digit := digitValue36(scanner.Peek())
+		if digit >= base {
+			return EOF, fmt.Errorf("incomplete escape sequence \\%c", r)
+		}
+		scanner.Pop()
+		value = value*rune(base) + rune(digit)
+	}
+
+	if base == 8 && value > 255 {
+		return EOF, fmt.Errorf("octal escape value %d > 255", value)
+	}
+	if !utf8.ValidRune(value) {
+		return EOF, fmt.Errorf("escape sequence is an invalid Unicode code point %U", value)
+	}
+	return value, nil
+}
+
+// decodeBracedEscape consumes the hexadecimal digits and closing brace of a \u{...} escape sequence and returns the rune it stands for.
+func (scanner *Scanner) decodeBracedEscape() (rune, error) {
+	var value rune
+	n := 0
+	for ; digitValue36(scanner.Peek()) < 16; n++ {
+		if n == 6 {
+			return EOF, errors.New("too many digits in escape sequence \\u{...}")
+		}
+		value = value*16 + rune(digitValue36(scanner.Pop()))
+	}
+	if n == 0 || !scanner.Accept('}') {
+		return EOF, errors.New("incomplete escape sequence \\u{...}")
+	}
+	if !utf8.ValidRune(value) {
+		return EOF, fmt.Errorf("escape sequence is an invalid Unicode code point %U", value)
+	}
+	return value, nil
+}
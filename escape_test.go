@@ -0,0 +1,82 @@
+package scanner
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestScannerReadEscapeSequence(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected rune
+		end      int
+	}{
+		{name: "newline", input: `\nx`, expected: '\n', end: 2},
+		{name: "backslash", input: `\\`, expected: '\\', end: 2},
+		{name: "quote", input: `\"`, expected: '"', end: 2},
+		{name: "hex", input: `\xFF`, expected: 0xFF, end: 4},
+		{name: "octal", input: `\101`, expected: 'A', end: 4},
+		{name: "nul", input: `\0x`, expected: 0, end: 2},
+		{name: "nul octal", input: `\012`, expected: '\n', end: 4},
+		{name: "unicode", input: `\u00e4`, expected: 'ä', end: 6},
+		{name: "long unicode", input: `\U0001F600`, expected: '😀', end: 10},
+		{name: "braced unicode", input: `\u{1F600}!`, expected: '😀', end: 9},
+		{name: "braced short", input: `\u{41}`, expected: 'A', end: 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			result, span, err := scanner.ReadEscapeSequence()
+			if err != nil {
+				t.Fatalf("ReadEscapeSequence() unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("ReadEscapeSequence() = %q, expected %q", result, tt.expected)
+			}
+			if span.Start.Offset != 0 || span.End.Offset != tt.end || scanner.Offset != tt.end {
+				t.Errorf("ReadEscapeSequence() span [%d, %d) at offset %d, expected [0, %d)", span.Start.Offset, span.End.Offset, scanner.Offset, tt.end)
+			}
+		})
+	}
+}
+
+func TestScannerReadEscapeSequenceErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		end     int
+		message string
+	}{
+		{name: "no backslash", input: `n`, end: 0, message: `invalid escape sequence: expected '\\' at 1:1`},
+		{name: "unknown", input: `\q`, end: 2, message: `invalid escape sequence: unknown escape sequence \q at 1:1`},
+		{name: "EOF", input: `\`, end: 1, message: `invalid escape sequence: unterminated escape sequence at 1:1`},
+		{name: "short hex", input: `\x4g`, end: 3, message: `invalid escape sequence: incomplete escape sequence \x at 1:1`},
+		{name: "octal overflow", input: `\777`, end: 4, message: `invalid escape sequence: octal escape value 511 > 255 at 1:1`},
+		{name: "surrogate", input: `\uD800`, end: 6, message: `invalid escape sequence: escape sequence is an invalid Unicode code point U+D800 at 1:1`},
+		{name: "braced empty", input: `\u{}`, end: 3, message: `invalid escape sequence: incomplete escape sequence \u{...} at 1:1`},
+		{name: "braced unclosed", input: `\u{41`, end: 5, message: `invalid escape sequence: incomplete escape sequence \u{...} at 1:1`},
+		{name: "braced too long", input: `\u{1000000}`, end: 9, message: `invalid escape sequence: too many digits in escape sequence \u{...} at 1:1`},
+		{name: "braced out of range", input: `\u{110000}`, end: 10, message: `invalid escape sequence: escape sequence is an invalid Unicode code point U+110000 at 1:1`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			_, span, err := scanner.ReadEscapeSequence()
+			if !errors.Is(err, ErrInvalidEscape) {
+				t.Fatalf("ReadEscapeSequence() error = %v, expected ErrInvalidEscape", err)
+			}
+			if err.Error() != tt.message {
+				t.Errorf("ReadEscapeSequence() error = %q, expected %q", err.Error(), tt.message)
+			}
+			if span.Start.Offset != 0 || span.End.Offset != tt.end {
+				t.Errorf("ReadEscapeSequence() span [%d, %d), expected [0, %d)", span.Start.Offset, span.End.Offset, tt.end)
+			}
+			if scanner.Offset != 0 {
+				t.Errorf("expected nothing consumed on error, got offset %d", scanner.Offset)
+			}
+		})
+	}
+}
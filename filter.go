@@ -0,0 +1,94 @@
+package scanner
+
+import "slices"
+
+// TokenSourceFunc adapts a function to a TokenSource.
+type TokenSourceFunc func() (Token, error)
+
+// Next calls the function.
+func (fn TokenSourceFunc) Next() (Token, error) {
+	return fn()
+}
+
+// A TokenFilter transforms the tokens of a TokenSource, such as SkipKinds, MergeAdjacent or Rewrite.
+type TokenFilter func(TokenSource) TokenSource
+
+// Filter returns a TokenSource producing the tokens of source passed through the given filters in order,
+// e.g. Filter(tokenizer, SkipKinds(whitespace, comment)), so that a parser sees a cleaned stream.
+func Filter(source TokenSource, filters ...TokenFilter) TokenSource {
+	for _, filter := range filters {
+		source = filter(source)
+	}
+	return source
+}
+
+// SkipKinds returns a TokenFilter dropping the tokens of the given kinds.
+func SkipKinds(kinds ...TokenKind) TokenFilter {
+	return func(source TokenSource) TokenSource {
+		return TokenSourceFunc(func() (Token, error) {
+			for {
+				token, err := source.Next()
+				if err != nil || !slices.Contains(kinds, token.Kind) {
+					return token, err
+				}
+			}
+		})
+	}
+}
+
+// MergeAdjacent returns a TokenFilter merging consecutive tokens of the same kind into a single token, if the kind is one of the given kinds
+// and the tokens are not separated by any text. The merged token spans all of them and its lexeme is the concatenation of their lexemes.
+// It keeps the Value of the first token, the leading trivia of the first and the trailing trivia of the last token.
+func MergeAdjacent(kinds ...TokenKind) TokenFilter {
+	return func(source TokenSource) TokenSource {
+		// the token or error read ahead of the merged token
+		var pending *Token
+		var pendingErr error
+		next := func() (Token, error) {
+			switch {
+			case pendingErr != nil:
+				return Token{}, pendingErr
+			case pending != nil:
+				token := *pending
+				pending = nil
+				return token, nil
+			}
+			return source.Next()
+		}
+
+		return TokenSourceFunc(func() (Token, error) {
+			token, err := next()
+			if err != nil || token.Kind == TokenEOF || !slices.Contains(kinds, token.Kind) {
+				return token, err
+			}
+
+			for {
+				following, err := source.Next()
+				if err != nil {
+					pendingErr = err
+					return token, nil
+				}
+				if following.Kind != token.Kind || following.Start.Offset != token.End.Offset {
+					pending = &following
+					return token, nil
+				}
+				token.Lexeme += following.Lexeme
+				token.End = following.End
+				token.Trailing = following.Trailing
+			}
+		})
+	}
+}
+
+// Rewrite returns a TokenFilter replacing each token by the result of fn, e.g. to change the kind of contextual keywords.
+func Rewrite(fn func(Token) Token) TokenFilter {
+	return func(source TokenSource) TokenSource {
+		return TokenSourceFunc(func() (Token, error) {
+			token, err := source.Next()
+			if err != nil {
+				return token, err
+			}
+			return fn(token), nil
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package scanner
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// collectTokens reads the tokens of source up to and including the TokenEOF token.
+func collectTokens(t *testing.T, source TokenSource) []string {
+	t.Helper()
+
+	var tokens []string
+	for {
+		token, err := source.Next()
+		if err != nil {
+			t.Fatalf("Next() unexpected error: %v", err)
+		}
+		tokens = append(tokens, token.Kind.String()+":"+token.Lexeme)
+		if token.Kind == TokenEOF {
+			return tokens
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	tokenizer := func(input string) *Tokenizer {
+		// whitespace is not skipped by the rules, so the filters see it
+		rules := testRules()
+		rules[0] = PredicateRule(testSpace, func(r rune) bool { return r == ' ' })
+		return NewTokenizer(NewScanner(input), rules...)
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		filters  []TokenFilter
+		expected []string
+	}{
+		{name: "none", input: "a b", filters: nil, expected: []string{"Ident:a", "Space: ", "Ident:b", "EOF:"}},
+		{name: "skip kinds", input: "a < 1", filters: []TokenFilter{SkipKinds(testSpace, testOperator)}, expected: []string{"Ident:a", "Number:1", "EOF:"}},
+		{name: "merge adjacent", input: "a?!# ?b", filters: []TokenFilter{MergeAdjacent(TokenError)}, expected: []string{"Ident:a", "Error:?!#", "Space: ", "Error:?", "Ident:b", "EOF:"}},
+		{name: "merge only listed kinds", input: "<<", filters: []TokenFilter{MergeAdjacent(TokenError)}, expected: []string{"Operator:<", "Operator:<", "EOF:"}},
+		{name: "merge not separated by skipped text", input: "?? ?", filters: []TokenFilter{SkipKinds(testSpace), MergeAdjacent(TokenError)}, expected: []string{"Error:??", "Error:?", "EOF:"}},
+		{name: "rewrite", input: "a b", filters: []TokenFilter{SkipKinds(testSpace), Rewrite(func(token Token) Token {
+			if token.Lexeme == "b" {
+				token.Kind = testString
+			}
+			return token
+		})}, expected: []string{"Ident:a", "String:b", "EOF:"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens := collectTokens(t, Filter(tokenizer(tt.input), tt.filters...))
+			if !slices.Equal(tokens, tt.expected) {
+				t.Errorf("got tokens %q, expected %q", tokens, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMergeAdjacentKeepsError(t *testing.T) {
+	source := &failingSource{tokens: []Token{
+		{Kind: TokenError, Lexeme: "?", Span: Span{End: TextPosition{Offset: 1}}},
+		{Kind: TokenError, Lexeme: "!", Span: Span{Start: TextPosition{Offset: 1}, End: TextPosition{Offset: 2}}},
+	}, err: ErrUnexpected}
+	filtered := Filter(source, MergeAdjacent(TokenError))
+
+	if token, err := filtered.Next(); err != nil || token.Lexeme != "?!" || token.End.Offset != 2 {
+		t.Errorf("Next() = %s ending at %d, %v, expected the merged token \"?!\" ending at 2", token, token.End.Offset, err)
+	}
+	for range 2 {
+		if _, err := filtered.Next(); !errors.Is(err, ErrUnexpected) {
+			t.Errorf("Next() error = %v, expected ErrUnexpected", err)
+		}
+	}
+}
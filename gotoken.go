@@ -0,0 +1,53 @@
+package scanner
+
+import (
+	"fmt"
+	"go/token"
+)
+
+// AddToFileSet adds the text of the Scanner as a file to the given token.FileSet, using the filename of the Scanner.
+// Line information is copied from the text of the Scanner. Like everywhere in go/token, the lines of the token.File are counted
+// from 1 at the beginning of the text, so the positions reported by the FileSet only agree with those of the Scanner if it counts
+// lines the same way, that is unless it was created WithZeroBasedLines or by NewScannerAt with a starting position on another line.
+// The returned token.File is remembered by the Scanner and used by Scanner.TokenPos and Scanner.FromTokenPos, which translate
+// between the two by the offset and thus always agree with the Scanner.
+// Note that token.Position counts columns in bytes, unlike TextPosition, and that token.File can't represent an empty last line.
+func (scanner *Scanner) AddToFileSet(fset *token.FileSet) *token.File {
+	file := fset.AddFile(scanner.filename, -1, len(scanner.text))
+
+	// token.File only accepts line offsets inside the file, so an empty last line can't be represented
+	lines := scanner.lineIndex()
+	for len(lines) > 1 && lines[len(lines)-1] >= len(scanner.text) {
+		lines = lines[:len(lines)-1]
+	}
+	if len(scanner.text) > 0 {
+		file.SetLines(lines)
+	}
+
+	scanner.tokenFile = file
+	return file
+}
+
+// TokenPos converts the given TextPosition to a token.Pos within the token.File created by Scanner.AddToFileSet.
+// token.NoPos is returned if the Scanner was not added to a token.FileSet or if the offset of the position is out of range.
+func (scanner *Scanner) TokenPos(pos TextPosition) token.Pos {
+	if scanner.tokenFile == nil || pos.Offset < 0 || pos.Offset > len(scanner.text) {
+		return token.NoPos
+	}
+	return scanner.tokenFile.Pos(pos.Offset)
+}
+
+// FromTokenPos converts the given token.Pos within the token.File created by Scanner.AddToFileSet to a TextPosition.
+// An error wrapping ErrInvalidPosition is returned if the Scanner was not added to a token.FileSet
+// or if the token.Pos does not lie within its file.
+func (scanner *Scanner) FromTokenPos(p token.Pos) (TextPosition, error) {
+	file := scanner.tokenFile
+	if file == nil {
+		return TextPosition{}, fmt.Errorf("%w: scanner was not added to a token.FileSet", ErrInvalidPosition)
+	}
+	if !p.IsValid() || int(p) < file.Base() || int(p) > file.Base()+file.Size() {
+		return TextPosition{}, fmt.Errorf("%w: token.Pos %d is not within file %q", ErrInvalidPosition, p, file.Name())
+	}
+
+	return scanner.positionOf(file.Offset(p))
+}
@@ -0,0 +1,112 @@
+package scanner
+
+import (
+	"errors"
+	"go/token"
+	"testing"
+)
+
+func TestScannerAddToFileSet(t *testing.T) {
+	inputs := []string{
+		"",
+		"abc",
+		"a\nb\rc\r\nd",
+		"αβ\nγδ\n",
+		"a\\\nb",
+	}
+
+	for _, input := range inputs {
+		fset := token.NewFileSet()
+		fset.AddFile("other.go", -1, 10)
+
+		scanner := NewScanner(input, WithFilename("input.txt"))
+		file := scanner.AddToFileSet(fset)
+
+		if file.Name() != "input.txt" {
+			t.Errorf("input %q: file name = %q, expected %q", input, file.Name(), "input.txt")
+		}
+		if file.Size() != len(input) {
+			t.Errorf("input %q: file size = %d, expected %d", input, file.Size(), len(input))
+		}
+
+		for {
+			pos := scanner.Pos()
+
+			tokenPos := scanner.TokenPos(pos)
+			if !tokenPos.IsValid() {
+				t.Errorf("input %q: TokenPos(%+v) is invalid", input, pos)
+				break
+			}
+
+			// an empty last line can't be represented by token.File
+			position := fset.Position(tokenPos)
+			if position.Filename != "input.txt" || position.Offset != pos.Offset ||
+				(position.Line != pos.Line && pos.Offset < len(input)) {
+				t.Errorf("input %q: position of %+v is %+v", input, pos, position)
+			}
+
+			back, err := scanner.FromTokenPos(tokenPos)
+			if err != nil {
+				t.Errorf("input %q: FromTokenPos(%d): unexpected error: %v", input, tokenPos, err)
+			} else if back != pos {
+				t.Errorf("input %q: FromTokenPos(%d): expected %+v, got %+v", input, tokenPos, pos, back)
+			}
+
+			if scanner.Pop() == EOF {
+				break
+			}
+		}
+	}
+}
+
+func TestScannerAddToFileSetTranslatesLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		scanner  *Scanner
+		expected TextPosition
+	}{
+		{name: "zero-based lines", scanner: NewScanner("a\nbc", WithZeroBasedLines()), expected: TextPosition{Offset: 3, Line: 1, Col: 2}},
+		{name: "starting position", scanner: NewScannerAt("a\nbc", TextPosition{Line: 10, Col: 1}), expected: TextPosition{Offset: 3, Line: 11, Col: 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fset := token.NewFileSet()
+			tt.scanner.AddToFileSet(fset)
+			tokenPos := tt.scanner.TokenPos(tt.expected)
+
+			// the FileSet counts lines from 1 at the beginning of the text
+			if position := fset.Position(tokenPos); position.Line != 2 || position.Column != 2 {
+				t.Errorf("FileSet position = %d:%d, expected 2:2", position.Line, position.Column)
+			}
+			if pos, err := tt.scanner.FromTokenPos(tokenPos); err != nil || pos != tt.expected {
+				t.Errorf("FromTokenPos(%d) = %+v, %v, expected %+v", tokenPos, pos, err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestScannerTokenPosInvalid(t *testing.T) {
+	scanner := NewScanner("abc")
+
+	if p := scanner.TokenPos(scanner.Pos()); p != token.NoPos {
+		t.Errorf("TokenPos before AddToFileSet: expected NoPos, got %d", p)
+	}
+	if _, err := scanner.FromTokenPos(1); !errors.Is(err, ErrInvalidPosition) {
+		t.Errorf("FromTokenPos before AddToFileSet: expected ErrInvalidPosition, got %v", err)
+	}
+
+	fset := token.NewFileSet()
+	other := fset.AddFile("other.go", -1, 10)
+	scanner.AddToFileSet(fset)
+
+	if p := scanner.TokenPos(TextPosition{Offset: 4, Line: 1, Col: 5}); p != token.NoPos {
+		t.Errorf("TokenPos past end: expected NoPos, got %d", p)
+	}
+	if _, err := scanner.FromTokenPos(token.NoPos); !errors.Is(err, ErrInvalidPosition) {
+		t.Errorf("FromTokenPos(NoPos): expected ErrInvalidPosition, got %v", err)
+	}
+	if _, err := scanner.FromTokenPos(other.Pos(5)); !errors.Is(err, ErrInvalidPosition) {
+		t.Errorf("FromTokenPos in other file: expected ErrInvalidPosition, got %v", err)
+	}
+}
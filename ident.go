@@ -0,0 +1,68 @@
+package scanner
+
+import "unicode"
+
+// WithIdentifierRules sets which runes Scanner.ReadIdentifier accepts at the start and in the rest of an identifier.
+// The default rules are the Unicode ID_Start and ID_Continue properties, see IsIDStart and IsIDContinue.
+// A nil predicate keeps the respective default rule.
+func WithIdentifierRules(start, cont func(rune) bool) Option {
+	return func(scanner *Scanner) {
+		scanner.identStart = start
+		scanner.identContinue = cont
+	}
+}
+
+// IsIDStart returns whether the given rune has the Unicode ID_Start property, that is whether it may start an identifier.
+func IsIDStart(r rune) bool {
+	if unicode.In(r, unicode.Pattern_Syntax, unicode.Pattern_White_Space) {
+		return false
+	}
+	return unicode.In(r, unicode.L, unicode.Nl, unicode.Other_ID_Start)
+}
+
+// IsIDContinue returns whether the given rune has the Unicode ID_Continue property, that is whether it may continue an identifier.
+func IsIDContinue(r rune) bool {
+	if IsIDStart(r) {
+		return true
+	}
+	if unicode.In(r, unicode.Pattern_Syntax, unicode.Pattern_White_Space) {
+		return false
+	}
+	return unicode.In(r, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc, unicode.Other_ID_Continue)
+}
+
+// ReadIdentifier consumes an identifier starting at the current scanner position and returns its text and span.
+// Which runes are accepted is configured with WithIdentifierRules. If the upcoming rune cannot start an identifier,
+// nothing is consumed and false is returned along with an empty string and an empty span.
+func (scanner *Scanner) ReadIdentifier() (string, Span, bool) {
+	start, cont := scanner.identStart, scanner.identContinue
+	if start == nil {
+		start = IsIDStart
+	}
+	if cont == nil {
+		cont = IsIDContinue
+	}
+
+	begin := scanner.TextPosition
+	if _, ok := scanner.AcceptFunc(start); !ok {
+		return "", Span{Start: begin, End: begin}, false
+	}
+	scanner.TakeWhile(cont)
+
+	span := Span{Start: begin, End: scanner.TextPosition}
+	return scanner.TextOf(span), span, true
+}
+
+// PeekWord returns the identifier starting at the current scanner position without advancing, so a parser can dispatch
+// on an upcoming keyword before committing to a production. The same rules as for Scanner.ReadIdentifier apply.
+// If the upcoming rune cannot start an identifier, an empty string is returned.
+func (scanner *Scanner) PeekWord() string {
+	savedPos := scanner.TextPosition
+	savedComplex := scanner.isComplexSinceMark
+
+	word, _, _ := scanner.ReadIdentifier()
+
+	scanner.TextPosition = savedPos
+	scanner.isComplexSinceMark = savedComplex
+	return word
+}
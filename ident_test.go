@@ -0,0 +1,107 @@
+package scanner
+
+import (
+	"testing"
+	"unicode"
+)
+
+func TestScannerReadIdentifier(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		options  []Option
+		expected string
+		ok       bool
+		end      int
+	}{
+		{name: "ascii", input: "foo_bar1 = 2", expected: "foo_bar1", ok: true, end: 8},
+		{name: "unicode letters", input: "größe+1", expected: "größe", ok: true, end: 7},
+		{name: "combining mark continues", input: "e\u0301x!", expected: "e\u0301x", ok: true, end: 4},
+		{name: "digit cannot start", input: "1abc", expected: "", ok: false, end: 0},
+		{name: "underscore cannot start by default", input: "_x", expected: "", ok: false, end: 0},
+		{name: "pattern syntax stops", input: "a→b", expected: "a", ok: true, end: 1},
+		{name: "escaped line break spliced", input: "ab\\\ncd", expected: "abcd", ok: true, end: 6},
+		{
+			name:     "custom rules",
+			input:    "$my-var x",
+			options:  []Option{WithIdentifierRules(func(r rune) bool { return r == '$' }, func(r rune) bool { return r == '-' || unicode.IsLetter(r) })},
+			expected: "$my-var",
+			ok:       true,
+			end:      7,
+		},
+		{
+			name:     "custom start only",
+			input:    "_x1",
+			options:  []Option{WithIdentifierRules(func(r rune) bool { return r == '_' || IsIDStart(r) }, nil)},
+			expected: "_x1",
+			ok:       true,
+			end:      3,
+		},
+		{name: "EOF", input: "", expected: "", ok: false, end: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input, tt.options...)
+			text, span, ok := scanner.ReadIdentifier()
+			if text != tt.expected || ok != tt.ok {
+				t.Errorf("ReadIdentifier() = %q, %v, expected %q, %v", text, ok, tt.expected, tt.ok)
+			}
+			if span.Start.Offset != 0 || span.End.Offset != tt.end || scanner.Offset != tt.end {
+				t.Errorf("ReadIdentifier() span [%d, %d) at offset %d, expected [0, %d)", span.Start.Offset, span.End.Offset, scanner.Offset, tt.end)
+			}
+		})
+	}
+}
+
+func TestIsIDStartContinue(t *testing.T) {
+	tests := []struct {
+		r             rune
+		start, contin bool
+	}{
+		{r: 'a', start: true, contin: true},
+		{r: '5', start: false, contin: true},
+		{r: '_', start: false, contin: true},
+		{r: 'ℕ', start: true, contin: true},
+		{r: '℘', start: true, contin: true},  // Other_ID_Start
+		{r: '·', start: false, contin: true}, // Other_ID_Continue
+		{r: '\u0301', start: false, contin: true},
+		{r: '-', start: false, contin: false},
+		{r: ' ', start: false, contin: false},
+	}
+
+	for _, tt := range tests {
+		if result := IsIDStart(tt.r); result != tt.start {
+			t.Errorf("IsIDStart(%q) = %v, expected %v", tt.r, result, tt.start)
+		}
+		if result := IsIDContinue(tt.r); result != tt.contin {
+			t.Errorf("IsIDContinue(%q) = %v, expected %v", tt.r, result, tt.contin)
+		}
+	}
+}
+
+func TestScannerPeekWord(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "keyword", input: "if x {", expected: "if"},
+		{name: "identifier", input: "func_1(", expected: "func_1"},
+		{name: "not a word", input: "(x)", expected: ""},
+		{name: "digit", input: "1x", expected: ""},
+		{name: "EOF", input: "", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			if result := scanner.PeekWord(); result != tt.expected {
+				t.Errorf("PeekWord() = %q, expected %q", result, tt.expected)
+			}
+			if scanner.Offset != 0 {
+				t.Errorf("expected nothing consumed, got offset %d", scanner.Offset)
+			}
+		})
+	}
+}
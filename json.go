@@ -0,0 +1,114 @@
+package scanner
+
+import (
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// jsonEscapes maps the runes following a backslash in a JSON string to the rune the escape sequence stands for.
+var jsonEscapes = map[byte]rune{
+	'"': '"', '\\': '\\', '/': '/', 'b': '\b', 'f': '\f', 'n': '\n', 'r': '\r', 't': '\t',
+}
+
+// ReadJSONString consumes a JSON string literal starting at the current scanner position and returns its decoded value
+// along with the span of the whole literal including the quotes. The literal is validated strictly as defined by RFC 8259:
+// it is read from the raw text, so line breaks, including escaped ones, are control characters, which must be escaped,
+// and \u escapes of UTF-16 surrogates must form a valid pair, which is decoded to a single rune.
+// If the position does not start with a double quote or the literal is unterminated, contains an unescaped control character,
+// invalid UTF-8 or an invalid escape sequence, an error wrapping ErrInvalidString is returned along with the span of the offending part,
+// and nothing is consumed.
+func (scanner *Scanner) ReadJSONString() (string, Span, error) {
+	start := scanner.TextPosition
+	text := scanner.text
+	fail := func(from, to int, format string, args ...any) (string, Span, error) {
+		// offsets of runes and escape sequences are always valid
+		startPos, _ := scanner.positionAfter(from)
+		endPos, _ := scanner.positionAfter(to)
+		span := Span{Start: startPos, End: endPos}
+		return "", span, scanner.ErrorfAt(span, "%w: "+format, append([]any{ErrInvalidString}, args...)...)
+	}
+
+	if start.Offset < 0 || start.Offset >= len(text) || text[start.Offset] != '"' {
+		return "", Span{Start: start, End: start}, scanner.Errorf("%w: expected '\"'", ErrInvalidString)
+	}
+
+	var value strings.Builder
+	i := start.Offset + 1
+	for {
+		if i >= len(text) {
+			return fail(start.Offset, i, "unterminated string")
+		}
+
+		r, w := utf8.DecodeRuneInString(text[i:])
+		switch {
+		case r == '"':
+			end, _ := scanner.positionAfter(i + 1)
+			scanner.TextPosition = end
+			scanner.isComplexSinceMark = true
+			return value.String(), Span{Start: start, End: end}, nil
+		case r < 0x20:
+			return fail(i, i+w, "unescaped control character %U", r)
+		case r == utf8.RuneError && w == 1:
+			return fail(i, i+w, "invalid UTF-8 encoding")
+		case r != '\\':
+			value.WriteString(text[i : i+w])
+			i += w
+			continue
+		}
+
+		// an escape sequence starting at i
+		if i+1 >= len(text) {
+			return fail(i, i+1, "unterminated escape sequence")
+		}
+		if escaped, ok := jsonEscapes[text[i+1]]; ok {
+			value.WriteRune(escaped)
+			i += 2
+			continue
+		}
+		if text[i+1] != 'u' {
+			_, w = utf8.DecodeRuneInString(text[i+1:])
+			return fail(i, i+1+w, "unknown escape sequence \\%s", text[i+1:i+1+w])
+		}
+
+		r, ok := jsonHex(text, i+2)
+		if !ok {
+			// the sequence may end within a multi-byte rune or a CRLF line break following the digits
+			end := min(i+6, len(text))
+			for end < len(text) && (!utf8.RuneStart(text[end]) || text[end-1:end+1] == "\r\n") {
+				end--
+			}
+			return fail(i, end, "incomplete escape sequence \\u")
+		}
+		n := 6
+		if utf16.IsSurrogate(r) {
+			low, ok := rune(0), i+12 <= len(text) && text[i+6:i+8] == `\u`
+			if ok {
+				low, ok = jsonHex(text, i+8)
+			}
+			if r = utf16.DecodeRune(r, low); !ok || r == utf8.RuneError {
+				return fail(i, i+6, "unpaired surrogate in escape sequence")
+			}
+			n = 12
+		}
+		value.WriteRune(r)
+		i += n
+	}
+}
+
+// jsonHex decodes the 4 hexadecimal digits at the given offset of text. It returns false if there are no 4 digits.
+func jsonHex(text string, offset int) (rune, bool) {
+	if offset+4 > len(text) {
+		return 0, false
+	}
+
+	var value rune
+	for _, c := range []byte(text[offset : offset+4]) {
+		digit := digitValue36(rune(c))
+		if digit >= 16 {
+			return 0, false
+		}
+		value = value*16 + rune(digit)
+	}
+	return value, true
+}
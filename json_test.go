@@ -0,0 +1,96 @@
+package scanner
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestScannerReadJSONString(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		end      int
+	}{
+		{name: "simple", input: `"hello": 1`, expected: "hello", end: 7},
+		{name: "empty", input: `""`, expected: "", end: 2},
+		{name: "escapes", input: `"a\"b\\c\/d\b\f\n\r\t"`, expected: "a\"b\\c/d\b\f\n\r\t", end: 22},
+		{name: "unicode escape", input: `"\u00e4\u00C4"`, expected: "äÄ", end: 14},
+		{name: "surrogate pair", input: `"\ud83d\ude00"`, expected: "😀", end: 14},
+		{name: "multi-byte content", input: `"世界"`, expected: "世界", end: 8},
+		{name: "backslash before line break", input: "\"a\\\\\"\n", expected: `a\`, end: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			result, span, err := scanner.ReadJSONString()
+			if err != nil {
+				t.Fatalf("ReadJSONString() unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("ReadJSONString() = %q, expected %q", result, tt.expected)
+			}
+			if span.Start.Offset != 0 || span.End.Offset != tt.end || scanner.Offset != tt.end {
+				t.Errorf("ReadJSONString() span [%d, %d) at offset %d, expected [0, %d)", span.Start.Offset, span.End.Offset, scanner.Offset, tt.end)
+			}
+		})
+	}
+}
+
+func TestScannerReadJSONStringErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		start, end int
+		message    string
+	}{
+		{name: "no quote", input: `'a'`, start: 0, end: 0, message: `invalid string literal: expected '"' at 1:1`},
+		{name: "unterminated", input: `"abc`, start: 0, end: 4, message: `invalid string literal: unterminated string at 1:1`},
+		{name: "line break", input: "\"a\nb\"", start: 2, end: 3, message: `invalid string literal: unescaped control character U+000A at 1:3`},
+		{name: "escaped line break", input: "\"a\\\nb\"", start: 2, end: 4, message: `invalid string literal: unknown escape sequence \` + "\n" + ` at 1:3`},
+		{name: "tab", input: "\"\t\"", start: 1, end: 2, message: `invalid string literal: unescaped control character U+0009 at 1:2`},
+		{name: "invalid UTF-8", input: "\"\xff\"", start: 1, end: 2, message: `invalid string literal: invalid UTF-8 encoding at 1:2`},
+		{name: "unknown escape", input: `"\x41"`, start: 1, end: 3, message: `invalid string literal: unknown escape sequence \x at 1:2`},
+		{name: "short unicode escape", input: `"\u12"`, start: 1, end: 6, message: `invalid string literal: incomplete escape sequence \u at 1:2`},
+		{name: "short unicode escape before multi-byte rune", input: `"\u123ä"`, start: 1, end: 6, message: `invalid string literal: incomplete escape sequence \u at 1:2`},
+		{name: "short unicode escape before CRLF", input: "\"\\u123\r\n\"", start: 1, end: 6, message: `invalid string literal: incomplete escape sequence \u at 1:2`},
+		{name: "lone high surrogate", input: `"\ud83d"`, start: 1, end: 7, message: `invalid string literal: unpaired surrogate in escape sequence at 1:2`},
+		{name: "lone low surrogate", input: `"\ude00\ude00"`, start: 1, end: 7, message: `invalid string literal: unpaired surrogate in escape sequence at 1:2`},
+		{name: "escape at EOF", input: `"\`, start: 1, end: 2, message: `invalid string literal: unterminated escape sequence at 1:2`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			_, span, err := scanner.ReadJSONString()
+			if !errors.Is(err, ErrInvalidString) {
+				t.Fatalf("ReadJSONString() error = %v, expected ErrInvalidString", err)
+			}
+			if err.Error() != tt.message {
+				t.Errorf("ReadJSONString() error = %q, expected %q", err.Error(), tt.message)
+			}
+			if span.Start.Offset != tt.start || span.End.Offset != tt.end {
+				t.Errorf("ReadJSONString() span [%d, %d), expected [%d, %d)", span.Start.Offset, span.End.Offset, tt.start, tt.end)
+			}
+			if scanner.Offset != 0 {
+				t.Errorf("expected nothing consumed on error, got offset %d", scanner.Offset)
+			}
+		})
+	}
+}
+
+func BenchmarkScannerReadJSONStringLongLine(b *testing.B) {
+	// reading the strings of a single long line one after another must not rescan the line for every string
+	input := strings.Repeat(`"word"`, 16*1024)
+	b.SetBytes(int64(len(input)))
+	for b.Loop() {
+		scanner := NewScanner(input)
+		for !scanner.IsEOF() {
+			if _, _, err := scanner.ReadJSONString(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
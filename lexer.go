@@ -0,0 +1,67 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrNotRegexpRule is returned by CompileLexer for rules not created by RegexpRule.
+var ErrNotRegexpRule = errors.New("token rule is not a regexp rule")
+
+// CompileLexer compiles the given rules, which must be created by RegexpRule, into a single TokenRule matching
+// the anchored alternation of all their expressions at once. The compiled rule recognizes the longest token any of the rules
+// would recognize, ties being won by the earlier rule, and produces it like that rule would, including the modifiers
+// applied to it such as TokenRule.Skipped or TokenRule.WithKeywords. Modifiers applied to the compiled rule itself are ignored.
+// Like for Scanner.Match, the expressions are matched against the raw text, so escaped line breaks are not skipped.
+// If one of the rules is not a regexp rule, an error wrapping ErrNotRegexpRule is returned.
+func CompileLexer(rules ...TokenRule) (TokenRule, error) {
+	var pattern strings.Builder
+	pattern.WriteString(`\A(?:`)
+	// the index of the capture group around the expression of each rule
+	groups := make([]int, len(rules))
+	group := 1
+	for i, rule := range rules {
+		if rule.re == nil {
+			return TokenRule{}, fmt.Errorf("%w: rule %d of kind %s", ErrNotRegexpRule, i, rule.kind)
+		}
+		if i > 0 {
+			pattern.WriteByte('|')
+		}
+		pattern.WriteString("(" + rule.re.String() + ")")
+		groups[i] = group
+		group += 1 + rule.re.NumSubexp()
+	}
+	pattern.WriteByte(')')
+
+	// the expressions are valid, so is the alternation
+	re := regexp.MustCompile(pattern.String())
+	re.Longest()
+
+	return TokenRule{choose: func(scanner *Scanner) (TokenRule, bool) {
+		start := scanner.Offset
+		if start < 0 || start > len(scanner.text) {
+			return TokenRule{}, false
+		}
+
+		loc := re.FindStringSubmatchIndex(scanner.text[start:])
+		if loc == nil || loc[1] == 0 {
+			return TokenRule{}, false
+		}
+		end, err := scanner.positionAfter(start + loc[1])
+		if err != nil {
+			// the match ends between the CR and LF of a line break
+			return TokenRule{}, false
+		}
+
+		for i, group := range groups {
+			if loc[2*group] >= 0 {
+				scanner.TextPosition = end
+				scanner.isComplexSinceMark = true
+				return rules[i], true
+			}
+		}
+		return TokenRule{}, false
+	}}, nil
+}
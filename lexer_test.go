@@ -0,0 +1,63 @@
+package scanner
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCompileLexer(t *testing.T) {
+	keywordIf := NewTokenKind("If")
+	lexer, err := CompileLexer(
+		RegexpRule(testSpace, regexp.MustCompile(`\s+`)).Skipped(),
+		RegexpRule(testOperator, regexp.MustCompile(`<|<=|=|==`)),
+		RegexpRule(testNumber, regexp.MustCompile(`(\d+)(\.\d+)?`)),
+		RegexpRule(testIdent, regexp.MustCompile(`[a-z]+`)).WithKeywords(map[string]TokenKind{"if": keywordIf}),
+		RegexpRule(testString, regexp.MustCompile(`[a-z]+`)),
+		RegexpRule(testString, regexp.MustCompile(`"[^"]*"`)),
+	)
+	if err != nil {
+		t.Fatalf("CompileLexer() unexpected error: %v", err)
+	}
+
+	tokens := collectTokens(t, NewTokenizer(NewScanner(`if x <= 3.25 == "s" ?`), lexer))
+	expected := []string{"If:if", "Ident:x", "Operator:<=", "Number:3.25", "Operator:==", `String:"s"`, "Error:?", "EOF:"}
+	if !slices.Equal(tokens, expected) {
+		t.Errorf("got tokens %q, expected %q", tokens, expected)
+	}
+}
+
+func TestCompileLexerErrors(t *testing.T) {
+	_, err := CompileLexer(RegexpRule(testIdent, regexp.MustCompile(`x`)), LiteralRule(testOperator, "+"))
+	if !errors.Is(err, ErrNotRegexpRule) {
+		t.Fatalf("CompileLexer() error = %v, expected ErrNotRegexpRule", err)
+	}
+	if message := "token rule is not a regexp rule: rule 1 of kind Operator"; err.Error() != message {
+		t.Errorf("CompileLexer() error = %q, expected %q", err.Error(), message)
+	}
+}
+
+func TestCompileLexerEmptyMatch(t *testing.T) {
+	lexer, _ := CompileLexer(RegexpRule(testIdent, regexp.MustCompile(`[a-z]*`)))
+	tokens := collectTokens(t, NewTokenizer(NewScanner("ab1"), lexer))
+	if expected := []string{"Ident:ab", "Error:1", "EOF:"}; !slices.Equal(tokens, expected) {
+		t.Errorf("got tokens %q, expected %q", tokens, expected)
+	}
+}
+
+func BenchmarkCompileLexerLongLine(b *testing.B) {
+	lexer, _ := CompileLexer(
+		RegexpRule(testSpace, regexp.MustCompile(`\s+`)).Skipped(),
+		RegexpRule(testIdent, regexp.MustCompile(`[a-z]+`)),
+	)
+	// tokenizing a single long line must not rescan the line for every token
+	input := strings.Repeat("word ", 16*1024)
+	b.SetBytes(int64(len(input)))
+	for b.Loop() {
+		tokenizer := NewTokenizer(NewScanner(input), lexer)
+		for tokenizer.NextSpan().Kind != TokenEOF {
+		}
+	}
+}
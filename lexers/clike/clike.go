@@ -0,0 +1,109 @@
+// Package clike provides a lexer for the tokens shared by C and the languages derived from it, such as C++, Java, C# and JavaScript,
+// built on the Tokenizer of package scanner. It recognizes identifiers, the keywords of C, integer, floating-point, string
+// and character literals, operators and punctuation, and skips whitespace and comments.
+package clike
+
+import (
+	"regexp"
+
+	"github.com/aCasualGoon/scanner.go"
+)
+
+// kinds is the namespace of the kinds of the tokens, named "clike".
+var kinds = scanner.NewKindNamespace("clike")
+
+// The kinds of the tokens of C-like languages.
+var (
+	Ident       = kinds.NewKind("Ident")
+	Keyword     = kinds.NewKind("Keyword")
+	Int         = kinds.NewKind("Int")
+	Float       = kinds.NewKind("Float")
+	String      = kinds.NewKind("String")
+	Char        = kinds.NewKind("Char")
+	Operator    = kinds.NewKind("Operator")
+	Punctuation = kinds.NewKind("Punctuation")
+	Comment     = kinds.NewKind("Comment")
+	Whitespace  = kinds.NewKind("Whitespace")
+)
+
+// Keywords holds the keywords of C, which are produced as Keyword tokens instead of Ident tokens.
+var Keywords = []string{
+	"auto", "break", "case", "char", "const", "continue", "default", "do", "double", "else", "enum", "extern",
+	"float", "for", "goto", "if", "inline", "int", "long", "register", "restrict", "return", "short", "signed",
+	"sizeof", "static", "struct", "switch", "typedef", "union", "unsigned", "void", "volatile", "while",
+}
+
+// Operators holds the operators of C, which are produced as Operator tokens.
+var Operators = []string{
+	"+", "-", "*", "/", "%", "++", "--", "==", "!=", "<", ">", "<=", ">=", "&&", "||", "!", "&", "|", "^", "~", "<<", ">>",
+	"=", "+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "<<=", ">>=", "->", "?", ":",
+}
+
+// Punctuators holds the punctuation of C, which is produced as Punctuation tokens.
+var Punctuators = []string{"(", ")", "[", "]", "{", "}", ";", ",", "."}
+
+var (
+	ident    = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+	integer  = regexp.MustCompile(`(?:0[xX][0-9a-fA-F]+|0[bB][01]+|[0-9]+)[uUlL]*`)
+	floating = regexp.MustCompile(`(?:(?:[0-9]+\.[0-9]*|\.[0-9]+)(?:[eE][+-]?[0-9]+)?|[0-9]+[eE][+-]?[0-9]+)[fFlL]?`)
+)
+
+// Rules returns the rules recognizing the tokens of C-like languages. Whitespace, line comments and block comments are skipped.
+// The Value of String tokens is their decoded string and the Value of Char tokens is the rune they stand for,
+// escape sequences being decoded like by Scanner.ReadQuotedString. Invalid literals and unterminated block comments are not recognized,
+// so their first rune becomes an error token.
+func Rules() []scanner.TokenRule {
+	keywords := make(map[string]scanner.TokenKind, len(Keywords))
+	for _, keyword := range Keywords {
+		keywords[keyword] = Keyword
+	}
+
+	rules := []scanner.TokenRule{
+		scanner.PredicateRule(Whitespace, func(r rune) bool { return r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\v' || r == '\f' }).Skipped(),
+		scanner.FuncRule(Comment, func(s *scanner.Scanner) bool {
+			if !s.ConsumeString("//") {
+				return false
+			}
+			s.SkipUntil('\n')
+			return true
+		}).Skipped(),
+		scanner.FuncRule(Comment, func(s *scanner.Scanner) bool {
+			if !s.ConsumeString("/*") {
+				return false
+			}
+			s.SkipUntilString("*/")
+			return s.ConsumeString("*/")
+		}).Skipped(),
+		scanner.RegexpRule(Ident, ident).WithKeywords(keywords),
+		scanner.RegexpRule(Int, integer),
+		scanner.RegexpRule(Float, floating),
+		quotedRule(String, '"').WithValue(func(lexeme string) any {
+			value, _, _ := scanner.NewScanner(lexeme).ReadQuotedString('"')
+			return value
+		}),
+		quotedRule(Char, '\'').WithValue(func(lexeme string) any {
+			value, _, _ := scanner.NewScanner(lexeme).ReadQuotedString('\'')
+			return []rune(value)[0]
+		}),
+	}
+	for _, operator := range Operators {
+		rules = append(rules, scanner.LiteralRule(Operator, operator))
+	}
+	for _, punctuator := range Punctuators {
+		rules = append(rules, scanner.LiteralRule(Punctuation, punctuator))
+	}
+	return rules
+}
+
+// quotedRule creates a rule recognizing a literal enclosed in quote. Character literals, enclosed in ', must hold exactly one rune.
+func quotedRule(kind scanner.TokenKind, quote rune) scanner.TokenRule {
+	return scanner.FuncRule(kind, func(s *scanner.Scanner) bool {
+		value, _, err := s.ReadQuotedString(quote)
+		return err == nil && (quote == '"' || len([]rune(value)) == 1)
+	})
+}
+
+// New creates a Tokenizer producing the tokens of the given source code, configured by the given options.
+func New(text string, options ...scanner.Option) *scanner.Tokenizer {
+	return scanner.NewTokenizer(scanner.NewScanner(text, options...), Rules()...)
+}
@@ -0,0 +1,58 @@
+package clike
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestLexer(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "statement",
+			input:    "if (x->n >= 0x1Fu) return y <<= 2;",
+			expected: `Keyword "if", Punctuation "(", Ident "x", Operator "->", Ident "n", Operator ">=", Int "0x1Fu", Punctuation ")", Keyword "return", Ident "y", Operator "<<=", Int "2", Punctuation ";"`,
+		},
+		{name: "floats", input: "1.5 .5 1. 2e10 3.0f 4", expected: `Float "1.5", Float ".5", Float "1.", Float "2e10", Float "3.0f", Int "4"`},
+		{name: "member access", input: "a.b", expected: `Ident "a", Punctuation ".", Ident "b"`},
+		{name: "comments", input: "a // line\n/* block\n */ b", expected: `Ident "a", Ident "b"`},
+		{name: "literals", input: `"s\"t" 'c' '\n'`, expected: `String "\"s\\\"t\"", Char "'c'", Char "'\\n'"`},
+		{name: "keyword prefix", input: "iffy intx", expected: `Ident "iffy", Ident "intx"`},
+		{name: "unterminated comment", input: "/* a", expected: `Operator "/", Operator "*", Ident "a"`},
+		{name: "unknown rune", input: "a @ b", expected: `Ident "a", Error "@", Ident "b"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result []string
+			for token := range New(tt.input).Tokens() {
+				result = append(result, token.Kind.String()+" "+strconv.Quote(token.Lexeme))
+			}
+			if joined := strings.Join(result, ", "); joined != tt.expected {
+				t.Errorf("Tokens() = %s, expected %s", joined, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLexerValues(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{input: `"a\tb"`, expected: "a\tb"},
+		{input: `'\x41'`, expected: 'A'},
+		{input: "'é'", expected: 'é'},
+	}
+
+	for _, tt := range tests {
+		token, _ := New(tt.input).Next()
+		if token.Value != tt.expected {
+			t.Errorf("value of %s = %#v, expected %#v", token, token.Value, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+// Package ini provides a lexer for INI configuration files, built on the Tokenizer of package scanner.
+//
+// A file consists of section headers like "[name]", key-value pairs like "key = value" or "key: value" and comments
+// starting with ";" or "#" on lines of their own. Values extend to the end of their line and may contain any rune.
+package ini
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/aCasualGoon/scanner.go"
+)
+
+// kinds is the namespace of the kinds of the tokens, named "ini".
+var kinds = scanner.NewKindNamespace("ini")
+
+// The kinds of the tokens of INI files.
+var (
+	Section    = kinds.NewKind("Section")
+	Key        = kinds.NewKind("Key")
+	Assign     = kinds.NewKind("Assign")
+	Value      = kinds.NewKind("Value")
+	Comment    = kinds.NewKind("Comment")
+	Whitespace = kinds.NewKind("Whitespace")
+)
+
+// valueMode is the mode of the Tokenizer after an Assign token, in which the rest of the line is the value.
+const valueMode = "value"
+
+var (
+	section = regexp.MustCompile(`\[[^\]\n]*\]`)
+	comment = regexp.MustCompile(`[;#][^\n]*`)
+	key     = regexp.MustCompile(`[^\s=:;#\[\]](?:[^=:\n]*[^\s=:])?`)
+	value   = regexp.MustCompile(`\S(?:[^\n]*\S)?`)
+)
+
+// Rules returns the rules recognizing the tokens outside of values. Whitespace and comments are skipped.
+// The Value of Section tokens is the name of the section without the brackets and surrounding whitespace.
+// The Assign rule enters the mode of values, whose rules are returned by ValueRules.
+func Rules() []scanner.TokenRule {
+	return []scanner.TokenRule{
+		scanner.PredicateRule(Whitespace, isSpace).Skipped(),
+		scanner.RegexpRule(Comment, comment).Skipped(),
+		scanner.RegexpRule(Section, section).WithValue(func(lexeme string) any {
+			return strings.TrimSpace(lexeme[1 : len(lexeme)-1])
+		}),
+		scanner.RegexpRule(Key, key),
+		scanner.FuncRule(Assign, func(s *scanner.Scanner) bool { return s.Accept('=') || s.Accept(':') }).EnterMode(valueMode),
+	}
+}
+
+// ValueRules returns the rules recognizing a value after an Assign token, which return to the rules of Rules at the end of the line.
+// Whitespace around the value is skipped and a line without a value produces no Value token.
+func ValueRules() []scanner.TokenRule {
+	return []scanner.TokenRule{
+		scanner.PredicateRule(Whitespace, func(r rune) bool { return r != '\n' && isSpace(r) }).Skipped(),
+		scanner.LiteralRule(Whitespace, "\n").Skipped().ExitMode(),
+		scanner.RegexpRule(Value, value).ExitMode(),
+	}
+}
+
+// isSpace returns whether r is whitespace in an INI file.
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\v' || r == '\f'
+}
+
+// New creates a Tokenizer producing the tokens of the given INI file, configured by the given options.
+func New(text string, options ...scanner.Option) *scanner.Tokenizer {
+	tokenizer := scanner.NewTokenizer(scanner.NewScanner(text, options...), Rules()...)
+	tokenizer.DefineMode(valueMode, ValueRules()...)
+	return tokenizer
+}
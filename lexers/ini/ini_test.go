@@ -0,0 +1,44 @@
+package ini
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestLexer(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "file",
+			input:    "; settings\n[ server ]\nhost = example.org\nport: 8080\n\n# paths\n[paths]\nroot=/var/www ; not a comment\n",
+			expected: `Section "[ server ]", Key "host", Assign "=", Value "example.org", Key "port", Assign ":", Value "8080", Section "[paths]", Key "root", Assign "=", Value "/var/www ; not a comment"`,
+		},
+		{name: "key with spaces", input: "user name = a b  ", expected: `Key "user name", Assign "=", Value "a b"`},
+		{name: "empty value", input: "a =\nb = 1", expected: `Key "a", Assign "=", Key "b", Assign "=", Value "1"`},
+		{name: "CRLF", input: "a = 1\r\nb = 2\r\n", expected: `Key "a", Assign "=", Value "1", Key "b", Assign "=", Value "2"`},
+		{name: "value with separators", input: "url = http://x=y", expected: `Key "url", Assign "=", Value "http://x=y"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result []string
+			for token := range New(tt.input).Tokens() {
+				result = append(result, token.Kind.String()+" "+strconv.Quote(token.Lexeme))
+			}
+			if joined := strings.Join(result, ", "); joined != tt.expected {
+				t.Errorf("Tokens() = %s, expected %s", joined, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLexerSectionValue(t *testing.T) {
+	token, _ := New("[ my section ]").Next()
+	if token.Kind != Section || token.Value != "my section" {
+		t.Errorf("Next() = %s with value %#v, expected a Section token with value %q", token, token.Value, "my section")
+	}
+}
@@ -0,0 +1,65 @@
+// Package json provides a lexer for JSON text as defined by RFC 8259, built on the Tokenizer of package scanner.
+package json
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/aCasualGoon/scanner.go"
+)
+
+// kinds is the namespace of the kinds of the tokens, named "json".
+var kinds = scanner.NewKindNamespace("json")
+
+// The kinds of the tokens of JSON text.
+var (
+	String       = kinds.NewKind("String")
+	Number       = kinds.NewKind("Number")
+	True         = kinds.NewKind("True")
+	False        = kinds.NewKind("False")
+	Null         = kinds.NewKind("Null")
+	LeftBrace    = kinds.NewKind("LeftBrace")
+	RightBrace   = kinds.NewKind("RightBrace")
+	LeftBracket  = kinds.NewKind("LeftBracket")
+	RightBracket = kinds.NewKind("RightBracket")
+	Colon        = kinds.NewKind("Colon")
+	Comma        = kinds.NewKind("Comma")
+	Whitespace   = kinds.NewKind("Whitespace")
+)
+
+// number matches a JSON number, which has no leading zeros, plus sign or leading or trailing decimal point.
+var number = regexp.MustCompile(`-?(?:0|[1-9][0-9]*)(?:\.[0-9]+)?(?:[eE][+-]?[0-9]+)?`)
+
+// Rules returns the rules recognizing the tokens of JSON text. Whitespace is skipped.
+// The Value of String tokens is their decoded string and the Value of Number tokens is the float64 they stand for.
+// Invalid string literals are not recognized, so their opening quote becomes an error token.
+func Rules() []scanner.TokenRule {
+	return []scanner.TokenRule{
+		scanner.PredicateRule(Whitespace, func(r rune) bool { return r == ' ' || r == '\t' || r == '\n' || r == '\r' }).Skipped(),
+		scanner.FuncRule(String, func(s *scanner.Scanner) bool {
+			_, _, err := s.ReadJSONString()
+			return err == nil
+		}).WithValue(func(lexeme string) any {
+			value, _, _ := scanner.NewScanner(lexeme).ReadJSONString()
+			return value
+		}),
+		scanner.RegexpRule(Number, number).WithValue(func(lexeme string) any {
+			value, _ := strconv.ParseFloat(lexeme, 64)
+			return value
+		}),
+		scanner.LiteralRule(True, "true").WithValue(func(string) any { return true }),
+		scanner.LiteralRule(False, "false").WithValue(func(string) any { return false }),
+		scanner.LiteralRule(Null, "null"),
+		scanner.LiteralRule(LeftBrace, "{"),
+		scanner.LiteralRule(RightBrace, "}"),
+		scanner.LiteralRule(LeftBracket, "["),
+		scanner.LiteralRule(RightBracket, "]"),
+		scanner.LiteralRule(Colon, ":"),
+		scanner.LiteralRule(Comma, ","),
+	}
+}
+
+// New creates a Tokenizer producing the tokens of the given JSON text, configured by the given options.
+func New(text string, options ...scanner.Option) *scanner.Tokenizer {
+	return scanner.NewTokenizer(scanner.NewScanner(text, options...), Rules()...)
+}
@@ -0,0 +1,87 @@
+package json
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestLexer(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "object", input: `{"a": [1, -2.5e3, true, false, null]}`, expected: `LeftBrace "{", String "\"a\"", Colon ":", LeftBracket "[", Number "1", Comma ",", Number "-2.5e3", Comma ",", True "true", Comma ",", False "false", Comma ",", Null "null", RightBracket "]", RightBrace "}"`},
+		{name: "whitespace", input: " \t\r\n1\n", expected: `Number "1"`},
+		{name: "leading zero", input: "01", expected: `Number "0", Number "1"`},
+		{name: "unterminated string", input: `"a`, expected: `Error "\"", Error "a"`},
+		{name: "unknown literal", input: "nul", expected: `Error "n", Error "u", Error "l"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result []string
+			for token := range New(tt.input).Tokens() {
+				result = append(result, token.Kind.String()+" "+strconv.Quote(token.Lexeme))
+			}
+			if joined := strings.Join(result, ", "); joined != tt.expected {
+				t.Errorf("Tokens() = %s, expected %s", joined, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLexerValues(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{input: `"a\nb\u00e4"`, expected: "a\nbä"},
+		{input: "-1.5e2", expected: -150.0},
+		{input: "true", expected: true},
+		{input: "false", expected: false},
+		{input: "null", expected: nil},
+	}
+
+	for _, tt := range tests {
+		token, _ := New(tt.input).Next()
+		if token.Value != tt.expected {
+			t.Errorf("value of %s = %#v, expected %#v", token, token.Value, tt.expected)
+		}
+	}
+}
+
+// minified returns a JSON document of the given number of array elements on a single line, like minified JSON.
+func minified(n int) string {
+	return "[" + strings.Repeat(`{"key":"value","n":-12.5e3,"ok":true},`, n-1) + `{"key":null}]`
+}
+
+func TestLexerSingleLine(t *testing.T) {
+	const n = 2000
+	input := minified(n)
+
+	count := 0
+	var last string
+	for token := range New(input).Tokens() {
+		count++
+		last = token.End.String()
+	}
+	// every element but the last has 13 tokens and a comma, the last one 5 tokens, plus the brackets of the array
+	if expected := 14*(n-1) + 5 + 2; count != expected {
+		t.Errorf("Tokens() produced %d tokens, expected %d", count, expected)
+	}
+	if expected := "1:" + strconv.Itoa(len(input)+1); last != expected {
+		t.Errorf("last token ends at %s, expected %s", last, expected)
+	}
+}
+
+func BenchmarkLexerSingleLine(b *testing.B) {
+	// minified JSON is a single long line, which must not be rescanned for every token
+	input := minified(2000)
+	b.SetBytes(int64(len(input)))
+	for b.Loop() {
+		for range New(input).Tokens() {
+		}
+	}
+}
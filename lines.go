@@ -0,0 +1,424 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrInvalidPosition is returned when a line, column or offset does not exist within the text of a Scanner.
+var ErrInvalidPosition = errors.New("invalid position")
+
+// lineIndex returns the byte offsets at which each line of the text begins.
+// The index is built on first use and cached, as the text of a Scanner never changes.
+// Line breaks (CR, LF and CRLF) are recognized the same way Scanner.Pop recognizes them.
+func (scanner *Scanner) lineIndex() []int {
+	if scanner.lineStarts != nil {
+		return scanner.lineStarts
+	}
+
+	starts := []int{0}
+	text := scanner.text
+	// line break bytes never occur inside multi-byte runes, so scanning bytes is safe
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '\n':
+			starts = append(starts, i+1)
+		case '\r':
+			if i+1 < len(text) && text[i+1] == '\n' {
+				i++
+			}
+			starts = append(starts, i+1)
+		}
+	}
+
+	scanner.lineStarts = starts
+	return starts
+}
+
+// lineRuneIndex returns the number of runes in front of each line of the text, see TextPosition.RuneIdx.
+// The index is built on first use and cached like the line index.
+func (scanner *Scanner) lineRuneIndex() []int {
+	if scanner.lineRuneStarts != nil {
+		return scanner.lineRuneStarts
+	}
+
+	starts := scanner.lineIndex()
+	runeStarts := make([]int, len(starts))
+	for i := 1; i < len(starts); i++ {
+		runeStarts[i] = runeStarts[i-1] + utf8.RuneCountInString(scanner.text[starts[i-1]:starts[i]])
+	}
+
+	scanner.lineRuneStarts = runeStarts
+	return runeStarts
+}
+
+// positionShift is the difference between the starting position given to NewScannerAt and the position of its offset within the text,
+// by which the positions derived from the text are shifted to agree with the positions reached through Scanner.Pop.
+type positionShift struct {
+	line    int // added to all line numbers
+	col     int // added to the columns on the line of the starting position
+	colLine int // line index (1-based) of the starting position, 0 if there is none
+	runeIdx int // added to all rune indices
+}
+
+// shiftTo makes the positions derived from the text continue from the given starting position.
+// A starting position whose offset is not a valid position within the text is kept as it is,
+// but cannot be related to the text, so the positions derived from the text are left unshifted.
+func (scanner *Scanner) shiftTo(start TextPosition) {
+	natural, err := scanner.positionOf(start.Offset)
+	if err != nil {
+		return
+	}
+
+	scanner.shift = positionShift{
+		line:    start.Line - natural.Line,
+		col:     start.Col - natural.Col,
+		colLine: scanner.lineOf(start.Offset),
+		runeIdx: start.RuneIdx - natural.RuneIdx,
+	}
+}
+
+// lineOf returns the line index (1-based) of the line containing the given byte offset, that is the last one starting at or before it.
+func (scanner *Scanner) lineOf(offset int) int {
+	return sort.SearchInts(scanner.lineIndex(), offset+1)
+}
+
+// lineNumber returns the line number reported for the given line index (1-based).
+func (scanner *Scanner) lineNumber(n int) int {
+	return n - 1 + scanner.lineBase + scanner.shift.line
+}
+
+// lineIndexOf returns the line index (1-based) of the given line number, the inverse of Scanner.lineNumber.
+func (scanner *Scanner) lineIndexOf(line int) int {
+	return line - scanner.lineBase - scanner.shift.line + 1
+}
+
+// firstCol returns the column reported for the beginning of the given line index (1-based).
+func (scanner *Scanner) firstCol(n int) int {
+	if n == scanner.shift.colLine {
+		return scanner.colBase + scanner.shift.col
+	}
+	return scanner.colBase
+}
+
+// lineBounds returns the byte offsets of the first rune of the given (1-based) line and of its terminating line break.
+// For the last line, the end offset is the length of the text.
+// The line must exist.
+func (scanner *Scanner) lineBounds(line int) (start, end int) {
+	starts := scanner.lineIndex()
+	start = starts[line-1]
+	if line >= len(starts) {
+		return start, len(scanner.text)
+	}
+
+	end = starts[line]
+	if end > start && scanner.text[end-1] == '\n' {
+		end--
+	}
+	if end > start && scanner.text[end-1] == '\r' {
+		end--
+	}
+	return start, end
+}
+
+// OffsetOf returns the byte offset of the given line and column within the text.
+// Lines and columns start at 1, or 0 if configured with WithZeroBasedLines or WithZeroBasedColumns,
+// and columns are counted according to the ColumnMode of the Scanner.
+// The column right after the last rune of a line (where its line break is) is valid.
+// An error wrapping ErrInvalidPosition is returned if the line or column does not exist.
+func (scanner *Scanner) OffsetOf(line, col int) (int, error) {
+	n := scanner.lineIndexOf(line)
+	if lineCount := len(scanner.lineIndex()); n < 1 || n > lineCount {
+		return 0, fmt.Errorf("%w: line %d out of range [%d, %d]", ErrInvalidPosition, line, scanner.lineNumber(1), scanner.lineNumber(lineCount))
+	}
+	if col < scanner.firstCol(n) {
+		return 0, fmt.Errorf("%w: column %d out of range on line %d", ErrInvalidPosition, col, line)
+	}
+
+	offset, end := scanner.lineBounds(n)
+	for c := scanner.firstCol(n); c < col; {
+		if offset >= end {
+			return 0, fmt.Errorf("%w: column %d out of range on line %d (max %d)", ErrInvalidPosition, col, line, c)
+		}
+		r, w := utf8.DecodeRuneInString(scanner.text[offset:end])
+		offset += w
+		c += scanner.columnMode.columnWidth(r)
+
+		if c > col {
+			return 0, fmt.Errorf("%w: column %d on line %d is inside a rune", ErrInvalidPosition, col, line)
+		}
+	}
+
+	return offset, nil
+}
+
+// positionOf returns the TextPosition of the given byte offset within the text.
+// An error wrapping ErrInvalidPosition is returned if the offset is out of range, falls inside a multi-byte rune or between the CR and LF of a CRLF line break.
+func (scanner *Scanner) positionOf(offset int) (TextPosition, error) {
+	if offset < 0 || offset > len(scanner.text) {
+		return TextPosition{}, fmt.Errorf("%w: offset %d out of range [0, %d]", ErrInvalidPosition, offset, len(scanner.text))
+	}
+	if offset < len(scanner.text) && !utf8.RuneStart(scanner.text[offset]) {
+		return TextPosition{}, fmt.Errorf("%w: offset %d is inside a multi-byte rune", ErrInvalidPosition, offset)
+	}
+
+	line := scanner.lineOf(offset)
+	start, end := scanner.lineBounds(line)
+	if offset > end {
+		return TextPosition{}, fmt.Errorf("%w: offset %d is inside a CRLF line break", ErrInvalidPosition, offset)
+	}
+
+	col := scanner.firstCol(line)
+	for _, r := range scanner.text[start:offset] {
+		col += scanner.columnMode.columnWidth(r)
+	}
+
+	pos := TextPosition{
+		Filename: scanner.filename,
+		Offset:   offset,
+		Line:     scanner.lineNumber(line),
+		Col:      col,
+		RuneIdx:  scanner.shift.runeIdx,
+	}
+	if scanner.runeIndex {
+		pos.RuneIdx += scanner.lineRuneIndex()[line-1] + utf8.RuneCountInString(scanner.text[start:offset])
+	}
+	return pos, nil
+}
+
+// positionAfter returns the TextPosition of the given byte offset, advancing from the current position over the text in between
+// the same way Scanner.Pop does, but without skipping escaped line breaks. Unlike Scanner.positionOf, it does not rescan the line
+// up to the current position, so consuming a long line piece by piece stays linear. Offsets in front of the current position
+// are left to Scanner.positionOf.
+// An error wrapping ErrInvalidPosition is returned if the offset is out of range, falls inside a multi-byte rune or between the CR and LF of a CRLF line break.
+func (scanner *Scanner) positionAfter(offset int) (TextPosition, error) {
+	if offset < scanner.Offset || scanner.Offset < 0 {
+		return scanner.positionOf(offset)
+	}
+	if offset > len(scanner.text) {
+		return TextPosition{}, fmt.Errorf("%w: offset %d out of range [0, %d]", ErrInvalidPosition, offset, len(scanner.text))
+	}
+	if offset < len(scanner.text) && !utf8.RuneStart(scanner.text[offset]) {
+		return TextPosition{}, fmt.Errorf("%w: offset %d is inside a multi-byte rune", ErrInvalidPosition, offset)
+	}
+	if offset > 0 && offset < len(scanner.text) && scanner.text[offset-1] == '\r' && scanner.text[offset] == '\n' {
+		return TextPosition{}, fmt.Errorf("%w: offset %d is inside a CRLF line break", ErrInvalidPosition, offset)
+	}
+
+	pos := scanner.TextPosition
+	for pos.Offset < offset {
+		r, w := utf8.DecodeRuneInString(scanner.text[pos.Offset:])
+		pos.Offset += w
+		if scanner.runeIndex {
+			pos.RuneIdx++
+		}
+
+		switch {
+		case r == '\n' || r == '\r' && !strings.HasPrefix(scanner.text[pos.Offset:], "\n"):
+			pos.Line++
+			pos.Col = scanner.colBase
+		case r != '\r':
+			// the CR of a CRLF line break is counted along with its LF
+			pos.Col += scanner.columnMode.columnWidth(r)
+		}
+	}
+	return pos, nil
+}
+
+// SetOffset sets the Scanner to be at the given byte offset, recomputing the line and column from the text.
+// Unlike Scanner.SetPos, the resulting position is always consistent with the text.
+// An error wrapping ErrInvalidPosition is returned and the position is left unchanged if the offset is out of range,
+// falls inside a multi-byte rune or between the CR and LF of a CRLF line break.
+func (scanner *Scanner) SetOffset(offset int) error {
+	pos, err := scanner.positionOf(offset)
+	if err != nil {
+		return err
+	}
+
+	scanner.TextPosition = pos
+	return nil
+}
+
+// SetPosStrict sets the Scanner to be at the given TextPosition after validating it against the text.
+// The filename of the given position is ignored in favor of the filename of the Scanner.
+// An error wrapping ErrInvalidPosition is returned and the position is left unchanged if the offset is not a valid position
+// (see Scanner.SetOffset) or if the line and column do not match the offset.
+func (scanner *Scanner) SetPosStrict(pos TextPosition) error {
+	actual, err := scanner.positionOf(pos.Offset)
+	if err != nil {
+		return err
+	}
+	if actual.Line != pos.Line || actual.Col != pos.Col {
+		return fmt.Errorf("%w: offset %d is at line %d, column %d, not line %d, column %d",
+			ErrInvalidPosition, pos.Offset, actual.Line, actual.Col, pos.Line, pos.Col)
+	}
+	if actual.RuneIdx != pos.RuneIdx {
+		return fmt.Errorf("%w: offset %d is at rune index %d, not %d", ErrInvalidPosition, pos.Offset, actual.RuneIdx, pos.RuneIdx)
+	}
+
+	scanner.TextPosition = actual
+	return nil
+}
+
+// LineAt returns the content of the given line without its line break, along with the span it covers.
+// Lines start at 1, or 0 if configured with WithZeroBasedLines.
+// The span ends before the line break, so its end position is the column right after the last rune of the line.
+// Escaped line breaks are not spliced, a line ending in a continuation keeps its trailing backslash.
+// If the line does not exist, an empty string and the zero Span are returned.
+func (scanner *Scanner) LineAt(line int) (text string, span Span) {
+	n := scanner.lineIndexOf(line)
+	if n < 1 || n > len(scanner.lineIndex()) {
+		return "", Span{}
+	}
+
+	start, end := scanner.lineBounds(n)
+	// both offsets are line boundaries and thus always valid
+	span.Start, _ = scanner.positionOf(start)
+	span.End, _ = scanner.positionOf(end)
+	return scanner.text[start:end], span
+}
+
+// A LineSpan represents a logical line of text, that is a line with all escaped line breaks spliced.
+type LineSpan struct {
+	// Text is the content of the line without its terminating line break.
+	// Line breaks are normalized and escaped line breaks are skipped the same way Scanner.Pop does.
+	Text string
+	// Number is the line number the logical line starts on.
+	Number int
+	// Span is the range the line covers, excluding its terminating line break.
+	Span
+}
+
+// Lines returns an iterator over the logical lines of the given piece of text.
+// The same skipping rules as for Scanner.Pop are applied, so lines joined by escaped line breaks are yielded as one line.
+func Lines(text string) iter.Seq[LineSpan] {
+	return NewScanner(text).Lines()
+}
+
+// Lines returns an iterator over the logical lines of the text of the Scanner, starting at the beginning of the text.
+// The same skipping rules as for Scanner.Pop are applied, so lines joined by escaped line breaks are yielded as one line.
+// A text ending in a line break ends in an empty line. The position of the Scanner is not changed.
+func (scanner *Scanner) Lines() iter.Seq[LineSpan] {
+	return func(yield func(LineSpan) bool) {
+		// offset 0 is always valid
+		first, _ := scanner.positionOf(0)
+		// the lines are read by a copy, which must not report progress on behalf of the Scanner
+		cursor := scanner.derive(scanner.text, first)
+
+		for {
+			start := cursor.TextPosition
+			end := start
+			r := cursor.Pop()
+			for r != '\n' && r != EOF {
+				end = cursor.TextPosition
+				r = cursor.Pop()
+			}
+
+			line := LineSpan{
+				Text:   normalize(scanner.text[start.Offset:end.Offset]),
+				Number: start.Line,
+				Span:   Span{Start: start, End: end},
+			}
+			if !yield(line) || r == EOF {
+				return
+			}
+		}
+	}
+}
+
+// ClampPos returns the valid position closest to the offset of the given position.
+// Offsets before or after the text are clamped to its beginning or end, offsets inside a multi-byte rune
+// or between the CR and LF of a CRLF line break are moved back to the start of the rune or line break.
+// The line and column of the given position are ignored and recomputed from the text.
+func (scanner *Scanner) ClampPos(pos TextPosition) TextPosition {
+	offset := min(max(pos.Offset, 0), len(scanner.text))
+	for offset > 0 && offset < len(scanner.text) && !utf8.RuneStart(scanner.text[offset]) {
+		offset--
+	}
+	if offset > 0 && offset < len(scanner.text) && scanner.text[offset-1] == '\r' && scanner.text[offset] == '\n' {
+		offset--
+	}
+
+	// the offset is valid now
+	clamped, _ := scanner.positionOf(offset)
+	return clamped
+}
+
+// LineEndingStats holds the number of each kind of line break within a text.
+type LineEndingStats struct {
+	// LF is the number of LF line breaks that are not part of a CRLF.
+	LF int
+	// CR is the number of CR line breaks that are not part of a CRLF.
+	CR int
+	// CRLF is the number of CRLF line breaks.
+	CRLF int
+	// Continuations is the number of line breaks escaped with a backslash, which are spliced by Scanner.Pop.
+	// These line breaks are also counted by their kind.
+	Continuations int
+}
+
+// Dominant returns the most common line break ("\n", "\r" or "\r\n"), or an empty string if there are no line breaks.
+// Ties are broken in favor of LF, then CRLF.
+func (stats LineEndingStats) Dominant() string {
+	switch {
+	case stats.LF == 0 && stats.CR == 0 && stats.CRLF == 0:
+		return ""
+	case stats.LF >= stats.CRLF && stats.LF >= stats.CR:
+		return "\n"
+	case stats.CRLF >= stats.CR:
+		return "\r\n"
+	default:
+		return "\r"
+	}
+}
+
+// LineEndingStats counts the line breaks within the whole text of the Scanner, regardless of its position.
+func (scanner *Scanner) LineEndingStats() LineEndingStats {
+	var stats LineEndingStats
+	starts := scanner.lineIndex()
+	for i, start := range starts[1:] {
+		// the line break ends right before the start of the next line
+		switch {
+		case start >= 2 && scanner.text[start-2] == '\r' && scanner.text[start-1] == '\n':
+			stats.CRLF++
+		case scanner.text[start-1] == '\n':
+			stats.LF++
+		default:
+			stats.CR++
+		}
+
+		if _, end := scanner.lineBounds(i + 1); end > 0 && scanner.text[end-1] == '\\' {
+			stats.Continuations++
+		}
+	}
+	return stats
+}
+
+// ReadLine consumes the rest of the current logical line including its line break and returns it.
+// The text of the line is normalized and escaped line breaks are spliced the same way as for Scanner.Lines,
+// and its span excludes the line break. The returned bool reports whether the line was terminated by a line break,
+// which is false for the last line of a text not ending in one. At the end of the text, an empty line is returned.
+func (scanner *Scanner) ReadLine() (LineSpan, bool) {
+	text, span := scanner.TakeWhile(func(r rune) bool { return r != '\n' })
+	line := LineSpan{Text: text, Number: span.Start.Line, Span: span}
+	return line, scanner.Accept('\n')
+}
+
+// ConsumeNewline consumes a single line break at the current scanner position and returns it as it appears in the text ("\n", "\r\n" or "\r").
+// If there is no line break at the position, nothing is consumed and false is returned along with an empty string.
+// Escaped line breaks are skipped the same way Scanner.Pop does, so they never count as a line break.
+func (scanner *Scanner) ConsumeNewline() (string, bool) {
+	if !scanner.Accept('\n') {
+		return "", false
+	}
+
+	text := scanner.text[:scanner.Offset]
+	if strings.HasSuffix(text, "\r\n") {
+		return "\r\n", true
+	}
+	return text[len(text)-1:], true
+}
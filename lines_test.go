@@ -0,0 +1,949 @@
+package scanner
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestScannerOffsetOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		line     int
+		col      int
+		expected int
+	}{
+		{
+			name:     "beginning of text",
+			input:    "abc",
+			line:     1,
+			col:      1,
+			expected: 0,
+		},
+		{
+			name:     "middle of first line",
+			input:    "abc",
+			line:     1,
+			col:      3,
+			expected: 2,
+		},
+		{
+			name:     "end of last line",
+			input:    "abc",
+			line:     1,
+			col:      4,
+			expected: 3,
+		},
+		{
+			name:     "empty string",
+			input:    "",
+			line:     1,
+			col:      1,
+			expected: 0,
+		},
+		{
+			name:     "second line after LF",
+			input:    "ab\ncd",
+			line:     2,
+			col:      2,
+			expected: 4,
+		},
+		{
+			name:     "second line after CR",
+			input:    "ab\rcd",
+			line:     2,
+			col:      1,
+			expected: 3,
+		},
+		{
+			name:     "second line after CRLF",
+			input:    "ab\r\ncd",
+			line:     2,
+			col:      1,
+			expected: 4,
+		},
+		{
+			name:     "line break position",
+			input:    "ab\r\ncd",
+			line:     1,
+			col:      3,
+			expected: 2,
+		},
+		{
+			name:     "empty last line",
+			input:    "ab\n",
+			line:     2,
+			col:      1,
+			expected: 3,
+		},
+		{
+			name:     "after escaped line break",
+			input:    "a\\\nb",
+			line:     2,
+			col:      1,
+			expected: 3,
+		},
+		{
+			name:     "UTF-8 characters",
+			input:    "x\nαβγ",
+			line:     2,
+			col:      3,
+			expected: 6,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+
+			offset, err := scanner.OffsetOf(tt.line, tt.col)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if offset != tt.expected {
+				t.Errorf("OffsetOf(%d, %d): expected %d, got %d", tt.line, tt.col, tt.expected, offset)
+			}
+		})
+	}
+}
+
+func TestScannerOffsetOfMatchesPop(t *testing.T) {
+	inputs := []string{
+		"abc",
+		"a\nb\rc\r\nd",
+		"αβ\nγδ",
+		"a\\\nb\\c",
+		"\n\n\r\r\n",
+	}
+
+	for _, input := range inputs {
+		scanner := NewScanner(input)
+		for {
+			pos := scanner.Pos()
+			offset, err := scanner.OffsetOf(pos.Line, pos.Col)
+			if err != nil {
+				t.Errorf("input %q at %+v: unexpected error: %v", input, pos, err)
+			} else if offset != pos.Offset {
+				t.Errorf("input %q at %+v: expected offset %d, got %d", input, pos, pos.Offset, offset)
+			}
+
+			if scanner.Pop() == EOF {
+				break
+			}
+		}
+	}
+}
+
+func TestScannerOffsetOfInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		line  int
+		col   int
+	}{
+		{name: "line zero", input: "abc", line: 0, col: 1},
+		{name: "negative line", input: "abc", line: -1, col: 1},
+		{name: "line past end", input: "abc", line: 2, col: 1},
+		{name: "column zero", input: "abc", line: 1, col: 0},
+		{name: "column past end of line", input: "abc", line: 1, col: 5},
+		{name: "column past line break", input: "ab\ncd", line: 1, col: 4},
+		{name: "column past CRLF", input: "ab\r\ncd", line: 1, col: 4},
+		{name: "column on empty line", input: "a\n\nb", line: 2, col: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+
+			_, err := scanner.OffsetOf(tt.line, tt.col)
+			if !errors.Is(err, ErrInvalidPosition) {
+				t.Errorf("OffsetOf(%d, %d): expected ErrInvalidPosition, got %v", tt.line, tt.col, err)
+			}
+		})
+	}
+}
+
+func TestScannerSetOffset(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		offset   int
+		expected TextPosition
+		next     rune
+	}{
+		{
+			name:     "beginning of text",
+			input:    "abc",
+			offset:   0,
+			expected: TextPosition{Offset: 0, Line: 1, Col: 1},
+			next:     'a',
+		},
+		{
+			name:     "middle of first line",
+			input:    "abc",
+			offset:   2,
+			expected: TextPosition{Offset: 2, Line: 1, Col: 3},
+			next:     'c',
+		},
+		{
+			name:     "end of text",
+			input:    "abc",
+			offset:   3,
+			expected: TextPosition{Offset: 3, Line: 1, Col: 4},
+			next:     EOF,
+		},
+		{
+			name:     "after LF",
+			input:    "ab\ncd",
+			offset:   4,
+			expected: TextPosition{Offset: 4, Line: 2, Col: 2},
+			next:     'd',
+		},
+		{
+			name:     "at CRLF",
+			input:    "ab\r\ncd",
+			offset:   2,
+			expected: TextPosition{Offset: 2, Line: 1, Col: 3},
+			next:     '\n',
+		},
+		{
+			name:     "after CRLF",
+			input:    "ab\r\ncd",
+			offset:   4,
+			expected: TextPosition{Offset: 4, Line: 2, Col: 1},
+			next:     'c',
+		},
+		{
+			name:     "after UTF-8 characters",
+			input:    "x\nαβγ",
+			offset:   4,
+			expected: TextPosition{Offset: 4, Line: 2, Col: 2},
+			next:     'β',
+		},
+		{
+			name:     "at escaped line break",
+			input:    "a\\\nb",
+			offset:   1,
+			expected: TextPosition{Offset: 1, Line: 1, Col: 2},
+			next:     'b',
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			scanner.SetPos(TextPosition{Offset: 0, Line: 42, Col: 42})
+
+			if err := scanner.SetOffset(tt.offset); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if scanner.Pos() != tt.expected {
+				t.Errorf("position: expected %+v, got %+v", tt.expected, scanner.Pos())
+			}
+			if r := scanner.Peek(); r != tt.next {
+				t.Errorf("next rune: expected %q, got %q", tt.next, r)
+			}
+		})
+	}
+}
+
+func TestScannerSetOffsetMatchesPop(t *testing.T) {
+	inputs := []string{
+		"abc",
+		"a\nb\rc\r\nd",
+		"αβ\nγδ",
+		"a\\\nb\\c",
+		"\n\n\r\r\n",
+	}
+
+	for _, input := range inputs {
+		popScanner := NewScanner(input)
+		seekScanner := NewScanner(input)
+		for {
+			pos := popScanner.Pos()
+			if err := seekScanner.SetOffset(pos.Offset); err != nil {
+				t.Errorf("input %q at %+v: unexpected error: %v", input, pos, err)
+			} else if seekScanner.Pos() != pos {
+				t.Errorf("input %q: expected %+v, got %+v", input, pos, seekScanner.Pos())
+			}
+
+			if popScanner.Pop() == EOF {
+				break
+			}
+		}
+	}
+}
+
+func TestScannerSetOffsetMatchesPopFromStart(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		start   TextPosition
+		options []Option
+	}{
+		{name: "later line", input: "abc\ndef", start: TextPosition{Line: 10, Col: 1}},
+		{name: "later column", input: "abc\ndef", start: TextPosition{Line: 3, Col: 7}},
+		{name: "within the text", input: "ab\ncd\nef", start: TextPosition{Offset: 4, Line: 20, Col: 5}},
+		{name: "rune index", input: "αβ\r\nγδ", start: TextPosition{Line: 2, Col: 3, RuneIdx: 40}, options: []Option{WithRuneIndex()}},
+		{name: "zero-based", input: "a\nb", start: TextPosition{Line: 0, Col: 4}, options: []Option{WithZeroBasedLines(), WithZeroBasedColumns()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			popScanner := NewScannerAt(tt.input, tt.start, tt.options...)
+			seekScanner := NewScannerAt(tt.input, tt.start, tt.options...)
+			for {
+				pos := popScanner.Pos()
+				if err := seekScanner.SetOffset(pos.Offset); err != nil {
+					t.Errorf("at %+v: unexpected error: %v", pos, err)
+				} else if seekScanner.Pos() != pos {
+					t.Errorf("SetOffset(%d): expected %+v, got %+v", pos.Offset, pos, seekScanner.Pos())
+				}
+				if offset, err := seekScanner.OffsetOf(pos.Line, pos.Col); err != nil || offset != pos.Offset {
+					t.Errorf("OffsetOf(%d, %d) = %d, %v, expected %d", pos.Line, pos.Col, offset, err, pos.Offset)
+				}
+				if err := seekScanner.SetPosStrict(pos); err != nil {
+					t.Errorf("SetPosStrict(%+v): unexpected error: %v", pos, err)
+				}
+
+				if popScanner.Pop() == EOF {
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestScannerLineAtFromStart(t *testing.T) {
+	scanner := NewScannerAt("abc\ndef", TextPosition{Line: 10, Col: 5})
+
+	text, span := scanner.LineAt(11)
+	if text != "def" {
+		t.Errorf("LineAt(11) = %q, expected %q", text, "def")
+	}
+	expected := Span{Start: TextPosition{Offset: 4, Line: 11, Col: 1}, End: TextPosition{Offset: 7, Line: 11, Col: 4}}
+	if span != expected {
+		t.Errorf("LineAt(11) span = %+v, expected %+v", span, expected)
+	}
+
+	if _, span := scanner.LineAt(10); span.Start.Col != 5 {
+		t.Errorf("LineAt(10) starts at column %d, expected 5", span.Start.Col)
+	}
+	if text, _ := scanner.LineAt(1); text != "" {
+		t.Errorf("LineAt(1) = %q, expected no line", text)
+	}
+}
+
+func TestScannerPositionAfterMatchesPositionOf(t *testing.T) {
+	inputs := []string{
+		"abc",
+		"a\nb\rc\r\nd",
+		"αβ\n世界",
+		"a\\\nb\\\r\nc",
+		"\n\n\r\r\n",
+	}
+
+	for _, input := range inputs {
+		scanner := NewScannerAt(input, TextPosition{Line: 3, Col: 5}, WithRuneIndex())
+		for from := 0; from <= len(input); from++ {
+			if scanner.SetOffset(from) != nil {
+				continue
+			}
+			for to := from; to <= len(input); to++ {
+				expected, expectedErr := scanner.positionOf(to)
+				pos, err := scanner.positionAfter(to)
+				if (err != nil) != (expectedErr != nil) || pos != expected {
+					t.Errorf("input %q: positionAfter(%d) from %d = %+v, %v, expected %+v, %v", input, to, from, pos, err, expected, expectedErr)
+				}
+			}
+		}
+	}
+}
+
+func TestScannerSetOffsetInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		offset int
+	}{
+		{name: "negative offset", input: "abc", offset: -1},
+		{name: "offset past end", input: "abc", offset: 4},
+		{name: "inside multi-byte rune", input: "αβγ", offset: 1},
+		{name: "inside CRLF", input: "a\r\nb", offset: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			before := scanner.Pos()
+
+			err := scanner.SetOffset(tt.offset)
+			if !errors.Is(err, ErrInvalidPosition) {
+				t.Errorf("SetOffset(%d): expected ErrInvalidPosition, got %v", tt.offset, err)
+			}
+			if scanner.Pos() != before {
+				t.Errorf("position changed on error: expected %+v, got %+v", before, scanner.Pos())
+			}
+		})
+	}
+}
+
+func TestScannerSetPosStrict(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		pos     TextPosition
+		wantErr bool
+	}{
+		{
+			name:  "beginning of text",
+			input: "abc",
+			pos:   TextPosition{Offset: 0, Line: 1, Col: 1},
+		},
+		{
+			name:  "end of text",
+			input: "abc",
+			pos:   TextPosition{Offset: 3, Line: 1, Col: 4},
+		},
+		{
+			name:  "after CRLF",
+			input: "a\r\nb",
+			pos:   TextPosition{Offset: 3, Line: 2, Col: 1},
+		},
+		{
+			name:  "after UTF-8 character",
+			input: "αβγ",
+			pos:   TextPosition{Offset: 4, Line: 1, Col: 3},
+		},
+		{
+			name:    "inside multi-byte rune",
+			input:   "αβγ",
+			pos:     TextPosition{Offset: 3, Line: 1, Col: 2},
+			wantErr: true,
+		},
+		{
+			name:    "inside CRLF",
+			input:   "a\r\nb",
+			pos:     TextPosition{Offset: 2, Line: 1, Col: 3},
+			wantErr: true,
+		},
+		{
+			name:    "offset past end",
+			input:   "abc",
+			pos:     TextPosition{Offset: 4, Line: 1, Col: 5},
+			wantErr: true,
+		},
+		{
+			name:    "wrong line",
+			input:   "a\nb",
+			pos:     TextPosition{Offset: 2, Line: 1, Col: 3},
+			wantErr: true,
+		},
+		{
+			name:    "wrong column",
+			input:   "αβγ",
+			pos:     TextPosition{Offset: 4, Line: 1, Col: 5},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			before := scanner.Pos()
+
+			err := scanner.SetPosStrict(tt.pos)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidPosition) {
+					t.Errorf("expected ErrInvalidPosition, got %v", err)
+				}
+				if scanner.Pos() != before {
+					t.Errorf("position changed on error: expected %+v, got %+v", before, scanner.Pos())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if scanner.Pos() != tt.pos {
+				t.Errorf("position: expected %+v, got %+v", tt.pos, scanner.Pos())
+			}
+		})
+	}
+}
+
+func TestScannerLineAt(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		line         int
+		expectedText string
+		expectedSpan Span
+	}{
+		{
+			name:         "single line",
+			input:        "abc",
+			line:         1,
+			expectedText: "abc",
+			expectedSpan: Span{
+				Start: TextPosition{Offset: 0, Line: 1, Col: 1},
+				End:   TextPosition{Offset: 3, Line: 1, Col: 4},
+			},
+		},
+		{
+			name:         "empty string",
+			input:        "",
+			line:         1,
+			expectedText: "",
+			expectedSpan: Span{
+				Start: TextPosition{Offset: 0, Line: 1, Col: 1},
+				End:   TextPosition{Offset: 0, Line: 1, Col: 1},
+			},
+		},
+		{
+			name:         "first line before LF",
+			input:        "ab\ncd",
+			line:         1,
+			expectedText: "ab",
+			expectedSpan: Span{
+				Start: TextPosition{Offset: 0, Line: 1, Col: 1},
+				End:   TextPosition{Offset: 2, Line: 1, Col: 3},
+			},
+		},
+		{
+			name:         "middle line between CRLFs",
+			input:        "ab\r\ncd\r\nef",
+			line:         2,
+			expectedText: "cd",
+			expectedSpan: Span{
+				Start: TextPosition{Offset: 4, Line: 2, Col: 1},
+				End:   TextPosition{Offset: 6, Line: 2, Col: 3},
+			},
+		},
+		{
+			name:         "line after CR",
+			input:        "ab\rcd",
+			line:         2,
+			expectedText: "cd",
+			expectedSpan: Span{
+				Start: TextPosition{Offset: 3, Line: 2, Col: 1},
+				End:   TextPosition{Offset: 5, Line: 2, Col: 3},
+			},
+		},
+		{
+			name:         "empty last line",
+			input:        "ab\n",
+			line:         2,
+			expectedText: "",
+			expectedSpan: Span{
+				Start: TextPosition{Offset: 3, Line: 2, Col: 1},
+				End:   TextPosition{Offset: 3, Line: 2, Col: 1},
+			},
+		},
+		{
+			name:         "line with continuation",
+			input:        "a\\\nb",
+			line:         1,
+			expectedText: "a\\",
+			expectedSpan: Span{
+				Start: TextPosition{Offset: 0, Line: 1, Col: 1},
+				End:   TextPosition{Offset: 2, Line: 1, Col: 3},
+			},
+		},
+		{
+			name:         "UTF-8 characters",
+			input:        "x\nαβγ",
+			line:         2,
+			expectedText: "αβγ",
+			expectedSpan: Span{
+				Start: TextPosition{Offset: 2, Line: 2, Col: 1},
+				End:   TextPosition{Offset: 8, Line: 2, Col: 4},
+			},
+		},
+		{
+			name:         "line zero",
+			input:        "abc",
+			line:         0,
+			expectedText: "",
+			expectedSpan: Span{},
+		},
+		{
+			name:         "line past end",
+			input:        "abc",
+			line:         2,
+			expectedText: "",
+			expectedSpan: Span{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+
+			text, span := scanner.LineAt(tt.line)
+			if text != tt.expectedText {
+				t.Errorf("LineAt(%d) text: expected %q, got %q", tt.line, tt.expectedText, text)
+			}
+			if span != tt.expectedSpan {
+				t.Errorf("LineAt(%d) span: expected %+v, got %+v", tt.line, tt.expectedSpan, span)
+			}
+		})
+	}
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []LineSpan
+	}{
+		{
+			name:  "empty string",
+			input: "",
+			expected: []LineSpan{
+				{Text: "", Number: 1, Span: Span{
+					Start: TextPosition{Offset: 0, Line: 1, Col: 1},
+					End:   TextPosition{Offset: 0, Line: 1, Col: 1},
+				}},
+			},
+		},
+		{
+			name:  "single line",
+			input: "abc",
+			expected: []LineSpan{
+				{Text: "abc", Number: 1, Span: Span{
+					Start: TextPosition{Offset: 0, Line: 1, Col: 1},
+					End:   TextPosition{Offset: 3, Line: 1, Col: 4},
+				}},
+			},
+		},
+		{
+			name:  "mixed line breaks",
+			input: "a\nb\r\nc\rd",
+			expected: []LineSpan{
+				{Text: "a", Number: 1, Span: Span{
+					Start: TextPosition{Offset: 0, Line: 1, Col: 1},
+					End:   TextPosition{Offset: 1, Line: 1, Col: 2},
+				}},
+				{Text: "b", Number: 2, Span: Span{
+					Start: TextPosition{Offset: 2, Line: 2, Col: 1},
+					End:   TextPosition{Offset: 3, Line: 2, Col: 2},
+				}},
+				{Text: "c", Number: 3, Span: Span{
+					Start: TextPosition{Offset: 5, Line: 3, Col: 1},
+					End:   TextPosition{Offset: 6, Line: 3, Col: 2},
+				}},
+				{Text: "d", Number: 4, Span: Span{
+					Start: TextPosition{Offset: 7, Line: 4, Col: 1},
+					End:   TextPosition{Offset: 8, Line: 4, Col: 2},
+				}},
+			},
+		},
+		{
+			name:  "trailing line break",
+			input: "a\n",
+			expected: []LineSpan{
+				{Text: "a", Number: 1, Span: Span{
+					Start: TextPosition{Offset: 0, Line: 1, Col: 1},
+					End:   TextPosition{Offset: 1, Line: 1, Col: 2},
+				}},
+				{Text: "", Number: 2, Span: Span{
+					Start: TextPosition{Offset: 2, Line: 2, Col: 1},
+					End:   TextPosition{Offset: 2, Line: 2, Col: 1},
+				}},
+			},
+		},
+		{
+			name:  "continuation spliced",
+			input: "ab\\\ncd\nef",
+			expected: []LineSpan{
+				{Text: "abcd", Number: 1, Span: Span{
+					Start: TextPosition{Offset: 0, Line: 1, Col: 1},
+					End:   TextPosition{Offset: 6, Line: 2, Col: 3},
+				}},
+				{Text: "ef", Number: 3, Span: Span{
+					Start: TextPosition{Offset: 7, Line: 3, Col: 1},
+					End:   TextPosition{Offset: 9, Line: 3, Col: 3},
+				}},
+			},
+		},
+		{
+			name:  "continuation with CRLF",
+			input: "a\\\r\nb",
+			expected: []LineSpan{
+				{Text: "ab", Number: 1, Span: Span{
+					Start: TextPosition{Offset: 0, Line: 1, Col: 1},
+					End:   TextPosition{Offset: 5, Line: 2, Col: 2},
+				}},
+			},
+		},
+		{
+			name:  "regular backslash",
+			input: "a\\b",
+			expected: []LineSpan{
+				{Text: "a\\b", Number: 1, Span: Span{
+					Start: TextPosition{Offset: 0, Line: 1, Col: 1},
+					End:   TextPosition{Offset: 3, Line: 1, Col: 4},
+				}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result []LineSpan
+			for line := range Lines(tt.input) {
+				result = append(result, line)
+			}
+
+			if len(result) != len(tt.expected) {
+				t.Fatalf("expected %d lines, got %d: %+v", len(tt.expected), len(result), result)
+			}
+			for i, expected := range tt.expected {
+				if result[i] != expected {
+					t.Errorf("line %d: expected %+v, got %+v", i, expected, result[i])
+				}
+			}
+		})
+	}
+}
+
+func TestScannerLines(t *testing.T) {
+	scanner := NewScanner("ab\ncd", WithFilename("input.txt"))
+	scanner.Pop()
+	scanner.Pop()
+	before := scanner.Pos()
+
+	var texts []string
+	for line := range scanner.Lines() {
+		if line.Start.Filename != "input.txt" {
+			t.Errorf("line %d: expected filename %q, got %q", line.Number, "input.txt", line.Start.Filename)
+		}
+		texts = append(texts, line.Text)
+	}
+
+	if len(texts) != 2 || texts[0] != "ab" || texts[1] != "cd" {
+		t.Errorf("expected lines [ab cd], got %q", texts)
+	}
+	if scanner.Pos() != before {
+		t.Errorf("position changed: expected %+v, got %+v", before, scanner.Pos())
+	}
+}
+
+func TestLinesEarlyTermination(t *testing.T) {
+	count := 0
+	for range Lines("a\nb\nc") {
+		count++
+		if count == 2 {
+			break
+		}
+	}
+
+	if count != 2 {
+		t.Errorf("expected 2 lines before break, got %d", count)
+	}
+}
+
+func TestScannerClampPos(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		pos      TextPosition
+		expected TextPosition
+	}{
+		{
+			name:     "valid position",
+			input:    "abc",
+			pos:      TextPosition{Offset: 1, Line: 1, Col: 2},
+			expected: TextPosition{Offset: 1, Line: 1, Col: 2},
+		},
+		{
+			name:     "wrong line and column",
+			input:    "a\nbc",
+			pos:      TextPosition{Offset: 3, Line: 1, Col: 4},
+			expected: TextPosition{Offset: 3, Line: 2, Col: 2},
+		},
+		{
+			name:     "negative offset",
+			input:    "abc",
+			pos:      TextPosition{Offset: -5, Line: 0, Col: 0},
+			expected: TextPosition{Offset: 0, Line: 1, Col: 1},
+		},
+		{
+			name:     "offset past end",
+			input:    "ab\ncd",
+			pos:      TextPosition{Offset: 100, Line: 9, Col: 9},
+			expected: TextPosition{Offset: 5, Line: 2, Col: 3},
+		},
+		{
+			name:     "inside two-byte rune",
+			input:    "αβ",
+			pos:      TextPosition{Offset: 3, Line: 1, Col: 2},
+			expected: TextPosition{Offset: 2, Line: 1, Col: 2},
+		},
+		{
+			name:     "inside four-byte rune",
+			input:    "a😀",
+			pos:      TextPosition{Offset: 4, Line: 1, Col: 3},
+			expected: TextPosition{Offset: 1, Line: 1, Col: 2},
+		},
+		{
+			name:     "inside CRLF",
+			input:    "a\r\nb",
+			pos:      TextPosition{Offset: 2, Line: 2, Col: 1},
+			expected: TextPosition{Offset: 1, Line: 1, Col: 2},
+		},
+		{
+			name:     "empty string",
+			input:    "",
+			pos:      TextPosition{Offset: 3, Line: 1, Col: 4},
+			expected: TextPosition{Offset: 0, Line: 1, Col: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+
+			result := scanner.ClampPos(tt.pos)
+			if result != tt.expected {
+				t.Errorf("ClampPos(%+v) = %+v, expected %+v", tt.pos, result, tt.expected)
+			}
+			if err := scanner.SetPosStrict(result); err != nil {
+				t.Errorf("clamped position is invalid: %v", err)
+			}
+		})
+	}
+}
+
+func TestScannerLineEndingStats(t *testing.T) {
+	tests := []struct {
+		name             string
+		input            string
+		expected         LineEndingStats
+		expectedDominant string
+	}{
+		{
+			name:             "no line breaks",
+			input:            "abc",
+			expected:         LineEndingStats{},
+			expectedDominant: "",
+		},
+		{
+			name:             "LF only",
+			input:            "a\nb\n",
+			expected:         LineEndingStats{LF: 2},
+			expectedDominant: "\n",
+		},
+		{
+			name:             "CRLF only",
+			input:            "a\r\nb\r\n",
+			expected:         LineEndingStats{CRLF: 2},
+			expectedDominant: "\r\n",
+		},
+		{
+			name:             "CR only",
+			input:            "a\rb",
+			expected:         LineEndingStats{CR: 1},
+			expectedDominant: "\r",
+		},
+		{
+			name:             "mixed",
+			input:            "a\r\nb\nc\r\nd\re\r\n",
+			expected:         LineEndingStats{LF: 1, CR: 1, CRLF: 3},
+			expectedDominant: "\r\n",
+		},
+		{
+			name:             "tie prefers LF",
+			input:            "a\r\nb\n",
+			expected:         LineEndingStats{LF: 1, CRLF: 1},
+			expectedDominant: "\n",
+		},
+		{
+			name:             "continuations",
+			input:            "a\\\nb\\\r\nc\\d\n",
+			expected:         LineEndingStats{LF: 2, CRLF: 1, Continuations: 2},
+			expectedDominant: "\n",
+		},
+		{
+			name:             "empty lines",
+			input:            "\n\r\n\r",
+			expected:         LineEndingStats{LF: 1, CR: 1, CRLF: 1},
+			expectedDominant: "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+
+			stats := scanner.LineEndingStats()
+			if stats != tt.expected {
+				t.Errorf("LineEndingStats() = %+v, expected %+v", stats, tt.expected)
+			}
+			if dominant := stats.Dominant(); dominant != tt.expectedDominant {
+				t.Errorf("Dominant() = %q, expected %q", dominant, tt.expectedDominant)
+			}
+		})
+	}
+}
+
+func TestScannerReadLine(t *testing.T) {
+	scanner := NewScanner("first\r\nsec\\\nond\n\nlast")
+	expected := []struct {
+		text       string
+		number     int
+		start, end int
+		terminated bool
+	}{
+		{text: "first", number: 1, start: 0, end: 5, terminated: true},
+		{text: "second", number: 2, start: 7, end: 15, terminated: true},
+		{text: "", number: 4, start: 16, end: 16, terminated: true},
+		{text: "last", number: 5, start: 17, end: 21, terminated: false},
+		{text: "", number: 5, start: 21, end: 21, terminated: false},
+	}
+
+	for i, want := range expected {
+		line, terminated := scanner.ReadLine()
+		if line.Text != want.text || line.Number != want.number || terminated != want.terminated {
+			t.Errorf("line %d: ReadLine() = %q (line %d), %v, expected %q (line %d), %v",
+				i, line.Text, line.Number, terminated, want.text, want.number, want.terminated)
+		}
+		if line.Start.Offset != want.start || line.End.Offset != want.end {
+			t.Errorf("line %d: span [%d, %d), expected [%d, %d)", i, line.Start.Offset, line.End.Offset, want.start, want.end)
+		}
+	}
+}
+
+func TestScannerConsumeNewline(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		ok       bool
+		offset   int
+	}{
+		{name: "LF", input: "\nx", expected: "\n", ok: true, offset: 1},
+		{name: "CRLF", input: "\r\nx", expected: "\r\n", ok: true, offset: 2},
+		{name: "CR", input: "\rx", expected: "\r", ok: true, offset: 1},
+		{name: "only one", input: "\n\n", expected: "\n", ok: true, offset: 1},
+		{name: "after escaped line break", input: "\\\n\r\n", expected: "\r\n", ok: true, offset: 4},
+		{name: "escaped line break only", input: "\\\nx", expected: "", ok: false, offset: 0},
+		{name: "no line break", input: "x\n", expected: "", ok: false, offset: 0},
+		{name: "EOF", input: "", expected: "", ok: false, offset: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			result, ok := scanner.ConsumeNewline()
+			if result != tt.expected || ok != tt.ok {
+				t.Errorf("ConsumeNewline() = %q, %v, expected %q, %v", result, ok, tt.expected, tt.ok)
+			}
+			if scanner.Offset != tt.offset {
+				t.Errorf("expected offset %d, got %d", tt.offset, scanner.Offset)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package scanner
+
+import "maps"
+
+// MarkNamed marks the rune at the current scanner position under the given name, to be used as the start of
+// Scanner.SliceFrom and Scanner.SliceBetweenNamed. Named marks are independent of each other and of Scanner.Mark,
+// marking a name again moves its mark.
+func (scanner *Scanner) MarkNamed(name string) {
+	if scanner.namedMarks == nil {
+		scanner.namedMarks = make(map[string]TextPosition)
+	} else if scanner.namedMarksShared {
+		scanner.namedMarks = maps.Clone(scanner.namedMarks)
+	}
+	scanner.namedMarksShared = false
+	scanner.namedMarks[name] = scanner.TextPosition
+}
+
+// MarkedNamed returns the TextPosition that was last marked under the given name using Scanner.MarkNamed,
+// and whether such a mark exists.
+func (scanner *Scanner) MarkedNamed(name string) (TextPosition, bool) {
+	pos, ok := scanner.namedMarks[name]
+	return pos, ok
+}
+
+// SliceFrom returns the string slice from the rune marked under the given name (inclusive) to the current scanner position (exclusive).
+// Line breaks are normalized and escaped line breaks are skipped the same way Scanner.Slice does.
+// An empty string is returned if there is no mark with the given name or if it lies after the current position.
+func (scanner *Scanner) SliceFrom(name string) string {
+	start, ok := scanner.namedMarks[name]
+	if !ok {
+		return ""
+	}
+	return scanner.TextOf(Span{Start: start, End: scanner.TextPosition})
+}
+
+// SliceBetweenNamed returns the string slice from the rune marked under the name a (inclusive) to the rune marked under the name b (exclusive).
+// Line breaks are normalized and escaped line breaks are skipped the same way Scanner.Slice does.
+// An empty string is returned if either mark does not exist or if mark a lies after mark b.
+func (scanner *Scanner) SliceBetweenNamed(a, b string) string {
+	start, ok := scanner.namedMarks[a]
+	if !ok {
+		return ""
+	}
+	end, ok := scanner.namedMarks[b]
+	if !ok {
+		return ""
+	}
+	return scanner.SliceBetween(start, end)
+}
+
+// SliceBetween returns the string slice from the given start position (inclusive) to the given end position (exclusive).
+// Line breaks are normalized and escaped line breaks are skipped the same way Scanner.Slice does.
+// An empty string is returned if the positions do not lie within the text or if start lies after end.
+func (scanner *Scanner) SliceBetween(start, end TextPosition) string {
+	return scanner.TextOf(Span{Start: start, End: end})
+}
+
+// PushMark pushes the current scanner position onto the mark stack, starting a capture region that is ended by Scanner.PopMark.
+// Capture regions can be nested, each PopMark ends the innermost region. The mark stack is independent of Scanner.Mark.
+func (scanner *Scanner) PushMark() {
+	scanner.markStack = append(scanner.markStack, scanner.TextPosition)
+}
+
+// PopMark pops the innermost position pushed with Scanner.PushMark off the mark stack and returns the string slice
+// from it (inclusive) to the current scanner position (exclusive).
+// Line breaks are normalized and escaped line breaks are skipped the same way Scanner.Slice does.
+// An empty string is returned if the mark stack is empty.
+func (scanner *Scanner) PopMark() string {
+	if len(scanner.markStack) == 0 {
+		return ""
+	}
+
+	start := scanner.markStack[len(scanner.markStack)-1]
+	scanner.markStack = scanner.markStack[:len(scanner.markStack)-1]
+	return scanner.TextOf(Span{Start: start, End: scanner.TextPosition})
+}
+
+// MarkDepth returns the number of positions on the mark stack.
+func (scanner *Scanner) MarkDepth() int {
+	return len(scanner.markStack)
+}
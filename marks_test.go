@@ -0,0 +1,174 @@
+package scanner
+
+import "testing"
+
+func TestScannerNamedMarks(t *testing.T) {
+	scanner := NewScanner("key = val\\\nue;")
+
+	scanner.MarkNamed("key")
+	scanner.PopN(3)
+	scanner.MarkNamed("keyEnd")
+	scanner.PopN(3)
+	scanner.MarkNamed("value")
+	for scanner.Peek() != ';' {
+		scanner.Pop()
+	}
+
+	tests := []struct {
+		name     string
+		result   string
+		expected string
+	}{
+		{name: "slice between", result: scanner.SliceBetweenNamed("key", "keyEnd"), expected: "key"},
+		{name: "slice from", result: scanner.SliceFrom("value"), expected: "value"},
+		{name: "slice from first mark", result: scanner.SliceFrom("key"), expected: "key = value"},
+		{name: "reversed marks", result: scanner.SliceBetweenNamed("value", "key"), expected: ""},
+		{name: "unknown mark", result: scanner.SliceFrom("unknown"), expected: ""},
+		{name: "unknown end mark", result: scanner.SliceBetweenNamed("key", "unknown"), expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.result != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, tt.result)
+			}
+		})
+	}
+}
+
+func TestScannerNamedMarksIndependent(t *testing.T) {
+	scanner := NewScanner("abcdef")
+
+	scanner.Mark()
+	scanner.MarkNamed("a")
+	scanner.Pop()
+	scanner.MarkNamed("b")
+	scanner.Pop()
+	scanner.MarkNamed("a")
+	scanner.Pop()
+
+	if result := scanner.Slice(); result != "abc" {
+		t.Errorf("Slice() = %q, expected %q", result, "abc")
+	}
+	if result := scanner.SliceFrom("a"); result != "c" {
+		t.Errorf("SliceFrom(a) = %q, expected %q", result, "c")
+	}
+	if result := scanner.SliceFrom("b"); result != "bc" {
+		t.Errorf("SliceFrom(b) = %q, expected %q", result, "bc")
+	}
+
+	pos, ok := scanner.MarkedNamed("b")
+	expected := TextPosition{Offset: 1, Line: 1, Col: 2}
+	if !ok || pos != expected {
+		t.Errorf("MarkedNamed(b) = %+v, %t, expected %+v, true", pos, ok, expected)
+	}
+	if _, ok := scanner.MarkedNamed("c"); ok {
+		t.Errorf("MarkedNamed(c): expected no mark")
+	}
+}
+
+func TestScannerMarkStack(t *testing.T) {
+	// call(f(x)) with nested capture regions for the call, its argument list and the inner call
+	scanner := NewScanner("call(f(x))")
+	var captures []string
+
+	scanner.PushMark()
+	for scanner.Peek() != '(' {
+		scanner.Pop()
+	}
+	scanner.Pop()
+
+	scanner.PushMark()
+	scanner.Pop()
+	scanner.Pop()
+	scanner.PushMark()
+	scanner.Pop()
+	captures = append(captures, scanner.PopMark())
+	scanner.Pop()
+	captures = append(captures, scanner.PopMark())
+	scanner.Pop()
+	captures = append(captures, scanner.PopMark())
+
+	expected := []string{"x", "f(x)", "call(f(x))"}
+	if len(captures) != len(expected) {
+		t.Fatalf("expected %q, got %q", expected, captures)
+	}
+	for i := range expected {
+		if captures[i] != expected[i] {
+			t.Errorf("capture %d: expected %q, got %q", i, expected[i], captures[i])
+		}
+	}
+	if scanner.MarkDepth() != 0 {
+		t.Errorf("MarkDepth() = %d, expected 0", scanner.MarkDepth())
+	}
+}
+
+func TestScannerMarkStackEmpty(t *testing.T) {
+	scanner := NewScanner("abc")
+	scanner.Pop()
+
+	if result := scanner.PopMark(); result != "" {
+		t.Errorf("PopMark() on empty stack = %q, expected empty string", result)
+	}
+}
+
+func TestScannerMarkStackNormalization(t *testing.T) {
+	scanner := NewScanner("a\r\nb\\\nc")
+	scanner.Mark()
+	scanner.PushMark()
+	scanner.PopN(4)
+
+	if scanner.MarkDepth() != 1 {
+		t.Errorf("MarkDepth() = %d, expected 1", scanner.MarkDepth())
+	}
+	if result := scanner.PopMark(); result != "a\nbc" {
+		t.Errorf("PopMark() = %q, expected %q", result, "a\nbc")
+	}
+	if result := scanner.Slice(); result != "a\nbc" {
+		t.Errorf("Slice() = %q, expected %q", result, "a\nbc")
+	}
+}
+
+func TestScannerSliceBetween(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		start, end int
+		expected   string
+	}{
+		{name: "whole text", input: "abc", start: 0, end: 3, expected: "abc"},
+		{name: "empty range", input: "abc", start: 1, end: 1, expected: ""},
+		{name: "CRLF normalized", input: "a\r\nb", start: 0, end: 4, expected: "a\nb"},
+		{name: "continuation skipped", input: "a\\\nb", start: 0, end: 4, expected: "ab"},
+		{name: "reversed", input: "abc", start: 2, end: 1, expected: ""},
+		{name: "past end", input: "abc", start: 1, end: 10, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			start, end := scanner.ClampPos(TextPosition{Offset: tt.start}), TextPosition{Offset: tt.end}
+			if tt.end <= len(tt.input) {
+				end = scanner.ClampPos(end)
+			}
+
+			if result := scanner.SliceBetween(start, end); result != tt.expected {
+				t.Errorf("SliceBetween(%d, %d) = %q, expected %q", tt.start, tt.end, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestScannerSliceBetweenCapturedSpan(t *testing.T) {
+	scanner := NewScanner("let x\\\ny = 1")
+	scanner.PopN(4)
+	span := scanner.PeekSpans(4)
+	start, end := span[0].Start, span[len(span)-1].End
+
+	// scanning on does not affect slicing the captured positions
+	for scanner.Pop() != EOF {
+	}
+	if result := scanner.SliceBetween(start, end); result != "xy =" {
+		t.Errorf("SliceBetween() = %q, expected %q", result, "xy =")
+	}
+}
@@ -0,0 +1,251 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+)
+
+// textPositionJSON is the JSON schema of a TextPosition.
+type textPositionJSON struct {
+	Filename string `json:"filename,omitempty"`
+	Offset   int    `json:"offset"`
+	Line     int    `json:"line"`
+	Col      int    `json:"col"`
+	RuneIdx  int    `json:"runeIdx,omitempty"`
+}
+
+// spanJSON is the JSON schema of a Span.
+type spanJSON struct {
+	Start TextPosition `json:"start"`
+	End   TextPosition `json:"end"`
+}
+
+// runeSpanJSON is the JSON schema of a RuneSpan. EOF is represented by an empty string.
+type runeSpanJSON struct {
+	Rune  string       `json:"rune"`
+	Start TextPosition `json:"start"`
+	End   TextPosition `json:"end"`
+}
+
+// lineSpanJSON is the JSON schema of a LineSpan.
+type lineSpanJSON struct {
+	Text   string       `json:"text"`
+	Number int          `json:"number"`
+	Start  TextPosition `json:"start"`
+	End    TextPosition `json:"end"`
+}
+
+// fieldSpanJSON is the JSON schema of a FieldSpan.
+type fieldSpanJSON struct {
+	Text  string       `json:"text"`
+	Start TextPosition `json:"start"`
+	End   TextPosition `json:"end"`
+}
+
+// tokenSpanJSON is the JSON schema of a TokenSpan.
+type tokenSpanJSON struct {
+	Kind  TokenKind    `json:"kind"`
+	Start TextPosition `json:"start"`
+	End   TextPosition `json:"end"`
+}
+
+// tokenJSON is the JSON schema of a Token.
+type tokenJSON struct {
+	Kind     TokenKind    `json:"kind"`
+	Lexeme   string       `json:"lexeme"`
+	Start    TextPosition `json:"start"`
+	End      TextPosition `json:"end"`
+	Value    any          `json:"value,omitempty"`
+	Mode     string       `json:"mode,omitempty"`
+	Leading  []Token      `json:"leading,omitempty"`
+	Trailing []Token      `json:"trailing,omitempty"`
+}
+
+// MarshalJSON encodes the position as {"filename": "...", "offset": 0, "line": 1, "col": 1, "runeIdx": 0}.
+// The filename and rune index are omitted if empty.
+func (pos TextPosition) MarshalJSON() ([]byte, error) {
+	return json.Marshal(textPositionJSON(pos))
+}
+
+// UnmarshalJSON decodes a position encoded by TextPosition.MarshalJSON.
+func (pos *TextPosition) UnmarshalJSON(data []byte) error {
+	var decoded textPositionJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*pos = TextPosition(decoded)
+	return nil
+}
+
+// MarshalText encodes the position in the format of TextPosition.String.
+func (pos TextPosition) MarshalText() ([]byte, error) {
+	return []byte(pos.String()), nil
+}
+
+// MarshalJSON encodes the span as {"start": ..., "end": ...} with both positions encoded by TextPosition.MarshalJSON.
+func (span Span) MarshalJSON() ([]byte, error) {
+	return json.Marshal(spanJSON(span))
+}
+
+// UnmarshalJSON decodes a span encoded by Span.MarshalJSON.
+func (span *Span) UnmarshalJSON(data []byte) error {
+	var decoded spanJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*span = Span(decoded)
+	return nil
+}
+
+// MarshalText encodes the span as "filename:line:col-line:col", or "line:col-line:col" if the start has no filename.
+func (span Span) MarshalText() ([]byte, error) {
+	end := span.End
+	end.Filename = ""
+	return []byte(span.Start.String() + "-" + end.String()), nil
+}
+
+// MarshalJSON encodes the rune span as {"rune": "a", "start": ..., "end": ...}.
+// EOF is encoded as an empty string.
+func (span RuneSpan) MarshalJSON() ([]byte, error) {
+	encoded := runeSpanJSON{Start: span.Start, End: span.End}
+	if span.Rune != EOF {
+		encoded.Rune = string(span.Rune)
+	}
+	return json.Marshal(encoded)
+}
+
+// UnmarshalJSON decodes a rune span encoded by RuneSpan.MarshalJSON.
+func (span *RuneSpan) UnmarshalJSON(data []byte) error {
+	var decoded runeSpanJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	r := EOF
+	if decoded.Rune != "" {
+		var w int
+		r, w = utf8.DecodeRuneInString(decoded.Rune)
+		if w != len(decoded.Rune) {
+			return fmt.Errorf("rune span: %q is not a single rune", decoded.Rune)
+		}
+	}
+
+	*span = newRuneSpan(r, decoded.Start, decoded.End)
+	return nil
+}
+
+// MarshalText encodes the rune span as the quoted rune followed by its span, e.g. "'a' 1:1-1:2".
+// EOF is encoded as EOF instead of a quoted rune.
+func (span RuneSpan) MarshalText() ([]byte, error) {
+	r := "EOF"
+	if span.Rune != EOF {
+		r = strconv.QuoteRune(span.Rune)
+	}
+	text, _ := span.Span.MarshalText()
+	return append([]byte(r+" "), text...), nil
+}
+
+// MarshalJSON encodes the line span as {"text": "...", "number": 1, "start": ..., "end": ...}.
+// Without it, the methods of the embedded Span would drop the text and number.
+func (line LineSpan) MarshalJSON() ([]byte, error) {
+	return json.Marshal(lineSpanJSON{Text: line.Text, Number: line.Number, Start: line.Start, End: line.End})
+}
+
+// UnmarshalJSON decodes a line span encoded by LineSpan.MarshalJSON.
+func (line *LineSpan) UnmarshalJSON(data []byte) error {
+	var decoded lineSpanJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*line = LineSpan{Text: decoded.Text, Number: decoded.Number, Span: Span{Start: decoded.Start, End: decoded.End}}
+	return nil
+}
+
+// MarshalText encodes the line span as its quoted text followed by its span, e.g. `"ab" 2:1-2:3`.
+// Without it, the method of the embedded Span would drop the text.
+func (line LineSpan) MarshalText() ([]byte, error) {
+	text, _ := line.Span.MarshalText()
+	return append([]byte(strconv.Quote(line.Text)+" "), text...), nil
+}
+
+// MarshalJSON encodes the field span as {"text": "...", "start": ..., "end": ...}.
+// Without it, the methods of the embedded Span would drop the text.
+func (field FieldSpan) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fieldSpanJSON{Text: field.Text, Start: field.Start, End: field.End})
+}
+
+// UnmarshalJSON decodes a field span encoded by FieldSpan.MarshalJSON.
+func (field *FieldSpan) UnmarshalJSON(data []byte) error {
+	var decoded fieldSpanJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*field = FieldSpan{Text: decoded.Text, Span: Span{Start: decoded.Start, End: decoded.End}}
+	return nil
+}
+
+// MarshalText encodes the field span as its quoted text followed by its span, e.g. `"ab" 1:1-1:3`.
+func (field FieldSpan) MarshalText() ([]byte, error) {
+	text, _ := field.Span.MarshalText()
+	return append([]byte(strconv.Quote(field.Text)+" "), text...), nil
+}
+
+// MarshalJSON encodes the token span as {"kind": "...", "start": ..., "end": ...}, with the kind encoded by TokenKind.MarshalText.
+// Without it, the methods of the embedded Span would drop the kind.
+func (token TokenSpan) MarshalJSON() ([]byte, error) {
+	return json.Marshal(tokenSpanJSON{Kind: token.Kind, Start: token.Start, End: token.End})
+}
+
+// UnmarshalJSON decodes a token span encoded by TokenSpan.MarshalJSON.
+func (token *TokenSpan) UnmarshalJSON(data []byte) error {
+	var decoded tokenSpanJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*token = TokenSpan{Kind: decoded.Kind, Span: Span{Start: decoded.Start, End: decoded.End}}
+	return nil
+}
+
+// MarshalText encodes the token span as its kind followed by its span, e.g. "Ident 1:1-1:3".
+func (token TokenSpan) MarshalText() ([]byte, error) {
+	text, _ := token.Span.MarshalText()
+	return append([]byte(token.Kind.String()+" "), text...), nil
+}
+
+// MarshalJSON encodes the token as {"kind": "...", "lexeme": "...", "start": ..., "end": ..., "value": ..., "mode": "...",
+// "leading": [...], "trailing": [...]}, with the kind encoded by TokenKind.MarshalText. The value, mode and trivia are omitted if empty.
+// A value that is an error, such as that of a TokenError token, is encoded as its message.
+// Without it, the methods of the embedded Span would drop everything but the span.
+func (token Token) MarshalJSON() ([]byte, error) {
+	value := token.Value
+	if err, ok := value.(error); ok {
+		value = err.Error()
+	}
+	return json.Marshal(tokenJSON{
+		Kind: token.Kind, Lexeme: token.Lexeme, Start: token.Start, End: token.End,
+		Value: value, Mode: token.Mode, Leading: token.Leading, Trailing: token.Trailing,
+	})
+}
+
+// UnmarshalJSON decodes a token encoded by Token.MarshalJSON. The value is decoded like by json.Unmarshal into an any,
+// e.g. a number value becomes a float64 and an error value its message.
+func (token *Token) UnmarshalJSON(data []byte) error {
+	var decoded tokenJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*token = Token{
+		Kind: decoded.Kind, Lexeme: decoded.Lexeme, Span: Span{Start: decoded.Start, End: decoded.End},
+		Value: decoded.Value, Mode: decoded.Mode, Leading: decoded.Leading, Trailing: decoded.Trailing,
+	}
+	return nil
+}
+
+// MarshalText encodes the token as its kind, quoted lexeme and span, e.g. `Ident "x" 1:5-1:6`.
+// Without it, the method of the embedded Span would drop the kind and lexeme.
+func (token Token) MarshalText() ([]byte, error) {
+	text, _ := token.Span.MarshalText()
+	return append([]byte(token.Kind.String()+" "+strconv.Quote(token.Lexeme)+" "), text...), nil
+}
@@ -0,0 +1,243 @@
+package scanner
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTextPositionJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		pos      TextPosition
+		expected string
+	}{
+		{
+			name:     "without filename",
+			pos:      TextPosition{Offset: 4, Line: 2, Col: 3},
+			expected: `{"offset":4,"line":2,"col":3}`,
+		},
+		{
+			name:     "with filename",
+			pos:      TextPosition{Filename: "a.txt", Offset: 0, Line: 1, Col: 1},
+			expected: `{"filename":"a.txt","offset":0,"line":1,"col":1}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.pos)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("Marshal() = %s, expected %s", data, tt.expected)
+			}
+
+			var decoded TextPosition
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if decoded != tt.pos {
+				t.Errorf("Unmarshal() = %+v, expected %+v", decoded, tt.pos)
+			}
+		})
+	}
+}
+
+func TestSpanJSON(t *testing.T) {
+	span := Span{
+		Start: TextPosition{Filename: "a.txt", Offset: 0, Line: 1, Col: 1},
+		End:   TextPosition{Filename: "a.txt", Offset: 3, Line: 1, Col: 4},
+	}
+	expected := `{"start":{"filename":"a.txt","offset":0,"line":1,"col":1},"end":{"filename":"a.txt","offset":3,"line":1,"col":4}}`
+
+	data, err := json.Marshal(span)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != expected {
+		t.Errorf("Marshal() = %s, expected %s", data, expected)
+	}
+
+	var decoded Span
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if decoded != span {
+		t.Errorf("Unmarshal() = %+v, expected %+v", decoded, span)
+	}
+}
+
+func TestRuneSpanJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		span     RuneSpan
+		expected string
+	}{
+		{
+			name:     "ASCII rune",
+			span:     newRuneSpan('a', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 1, Line: 1, Col: 2}),
+			expected: `{"rune":"a","start":{"offset":0,"line":1,"col":1},"end":{"offset":1,"line":1,"col":2}}`,
+		},
+		{
+			name:     "UTF-8 rune",
+			span:     newRuneSpan('α', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 2, Line: 1, Col: 2}),
+			expected: `{"rune":"α","start":{"offset":0,"line":1,"col":1},"end":{"offset":2,"line":1,"col":2}}`,
+		},
+		{
+			name:     "EOF",
+			span:     newRuneSpan(EOF, TextPosition{Offset: 1, Line: 1, Col: 2}, TextPosition{Offset: 1, Line: 1, Col: 2}),
+			expected: `{"rune":"","start":{"offset":1,"line":1,"col":2},"end":{"offset":1,"line":1,"col":2}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.span)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("Marshal() = %s, expected %s", data, tt.expected)
+			}
+
+			var decoded RuneSpan
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if decoded != tt.span {
+				t.Errorf("Unmarshal() = %+v, expected %+v", decoded, tt.span)
+			}
+		})
+	}
+}
+
+func TestRuneSpanJSONInvalidRune(t *testing.T) {
+	var decoded RuneSpan
+	if err := json.Unmarshal([]byte(`{"rune":"ab"}`), &decoded); err == nil {
+		t.Errorf("expected error for multi-rune string, got %+v", decoded)
+	}
+}
+
+func TestMarshalText(t *testing.T) {
+	span := Span{
+		Start: TextPosition{Filename: "a.txt", Offset: 0, Line: 1, Col: 1},
+		End:   TextPosition{Filename: "a.txt", Offset: 5, Line: 2, Col: 2},
+	}
+
+	tests := []struct {
+		name      string
+		marshaler interface{ MarshalText() ([]byte, error) }
+		expected  string
+	}{
+		{name: "position", marshaler: span.Start, expected: "a.txt:1:1"},
+		{name: "span", marshaler: span, expected: "a.txt:1:1-2:2"},
+		{name: "rune span", marshaler: RuneSpan{Rune: 'x', Span: span}, expected: "'x' a.txt:1:1-2:2"},
+		{name: "EOF rune span", marshaler: RuneSpan{Rune: EOF, Span: span}, expected: "EOF a.txt:1:1-2:2"},
+		{name: "line span", marshaler: LineSpan{Text: "a\tb", Number: 1, Span: span}, expected: `"a\tb" a.txt:1:1-2:2`},
+		{name: "field span", marshaler: FieldSpan{Text: "ab", Span: span}, expected: `"ab" a.txt:1:1-2:2`},
+		{name: "token span", marshaler: TokenSpan{Kind: testIdent, Span: span}, expected: "Ident a.txt:1:1-2:2"},
+		{name: "token", marshaler: Token{Kind: testIdent, Lexeme: "x", Span: span}, expected: `Ident "x" a.txt:1:1-2:2`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.marshaler.MarshalText()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("MarshalText() = %q, expected %q", data, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLineSpanJSON(t *testing.T) {
+	line := LineSpan{Text: "ab", Number: 2, Span: Span{
+		Start: TextPosition{Offset: 3, Line: 2, Col: 1},
+		End:   TextPosition{Offset: 5, Line: 2, Col: 3},
+	}}
+	expected := `{"text":"ab","number":2,"start":{"offset":3,"line":2,"col":1},"end":{"offset":5,"line":2,"col":3}}`
+
+	data, err := json.Marshal(line)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != expected {
+		t.Errorf("Marshal() = %s, expected %s", data, expected)
+	}
+
+	var decoded LineSpan
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if decoded != line {
+		t.Errorf("Unmarshal() = %+v, expected %+v", decoded, line)
+	}
+}
+
+func TestTokenJSON(t *testing.T) {
+	span := Span{Start: TextPosition{Offset: 2, Line: 1, Col: 3}, End: TextPosition{Offset: 3, Line: 1, Col: 4}}
+	space := Token{Kind: testSpace, Lexeme: " ", Span: Span{Start: TextPosition{Offset: 1, Line: 1, Col: 2}, End: span.Start}}
+	token := Token{Kind: testNumber, Lexeme: "7", Span: span, Value: 7.0, Mode: "expr", Leading: []Token{space}}
+	expected := `{"kind":"Number","lexeme":"7","start":{"offset":2,"line":1,"col":3},"end":{"offset":3,"line":1,"col":4},"value":7,"mode":"expr",` +
+		`"leading":[{"kind":"Space","lexeme":" ","start":{"offset":1,"line":1,"col":2},"end":{"offset":2,"line":1,"col":3}}]}`
+
+	data, err := json.Marshal(token)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != expected {
+		t.Errorf("Marshal() = %s, expected %s", data, expected)
+	}
+
+	var decoded Token
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, token) {
+		t.Errorf("Unmarshal() = %+v, expected %+v", decoded, token)
+	}
+
+	errorToken := Token{Kind: TokenError, Lexeme: "?", Span: span, Value: errors.New("no token rule matches '?'")}
+	if data, err := json.Marshal(errorToken); err != nil || !strings.Contains(string(data), `"value":"no token rule matches '?'"`) {
+		t.Errorf("Marshal() = %s, %v, expected the error message as value", data, err)
+	}
+}
+
+func TestSpanEmbeddingJSON(t *testing.T) {
+	span := Span{Start: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 2, Line: 1, Col: 3}}
+	position := `"start":{"offset":0,"line":1,"col":1},"end":{"offset":2,"line":1,"col":3}`
+
+	tests := []struct {
+		name     string
+		value    any
+		decoded  any
+		expected string
+	}{
+		{name: "field span", value: FieldSpan{Text: "ab", Span: span}, decoded: &FieldSpan{}, expected: `{"text":"ab",` + position + `}`},
+		{name: "token span", value: TokenSpan{Kind: testIdent, Span: span}, decoded: &TokenSpan{}, expected: `{"kind":"Ident",` + position + `}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("Marshal() = %s, expected %s", data, tt.expected)
+			}
+			if err := json.Unmarshal(data, tt.decoded); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if decoded := reflect.ValueOf(tt.decoded).Elem().Interface(); decoded != tt.value {
+				t.Errorf("Unmarshal() = %+v, expected %+v", decoded, tt.value)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package scanner
+
+// A Matcher is a precompiled set of words, such as the keywords or operators of a language, for use with Scanner.MatchLongest.
+// A Matcher is immutable and can be shared between Scanners.
+type Matcher struct {
+	root *matcherNode
+}
+
+// matcherNode is a node of the trie of a Matcher.
+type matcherNode struct {
+	children map[rune]*matcherNode
+	word     string // the word ending at this node, if terminal
+	terminal bool
+}
+
+// NewMatcher creates a Matcher for the given words. Empty words are ignored.
+func NewMatcher(words []string) *Matcher {
+	root := &matcherNode{}
+	for _, word := range words {
+		if word == "" {
+			continue
+		}
+
+		node := root
+		for _, r := range word {
+			child, ok := node.children[r]
+			if !ok {
+				if node.children == nil {
+					node.children = make(map[rune]*matcherNode)
+				}
+				child = &matcherNode{}
+				node.children[r] = child
+			}
+			node = child
+		}
+		node.word, node.terminal = word, true
+	}
+	return &Matcher{root: root}
+}
+
+// MatchLongest consumes the longest word of m the upcoming runes start with and returns it.
+// The upcoming runes are normalized the same way as for Scanner.ConsumeString.
+// If none of the words match, nothing is consumed and false is returned along with an empty string.
+func (scanner *Scanner) MatchLongest(m *Matcher) (string, bool) {
+	savedPos := scanner.TextPosition
+	savedComplex := scanner.isComplexSinceMark
+
+	var match *matcherNode
+	var matchPos TextPosition
+	var matchComplex bool
+	for node := m.root; ; {
+		r := scanner.Pop()
+		if node = node.children[r]; r == EOF || node == nil {
+			break
+		}
+		if node.terminal {
+			match, matchPos, matchComplex = node, scanner.TextPosition, scanner.isComplexSinceMark
+		}
+	}
+
+	if match == nil {
+		scanner.TextPosition = savedPos
+		scanner.isComplexSinceMark = savedComplex
+		return "", false
+	}
+	scanner.TextPosition = matchPos
+	scanner.isComplexSinceMark = matchComplex
+	return match.word, true
+}
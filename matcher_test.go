@@ -0,0 +1,49 @@
+package scanner
+
+import "testing"
+
+func TestScannerMatchLongest(t *testing.T) {
+	operators := NewMatcher([]string{"<", "<=", "<<", "<<=", "<<<", "=", "==", "→", ""})
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		ok       bool
+		offset   int
+	}{
+		{name: "single", input: "< x", expected: "<", ok: true, offset: 1},
+		{name: "longest", input: "<<=1", expected: "<<=", ok: true, offset: 3},
+		{name: "falls back to shorter", input: "<<-", expected: "<<", ok: true, offset: 2},
+		{name: "equality", input: "==", expected: "==", ok: true, offset: 2},
+		{name: "multi-byte", input: "→", expected: "→", ok: true, offset: 3},
+		{name: "escaped line break skipped", input: "<\\\n=", expected: "<=", ok: true, offset: 4},
+		{name: "no match", input: ">", expected: "", ok: false, offset: 0},
+		{name: "EOF", input: "", expected: "", ok: false, offset: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			result, ok := scanner.MatchLongest(operators)
+			if result != tt.expected || ok != tt.ok {
+				t.Errorf("MatchLongest() = %q, %v, expected %q, %v", result, ok, tt.expected, tt.ok)
+			}
+			if scanner.Offset != tt.offset {
+				t.Errorf("expected offset %d, got %d", tt.offset, scanner.Offset)
+			}
+		})
+	}
+}
+
+func TestScannerMatchLongestPartialWord(t *testing.T) {
+	keywords := NewMatcher([]string{"interface", "int"})
+	scanner := NewScanner("inter")
+
+	if result, ok := scanner.MatchLongest(keywords); !ok || result != "int" {
+		t.Errorf("MatchLongest() = %q, %v, expected %q, true", result, ok, "int")
+	}
+	if r := scanner.Peek(); r != 'e' {
+		t.Errorf("Peek() = %q, expected 'e'", r)
+	}
+}
@@ -0,0 +1,51 @@
+package scanner
+
+// A Memo is the memoization table of a single parsing rule, created by Scanner.Memo.
+// It maps the position a rule was applied at to its result and the position it ended at,
+// so packrat-style parsers never apply the same rule at the same position twice.
+type Memo struct {
+	scanner *Scanner
+	entries map[int]memoEntry // keyed by start offset
+}
+
+// memoEntry is the memoized application of a rule.
+type memoEntry struct {
+	result any
+	end    TextPosition
+}
+
+// Memo returns the memoization table for the rule with the given ID, creating it on first use.
+// Memoization is entirely optional, Scanners that never call Memo carry no memoization state.
+func (scanner *Scanner) Memo(ruleID int) *Memo {
+	if scanner.memos == nil {
+		scanner.memos = make(map[int]*Memo)
+	}
+
+	memo, ok := scanner.memos[ruleID]
+	if !ok {
+		memo = &Memo{scanner: scanner, entries: make(map[int]memoEntry)}
+		scanner.memos[ruleID] = memo
+	}
+	return memo
+}
+
+// Get looks up the result of the rule at the current scanner position.
+// If the rule was memoized there, its result is returned and the scanner is moved to the position the rule ended at.
+// Otherwise ok is false and the scanner is not moved.
+func (memo *Memo) Get() (result any, ok bool) {
+	entry, ok := memo.entries[memo.scanner.Offset]
+	if !ok {
+		return nil, false
+	}
+
+	memo.scanner.TextPosition = entry.end
+	// the skipped text may need normalization when slicing
+	memo.scanner.isComplexSinceMark = true
+	return entry.result, true
+}
+
+// Set memoizes the result of the rule applied at the given start position, ending at the current scanner position.
+// Failed applications should be memoized as well, typically with a nil result and the start position restored beforehand.
+func (memo *Memo) Set(start TextPosition, result any) {
+	memo.entries[start.Offset] = memoEntry{result: result, end: memo.scanner.TextPosition}
+}
@@ -0,0 +1,94 @@
+package scanner
+
+import "testing"
+
+func TestScannerMemo(t *testing.T) {
+	const ruleNumber = 1
+	calls := 0
+
+	// parseNumber consumes a run of digits, memoized per position
+	parseNumber := func(scanner *Scanner) (string, bool) {
+		memo := scanner.Memo(ruleNumber)
+		if result, ok := memo.Get(); ok {
+			number, _ := result.(string)
+			return number, number != ""
+		}
+
+		calls++
+		start := scanner.Pos()
+		scanner.Mark()
+		for r := scanner.Peek(); r >= '0' && r <= '9'; r = scanner.Peek() {
+			scanner.Pop()
+		}
+		number := scanner.Slice()
+		if number == "" {
+			scanner.SetPos(start)
+		}
+		memo.Set(start, number)
+		return number, number != ""
+	}
+
+	scanner := NewScanner("123+x")
+
+	// first alternative: number followed by '-', fails and backtracks
+	scanner.Try(func(scanner *Scanner) bool {
+		_, ok := parseNumber(scanner)
+		return ok && scanner.Pop() == '-'
+	})
+	// second alternative: number followed by '+'
+	number, ok := parseNumber(scanner)
+
+	if !ok || number != "123" {
+		t.Errorf("parseNumber() = %q, %t, expected %q, true", number, ok, "123")
+	}
+	if calls != 1 {
+		t.Errorf("expected rule to be applied once, got %d", calls)
+	}
+	if scanner.Pos() != (TextPosition{Offset: 3, Line: 1, Col: 4}) {
+		t.Errorf("expected position after number, got %+v", scanner.Pos())
+	}
+
+	// failures are memoized too
+	scanner.Pop()
+	before := scanner.Pos()
+	parseNumber(scanner)
+	parseNumber(scanner)
+	if calls != 2 {
+		t.Errorf("expected rule to be applied twice, got %d", calls)
+	}
+	if scanner.Pos() != before {
+		t.Errorf("failed rule moved position to %+v", scanner.Pos())
+	}
+}
+
+func TestScannerMemoRules(t *testing.T) {
+	scanner := NewScanner("abc")
+
+	scanner.Memo(1).Set(scanner.Pos(), "one")
+	if _, ok := scanner.Memo(2).Get(); ok {
+		t.Errorf("expected no result for other rule")
+	}
+	if scanner.Memo(1) != scanner.Memo(1) {
+		t.Errorf("expected the same memo for the same rule")
+	}
+
+	result, ok := scanner.Memo(1).Get()
+	if !ok || result != "one" {
+		t.Errorf("Get() = %v, %t, expected %q, true", result, ok, "one")
+	}
+}
+
+func TestScannerMemoSlice(t *testing.T) {
+	scanner := NewScanner("a\r\nb")
+	start := scanner.Pos()
+	scanner.Pop()
+	scanner.Pop()
+	scanner.Memo(0).Set(start, nil)
+
+	scanner.SetPos(start)
+	scanner.Mark()
+	scanner.Memo(0).Get()
+	if result := scanner.Slice(); result != "a\n" {
+		t.Errorf("Slice() = %q, expected %q", result, "a\n")
+	}
+}
@@ -0,0 +1,186 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidNumber is returned when the text at the scanner position is not a well-formed numeric literal.
+var ErrInvalidNumber = errors.New("invalid number")
+
+// ReadInt consumes an integer literal starting at the current scanner position and returns its value, lexeme and span.
+// The literal may have a sign, a 0x, 0o or 0b base prefix (or a leading 0 for octal) and underscores between digits, as in Go.
+// The lexeme extends over all following letters, digits and underscores, so "12ab" is malformed rather than read as 12.
+// If there is no number at the position or the literal is malformed or does not fit into an int64,
+// an error wrapping ErrInvalidNumber is returned and nothing is consumed.
+func (scanner *Scanner) ReadInt() (int64, string, Span, error) {
+	return readNumber(scanner, false, func(lexeme string) (int64, error) {
+		return strconv.ParseInt(lexeme, 0, 64)
+	})
+}
+
+// ReadFloat consumes a numeric literal starting at the current scanner position and returns its value, lexeme and span.
+// In addition to the integer literals accepted by Scanner.ReadInt, decimal and hexadecimal floating-point literals
+// with a fraction and an exponent (e or E, p or P for hexadecimal) are accepted, as in Go.
+// If there is no number at the position or the literal is malformed or out of range,
+// an error wrapping ErrInvalidNumber is returned and nothing is consumed.
+func (scanner *Scanner) ReadFloat() (float64, string, Span, error) {
+	return readNumber(scanner, true, func(lexeme string) (float64, error) {
+		value, err := strconv.ParseFloat(lexeme, 64)
+		if errors.Is(err, strconv.ErrSyntax) {
+			// binary and octal literals are only understood as integers
+			if i, intErr := strconv.ParseInt(lexeme, 0, 64); intErr == nil {
+				return float64(i), nil
+			}
+		}
+		return value, err
+	})
+}
+
+// ReadUint consumes an unsigned integer literal in the given base starting at the current scanner position
+// and returns its value, lexeme and span. For base 0, the base is implied by a 0x, 0o or 0b prefix (or a leading 0 for octal)
+// as in Go, otherwise base must be between 2 and 36 and the literal consists of digits of that base only, letters standing for digits above 9.
+// Underscores may separate digits in either case. The lexeme extends over all following letters, digits and underscores like for Scanner.ReadInt.
+// If there is no number at the position or the literal is malformed or does not fit into a uint64,
+// an error wrapping ErrInvalidNumber is returned along with the lexeme and span, and nothing is consumed.
+func (scanner *Scanner) ReadUint(base int) (uint64, string, Span, error) {
+	start := scanner.TextPosition
+	if base != 0 && (base < 2 || base > 36) {
+		return 0, "", Span{Start: start, End: start}, fmt.Errorf("%w: invalid base %d", ErrInvalidNumber, base)
+	}
+
+	savedComplex := scanner.isComplexSinceMark
+	fail := func(lexeme string, span Span, err error) (uint64, string, Span, error) {
+		scanner.TextPosition = start
+		scanner.isComplexSinceMark = savedComplex
+		return 0, lexeme, span, err
+	}
+
+	limit := base
+	if base == 0 {
+		limit = 10
+	}
+	if digitValue36(scanner.Peek()) >= limit {
+		return fail("", Span{Start: start, End: start}, scanner.Errorf("%w: expected number", ErrInvalidNumber))
+	}
+	lexeme, span := scanner.TakeWhile(func(r rune) bool { return isDigit(r) || isLetter(r) || r == '_' })
+
+	digits := lexeme
+	if base != 0 {
+		// strconv only accepts underscores with base 0
+		if strings.HasSuffix(lexeme, "_") || strings.Contains(lexeme, "__") {
+			return fail(lexeme, span, scanner.ErrorfAt(span, "%w: malformed number %q", ErrInvalidNumber, lexeme))
+		}
+		digits = strings.ReplaceAll(lexeme, "_", "")
+	}
+	value, err := strconv.ParseUint(digits, base, 64)
+	if err != nil {
+		return fail(lexeme, span, scanner.numberError(lexeme, span, err))
+	}
+	return value, lexeme, span, nil
+}
+
+// ReadDigits consumes up to max digits of the given base starting at the current scanner position and returns them along with their span,
+// e.g. ReadDigits(16, 2) for the digits of a \x escape sequence. Letters stand for digits above 9 in either case.
+// A negative max consumes all following digits. If there are no digits, an empty string and an empty span are returned.
+// The base must be between 2 and 36.
+func (scanner *Scanner) ReadDigits(base, max int) (string, Span) {
+	n := 0
+	return scanner.TakeWhile(func(r rune) bool {
+		if n == max || digitValue36(r) >= base {
+			return false
+		}
+		n++
+		return true
+	})
+}
+
+// ReadHexDigits consumes up to max hexadecimal digits starting at the current scanner position like Scanner.ReadDigits does.
+func (scanner *Scanner) ReadHexDigits(max int) (string, Span) {
+	return scanner.ReadDigits(16, max)
+}
+
+// digitValue36 returns the value of the given digit in bases up to 36, or 36 if the rune is no digit.
+func digitValue36(r rune) int {
+	switch {
+	case isDigit(r):
+		return int(r - '0')
+	case 'a' <= r && r <= 'z':
+		return int(r - 'a' + 10)
+	case 'A' <= r && r <= 'Z':
+		return int(r - 'A' + 10)
+	}
+	return 36
+}
+
+// readNumber consumes the lexeme of a numeric literal and converts it with parse.
+// If the lexeme is missing or cannot be parsed, the state of the Scanner is left unchanged.
+func readNumber[T any](scanner *Scanner, fraction bool, parse func(lexeme string) (T, error)) (T, string, Span, error) {
+	savedPos := scanner.TextPosition
+	savedComplex := scanner.isComplexSinceMark
+
+	lexeme, span, err := scanner.scanNumber(fraction)
+	if err == nil {
+		var value T
+		if value, err = parse(lexeme); err == nil {
+			return value, lexeme, span, nil
+		}
+		err = scanner.numberError(lexeme, span, err)
+	}
+
+	scanner.TextPosition = savedPos
+	scanner.isComplexSinceMark = savedComplex
+	var zero T
+	return zero, lexeme, span, err
+}
+
+// scanNumber consumes the lexeme of a numeric literal and returns it along with its span.
+// If fractions and exponents are allowed, a '.' and the sign of an exponent are part of the lexeme.
+// If the position does not start with a (signed) digit, or '.' followed by a digit for fractions,
+// an error wrapping ErrInvalidNumber is returned.
+func (scanner *Scanner) scanNumber(fraction bool) (string, Span, error) {
+	start := scanner.TextPosition
+
+	scanner.AcceptFunc(func(r rune) bool { return r == '+' || r == '-' })
+	first := scanner.Peek()
+	if !isDigit(first) && !(fraction && first == '.' && isDigit(scanner.LookAhead(1))) {
+		span := Span{Start: start, End: start}
+		return "", span, scanner.ErrorfAt(span, "%w: expected number", ErrInvalidNumber)
+	}
+
+	hex := scanner.HasPrefix("0x") || scanner.HasPrefix("0X")
+	previous := EOF
+	scanner.TakeWhile(func(r rune) bool {
+		accept := isDigit(r) || isLetter(r) || r == '_'
+		if fraction {
+			exponent := previous == 'p' || previous == 'P' || (!hex && (previous == 'e' || previous == 'E'))
+			accept = accept || r == '.' || ((r == '+' || r == '-') && exponent)
+		}
+		previous = r
+		return accept
+	})
+
+	span := Span{Start: start, End: scanner.TextPosition}
+	return scanner.TextOf(span), span, nil
+}
+
+// numberError wraps a strconv error for the given lexeme in an error wrapping ErrInvalidNumber.
+func (scanner *Scanner) numberError(lexeme string, span Span, err error) error {
+	reason := "malformed"
+	if errors.Is(err, strconv.ErrRange) {
+		reason = "out of range"
+	}
+	return scanner.ErrorfAt(span, "%w: %s number %q", ErrInvalidNumber, reason, lexeme)
+}
+
+// isDigit returns whether r is an ASCII decimal digit.
+func isDigit(r rune) bool {
+	return '0' <= r && r <= '9'
+}
+
+// isLetter returns whether r is an ASCII letter.
+func isLetter(r rune) bool {
+	return ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z')
+}
@@ -0,0 +1,200 @@
+package scanner
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestScannerReadInt(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected int64
+		lexeme   string
+		err      bool
+		offset   int
+	}{
+		{name: "decimal", input: "123 ", expected: 123, lexeme: "123", offset: 3},
+		{name: "negative", input: "-42;", expected: -42, lexeme: "-42", offset: 3},
+		{name: "positive sign", input: "+7", expected: 7, lexeme: "+7", offset: 2},
+		{name: "hex", input: "0xFF)", expected: 255, lexeme: "0xFF", offset: 4},
+		{name: "octal", input: "0o17", expected: 15, lexeme: "0o17", offset: 4},
+		{name: "legacy octal", input: "017", expected: 15, lexeme: "017", offset: 3},
+		{name: "binary", input: "0b101", expected: 5, lexeme: "0b101", offset: 5},
+		{name: "underscores", input: "1_000_000", expected: 1000000, lexeme: "1_000_000", offset: 9},
+		{name: "stops at dot", input: "1.5", expected: 1, lexeme: "1", offset: 1},
+		{name: "escaped line break spliced", input: "12\\\n34", expected: 1234, lexeme: "1234", offset: 6},
+		{name: "not a number", input: "abc", err: true, offset: 0},
+		{name: "sign only", input: "-x", err: true, offset: 0},
+		{name: "trailing letters", input: "12ab", lexeme: "12ab", err: true, offset: 0},
+		{name: "double underscore", input: "1__0", lexeme: "1__0", err: true, offset: 0},
+		{name: "empty hex", input: "0x", lexeme: "0x", err: true, offset: 0},
+		{name: "overflow", input: "9223372036854775808", lexeme: "9223372036854775808", err: true, offset: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			value, lexeme, span, err := scanner.ReadInt()
+			if tt.err {
+				if !errors.Is(err, ErrInvalidNumber) {
+					t.Errorf("ReadInt() error = %v, expected ErrInvalidNumber", err)
+				}
+			} else if err != nil {
+				t.Errorf("ReadInt() unexpected error: %v", err)
+			}
+			if value != tt.expected || lexeme != tt.lexeme {
+				t.Errorf("ReadInt() = %d, %q, expected %d, %q", value, lexeme, tt.expected, tt.lexeme)
+			}
+			if !tt.err && span.End.Offset != tt.offset {
+				t.Errorf("ReadInt() span ends at %d, expected %d", span.End.Offset, tt.offset)
+			}
+			if scanner.Offset != tt.offset {
+				t.Errorf("expected offset %d, got %d", tt.offset, scanner.Offset)
+			}
+		})
+	}
+}
+
+func TestScannerReadFloat(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected float64
+		lexeme   string
+		err      bool
+	}{
+		{name: "integer", input: "3", expected: 3, lexeme: "3"},
+		{name: "fraction", input: "3.25,", expected: 3.25, lexeme: "3.25"},
+		{name: "leading dot", input: ".5", expected: 0.5, lexeme: ".5"},
+		{name: "trailing dot", input: "5.)", expected: 5, lexeme: "5."},
+		{name: "exponent", input: "1e3", expected: 1000, lexeme: "1e3"},
+		{name: "negative exponent", input: "-2.5E-1 ", expected: -0.25, lexeme: "-2.5E-1"},
+		{name: "underscores", input: "1_000.5", expected: 1000.5, lexeme: "1_000.5"},
+		{name: "hex float", input: "0x1.8p1", expected: 3, lexeme: "0x1.8p1"},
+		{name: "hex e is a digit", input: "0x1e+2", expected: 30, lexeme: "0x1e"},
+		{name: "binary", input: "0b11", expected: 3, lexeme: "0b11"},
+		{name: "lone dot", input: ".x", err: true},
+		{name: "double dot", input: "1.2.3", lexeme: "1.2.3", err: true},
+		{name: "missing exponent", input: "1e", lexeme: "1e", err: true},
+		{name: "out of range", input: "1e400", lexeme: "1e400", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			value, lexeme, _, err := scanner.ReadFloat()
+			if tt.err {
+				if !errors.Is(err, ErrInvalidNumber) {
+					t.Errorf("ReadFloat() error = %v, expected ErrInvalidNumber", err)
+				}
+				if scanner.Offset != 0 {
+					t.Errorf("expected nothing consumed on error, got offset %d", scanner.Offset)
+				}
+			} else if err != nil {
+				t.Errorf("ReadFloat() unexpected error: %v", err)
+			}
+			if value != tt.expected || lexeme != tt.lexeme {
+				t.Errorf("ReadFloat() = %v, %q, expected %v, %q", value, lexeme, tt.expected, tt.lexeme)
+			}
+		})
+	}
+}
+
+func TestScannerReadIntErrorPosition(t *testing.T) {
+	scanner := NewScanner("x = 0xZ", WithFilename("a.cfg"))
+	scanner.PopN(4)
+
+	_, _, span, err := scanner.ReadInt()
+	if err == nil {
+		t.Fatalf("ReadInt() expected error")
+	}
+	if expected := `invalid number: malformed number "0xZ" at a.cfg:1:5`; err.Error() != expected {
+		t.Errorf("ReadInt() error = %q, expected %q", err.Error(), expected)
+	}
+	if span.Start.Offset != 4 || span.End.Offset != 7 {
+		t.Errorf("ReadInt() span = [%d, %d), expected [4, 7)", span.Start.Offset, span.End.Offset)
+	}
+}
+
+func TestScannerReadUint(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		base     int
+		expected uint64
+		lexeme   string
+		err      string
+		offset   int
+	}{
+		{name: "decimal", input: "123 ", base: 0, expected: 123, lexeme: "123", offset: 3},
+		{name: "hex prefix", input: "0xFF)", base: 0, expected: 255, lexeme: "0xFF", offset: 4},
+		{name: "octal prefix", input: "0o17", base: 0, expected: 15, lexeme: "0o17", offset: 4},
+		{name: "binary prefix", input: "0b1010_1010", base: 0, expected: 170, lexeme: "0b1010_1010", offset: 11},
+		{name: "max", input: "18446744073709551615", base: 0, expected: 1<<64 - 1, lexeme: "18446744073709551615", offset: 20},
+		{name: "explicit hex", input: "ff_ff,", base: 16, expected: 0xffff, lexeme: "ff_ff", offset: 5},
+		{name: "explicit binary", input: "1101", base: 2, expected: 13, lexeme: "1101", offset: 4},
+		{name: "base 36", input: "zz", base: 36, expected: 1295, lexeme: "zz", offset: 2},
+		{name: "sign", input: "-1", base: 0, err: "invalid number: expected number at 1:1", offset: 0},
+		{name: "digit outside base", input: "2", base: 2, err: "invalid number: expected number at 1:1", offset: 0},
+		{name: "letter outside base", input: "1g", base: 16, lexeme: "1g", err: `invalid number: malformed number "1g" at 1:1`, offset: 0},
+		{name: "trailing underscore", input: "12_", base: 10, lexeme: "12_", err: `invalid number: malformed number "12_" at 1:1`, offset: 0},
+		{name: "overflow", input: "0x1_0000_0000_0000_0000", base: 0, lexeme: "0x1_0000_0000_0000_0000", err: `invalid number: out of range number "0x1_0000_0000_0000_0000" at 1:1`, offset: 0},
+		{name: "invalid base", input: "1", base: 37, err: "invalid number: invalid base 37", offset: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			value, lexeme, span, err := scanner.ReadUint(tt.base)
+			if tt.err != "" {
+				if !errors.Is(err, ErrInvalidNumber) || err.Error() != tt.err {
+					t.Errorf("ReadUint() error = %v, expected %q", err, tt.err)
+				}
+			} else if err != nil {
+				t.Errorf("ReadUint() unexpected error: %v", err)
+			}
+			if value != tt.expected || lexeme != tt.lexeme {
+				t.Errorf("ReadUint() = %d, %q, expected %d, %q", value, lexeme, tt.expected, tt.lexeme)
+			}
+			if span.End.Offset-span.Start.Offset != len(tt.lexeme) {
+				t.Errorf("ReadUint() span [%d, %d), expected length %d", span.Start.Offset, span.End.Offset, len(tt.lexeme))
+			}
+			if scanner.Offset != tt.offset {
+				t.Errorf("expected offset %d, got %d", tt.offset, scanner.Offset)
+			}
+		})
+	}
+}
+
+func TestScannerReadDigits(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		read     func(*Scanner) (string, Span)
+		expected string
+	}{
+		{name: "decimal", input: "123a", read: func(s *Scanner) (string, Span) { return s.ReadDigits(10, -1) }, expected: "123"},
+		{name: "limited", input: "12345", read: func(s *Scanner) (string, Span) { return s.ReadDigits(10, 3) }, expected: "123"},
+		{name: "binary", input: "10102", read: func(s *Scanner) (string, Span) { return s.ReadDigits(2, -1) }, expected: "1010"},
+		{name: "base 36", input: "zZ9-", read: func(s *Scanner) (string, Span) { return s.ReadDigits(36, -1) }, expected: "zZ9"},
+		{name: "hex", input: "fF0g", read: func(s *Scanner) (string, Span) { return s.ReadHexDigits(-1) }, expected: "fF0"},
+		{name: "hex color", input: "ff8800cc", read: func(s *Scanner) (string, Span) { return s.ReadHexDigits(6) }, expected: "ff8800"},
+		{name: "none", input: "x1", read: func(s *Scanner) (string, Span) { return s.ReadDigits(10, -1) }, expected: ""},
+		{name: "zero max", input: "1", read: func(s *Scanner) (string, Span) { return s.ReadDigits(10, 0) }, expected: ""},
+		{name: "escaped line break spliced", input: "1\\\n2", read: func(s *Scanner) (string, Span) { return s.ReadDigits(10, 2) }, expected: "12"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			result, span := tt.read(scanner)
+			if result != tt.expected {
+				t.Errorf("got %q, expected %q", result, tt.expected)
+			}
+			if span.End.Offset != scanner.Offset || scanner.TextOf(span) != tt.expected {
+				t.Errorf("span [%d, %d) does not cover %q", span.Start.Offset, span.End.Offset, tt.expected)
+			}
+		})
+	}
+}
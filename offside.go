@@ -0,0 +1,132 @@
+package scanner
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrIndentation is returned when the indentation of a line does not match the enclosing blocks.
+var ErrIndentation = errors.New("inconsistent indentation")
+
+// indentLevel is the indentation of an open block, measured with tabs advancing to the next tab stop and as single columns.
+type indentLevel struct {
+	width, narrow int
+}
+
+// Offside returns a TokenFilter implementing the offside rule of languages like Python on top of the tokens read from scanner:
+// a token of kind newline is inserted at the end of each logical line that contains tokens, and tokens of kind indent or dedent
+// when the indentation of the next line increases or decreases, one dedent for each closed block.
+// Indentation is measured like by Scanner.IndentationWidth, lines continued by an escaped line break do not start a new logical line,
+// and lines without tokens, e.g. blank or comment-only lines, are ignored. At the end of the text, all open blocks are closed.
+// If a line is dedented to a width that matches no enclosing block, or its order relative to the enclosing blocks depends on the tab width,
+// a token of kind TokenError whose Value is an error wrapping ErrIndentation is inserted in front of the line's first token.
+// The inserted tokens have empty lexemes and spans: newline tokens at the end of the line, indent and dedent tokens at the first token of the line.
+func Offside(scanner *Scanner, newline, indent, dedent TokenKind) TokenFilter {
+	return func(source TokenSource) TokenSource {
+		levels := []indentLevel{{}}
+		var queue []Token // inserted tokens followed by the token read from the source
+		var previous *Token
+		return TokenSourceFunc(func() (Token, error) {
+			for len(queue) == 0 {
+				token, err := source.Next()
+				if err != nil {
+					return token, err
+				}
+
+				switch {
+				case token.Kind == TokenEOF && previous != nil:
+					queue = append(queue, emptyToken(newline, previous.End))
+					for range levels[1:] {
+						queue = append(queue, emptyToken(dedent, token.Start))
+					}
+					levels = levels[:1]
+				case token.Kind == TokenEOF:
+				case previous == nil || scanner.startsLogicalLine(token.Start.Line, previous.End.Line):
+					if previous != nil {
+						queue = append(queue, emptyToken(newline, previous.End))
+					}
+					level := scanner.indentationOf(token.Start.Line)
+					var inserted []Token
+					levels, inserted = offsideTokens(levels, level, token.Start, indent, dedent)
+					queue = append(queue, inserted...)
+				}
+
+				queue = append(queue, token)
+				if token.Kind != TokenEOF {
+					previous = &token
+				}
+			}
+
+			token := queue[0]
+			queue = queue[1:]
+			return token, nil
+		})
+	}
+}
+
+// offsideTokens returns the blocks open after a line indented by level starting at pos, along with the indent, dedent or error tokens to insert.
+func offsideTokens(levels []indentLevel, level indentLevel, pos TextPosition, indent, dedent TokenKind) ([]indentLevel, []Token) {
+	top := levels[len(levels)-1]
+	switch {
+	case level == top:
+		return levels, nil
+	case level.width > top.width && level.narrow > top.narrow:
+		return append(levels, level), []Token{emptyToken(indent, pos)}
+	case level.width > top.width || level.narrow > top.narrow:
+		return levels, []Token{indentationError(pos, "indentation is ambiguous, tabs and spaces are mixed inconsistently")}
+	}
+
+	var tokens []Token
+	for len(levels) > 1 && levels[len(levels)-1].width > level.width {
+		levels = levels[:len(levels)-1]
+		tokens = append(tokens, emptyToken(dedent, pos))
+	}
+	if levels[len(levels)-1] != level {
+		tokens = append(tokens, indentationError(pos, "dedent does not match any outer indentation level"))
+	}
+	return levels, tokens
+}
+
+// startsLogicalLine returns whether the given line starts a new logical line after the line the previous token ends on,
+// i.e. whether the line is after it and not joined to it by escaped line breaks.
+func (scanner *Scanner) startsLogicalLine(line, previousLine int) bool {
+	for ; line > previousLine; line-- {
+		text, _ := scanner.LineAt(line - 1)
+		if !strings.HasSuffix(text, "\\") {
+			return true
+		}
+	}
+	return false
+}
+
+// indentationOf measures the indentation of the given line.
+func (scanner *Scanner) indentationOf(line int) indentLevel {
+	text, _ := scanner.LineAt(line)
+	var level indentLevel
+	for _, r := range text {
+		switch {
+		case r == ' ':
+			level.width++
+		case r == '\t' && scanner.tabWidth > 0:
+			level.width += scanner.tabWidth - level.width%scanner.tabWidth
+		case r == '\t':
+			level.width++
+		default:
+			return level
+		}
+		level.narrow++
+	}
+	return level
+}
+
+// emptyToken returns a token of the given kind with an empty lexeme and span at pos.
+func emptyToken(kind TokenKind, pos TextPosition) Token {
+	return Token{Kind: kind, Span: Span{Start: pos, End: pos}}
+}
+
+// indentationError returns a token of kind TokenError at pos whose Value is an *Error wrapping ErrIndentation.
+func indentationError(pos TextPosition, msg string) Token {
+	token := emptyToken(TokenError, pos)
+	token.Value = &Error{Span: token.Span, Msg: ErrIndentation.Error() + ": " + msg, Err: ErrIndentation}
+	return token
+}
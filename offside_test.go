@@ -0,0 +1,83 @@
+package scanner
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+var (
+	testNewline = NewTokenKind("Newline")
+	testIndent  = NewTokenKind("Indent")
+	testDedent  = NewTokenKind("Dedent")
+)
+
+func TestOffside(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		tabWidth int
+		expected []string
+	}{
+		{name: "flat", input: "a\nb", expected: []string{"Ident:a", "Newline:", "Ident:b", "Newline:", "EOF:"}},
+		{name: "block", input: "a\n  b\n  c\nd", expected: []string{
+			"Ident:a", "Newline:", "Indent:", "Ident:b", "Newline:", "Ident:c", "Newline:", "Dedent:", "Ident:d", "Newline:", "EOF:",
+		}},
+		{name: "nested closed at EOF", input: "a\n b\n  c\n", expected: []string{
+			"Ident:a", "Newline:", "Indent:", "Ident:b", "Newline:", "Indent:", "Ident:c", "Newline:", "Dedent:", "Dedent:", "EOF:",
+		}},
+		{name: "several dedents", input: "a\n b\n  c\nd", expected: []string{
+			"Ident:a", "Newline:", "Indent:", "Ident:b", "Newline:", "Indent:", "Ident:c", "Newline:", "Dedent:", "Dedent:", "Ident:d", "Newline:", "EOF:",
+		}},
+		{name: "blank lines ignored", input: "a\n\n    \n  b", expected: []string{"Ident:a", "Newline:", "Indent:", "Ident:b", "Newline:", "Dedent:", "EOF:"}},
+		{name: "continuation", input: "a \\\n  b\nc", expected: []string{"Ident:a", "Ident:b", "Newline:", "Ident:c", "Newline:", "EOF:"}},
+		{name: "tab equals spaces", input: "a\n\tb\n        c", tabWidth: 8, expected: []string{
+			"Ident:a", "Newline:", "Indent:", "Ident:b", "Newline:", "Error:", "Ident:c", "Newline:", "Dedent:", "EOF:",
+		}},
+		{name: "tab after spaces", input: "a\n  b\n  \tc", tabWidth: 4, expected: []string{
+			"Ident:a", "Newline:", "Indent:", "Ident:b", "Newline:", "Indent:", "Ident:c", "Newline:", "Dedent:", "Dedent:", "EOF:",
+		}},
+		{name: "unmatched dedent", input: "a\n    b\n  c", expected: []string{
+			"Ident:a", "Newline:", "Indent:", "Ident:b", "Newline:", "Dedent:", "Error:", "Ident:c", "Newline:", "EOF:",
+		}},
+		{name: "empty", input: "  \n", expected: []string{"EOF:"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var options []Option
+			if tt.tabWidth != 0 {
+				options = append(options, WithTabWidth(tt.tabWidth))
+			}
+			scanner := NewScanner(tt.input, options...)
+			source := Filter(NewTokenizer(scanner, testRules()...), Offside(scanner, testNewline, testIndent, testDedent))
+			if tokens := collectTokens(t, source); !slices.Equal(tokens, tt.expected) {
+				t.Errorf("got tokens %q, expected %q", tokens, tt.expected)
+			}
+		})
+	}
+}
+
+func TestOffsideErrorToken(t *testing.T) {
+	scanner := NewScanner("a\n    b\n  c")
+	source := Filter(NewTokenizer(scanner, testRules()...), Offside(scanner, testNewline, testIndent, testDedent))
+
+	for {
+		token, err := source.Next()
+		if err != nil || token.Kind == TokenEOF {
+			t.Fatalf("Next() = %s, %v, expected an error token", token, err)
+		}
+		if token.Kind != TokenError {
+			continue
+		}
+
+		err, _ = token.Value.(error)
+		if !errors.Is(err, ErrIndentation) {
+			t.Fatalf("error token value = %v, expected ErrIndentation", token.Value)
+		}
+		if message := "inconsistent indentation: dedent does not match any outer indentation level at 3:3"; err.Error() != message {
+			t.Errorf("error = %q, expected %q", err.Error(), message)
+		}
+		return
+	}
+}
@@ -0,0 +1,134 @@
+package scanner
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// TokenizeParallel splits the given text into about the given number of chunks at line breaks and tokenizes the chunks concurrently,
+// each with the Tokenizer newTokenizer creates for the Scanner of the chunk, configured by the given options. It returns the tokens
+// of all chunks in order, followed by a single TokenEOF token, with the same positions as if the whole text had been tokenized at once.
+// If chunks is not positive, the text is split into runtime.GOMAXPROCS(0) chunks.
+//
+// The text is only split after line breaks that are not escaped, so the result equals that of a single Tokenizer as long as
+// no token or trivia spans an unescaped line break and the rules do not depend on what came before, as is the case for
+// line-delimited formats such as logs or CSV without quoted line breaks. Each chunk starts in the default mode of its Tokenizer.
+//
+// If the Tokenizers stop on errors (see Tokenizer.SetStopOnError), the tokens end with the empty error token of the first unrecognized input
+// instead of the TokenEOF token, like Tokenizer.Next stops there.
+//
+// A callback set with WithProgress receives the fraction of the whole text consumed by all chunks together. It is invoked from
+// the goroutines of the chunks, but never concurrently.
+func TokenizeParallel(text string, chunks int, newTokenizer func(*Scanner) *Tokenizer, options ...Option) []Token {
+	if chunks <= 0 {
+		chunks = runtime.GOMAXPROCS(0)
+	}
+
+	whole := NewScanner(text, options...)
+	var regions []Span
+	start := whole.TextPosition
+	for i := 1; i < chunks; i++ {
+		offset := lineBoundary(text, max(start.Offset, len(text)*i/chunks))
+		if offset <= start.Offset || offset >= len(text) {
+			continue
+		}
+		// boundaries are at the start of a line, which is always a valid position
+		end, _ := whole.positionOf(offset)
+		regions = append(regions, Span{Start: start, End: end})
+		start = end
+	}
+	regions = append(regions, Span{Start: start, End: AdvancePosition(start, text[start.Offset:], whole.positionOptions()...)})
+
+	// the tokens of each chunk end with its TokenEOF token, or the error token it stopped at
+	results := make([][]Token, len(regions))
+	progress := newParallelProgress(whole, len(regions))
+	var wg sync.WaitGroup
+	for i, region := range regions {
+		scanner := whole.region(region)
+		progress.track(scanner, i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tokenizer := newTokenizer(scanner)
+			for {
+				token, err := tokenizer.Next()
+				results[i] = append(results[i], token)
+				if err != nil || token.Kind == TokenEOF {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// the trivia in front of the TokenEOF token of a chunk leads the first token of the following chunks
+	total := 0
+	for _, result := range results {
+		total += len(result)
+	}
+	tokens := make([]Token, 0, total)
+	var leading []Token
+	for _, result := range results {
+		if len(leading) > 0 {
+			result[0].Leading = append(leading, result[0].Leading...)
+		}
+		last := result[len(result)-1]
+		if last.Kind != TokenEOF {
+			return append(tokens, result...)
+		}
+		tokens = append(tokens, result[:len(result)-1]...)
+		leading = last.Leading
+	}
+	return append(tokens, results[len(results)-1][len(results[len(results)-1])-1])
+}
+
+// parallelProgress combines the progress of the chunks of TokenizeParallel into the progress of the whole text.
+type parallelProgress struct {
+	mu       sync.Mutex
+	whole    *Scanner
+	consumed []int // bytes consumed by each chunk
+	total    int   // bytes consumed by all chunks
+}
+
+// newParallelProgress returns a parallelProgress reporting to the progress callback of whole, or nil if it has none.
+func newParallelProgress(whole *Scanner, chunks int) *parallelProgress {
+	if whole.progressEvery <= 0 || whole.progressCallback == nil {
+		return nil
+	}
+	return &parallelProgress{whole: whole, consumed: make([]int, chunks)}
+}
+
+// track makes the Scanner of the given chunk report its progress to the progress callback of the whole text.
+func (progress *parallelProgress) track(scanner *Scanner, chunk int) {
+	if progress == nil {
+		return
+	}
+	start := scanner.Offset
+	scanner.progressEvery = progress.whole.progressEvery
+	scanner.progressCallback = func(float64) {
+		progress.mu.Lock()
+		defer progress.mu.Unlock()
+		consumed := scanner.furthestOffset - start
+		progress.total += consumed - progress.consumed[chunk]
+		progress.consumed[chunk] = consumed
+		progress.whole.progressCallback(progress.whole.fractionAt(progress.total))
+	}
+}
+
+// lineBoundary returns the offset of the start of the first line at or after the given offset that follows an unescaped line break,
+// or the length of the text if there is none.
+func lineBoundary(text string, offset int) int {
+	for offset < len(text) {
+		i := strings.IndexByte(text[offset:], '\n')
+		if i < 0 {
+			return len(text)
+		}
+		end := offset + i
+		offset = end + 1
+		if !strings.HasSuffix(strings.TrimSuffix(text[:end], "\r"), "\\") {
+			return offset
+		}
+	}
+	return len(text)
+}
@@ -0,0 +1,110 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestTokenizeParallel(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		options []Option
+		stop    bool
+	}{
+		{name: "lines", input: strings.Repeat("a <= 1\nb = \"x y\"\n", 20)},
+		{name: "CRLF", input: strings.Repeat("a <= 1\r\nb = 2\r\n", 20)},
+		{name: "continuations", input: strings.Repeat("a <= \\\n1\nb\\\r\n= 2\n", 20)},
+		{name: "multi-byte runes", input: strings.Repeat("äöü < ß\n", 30), options: []Option{WithRuneIndex(), WithFilename("x.txt")}},
+		{name: "errors and no final line break", input: strings.Repeat("a ? b\n", 10) + "c"},
+		{name: "stopping on errors", input: strings.Repeat("a = b\n", 10) + "c ?\n" + strings.Repeat("a = b\n", 10), stop: true},
+		{name: "empty", input: ""},
+	}
+
+	describe := func(tokens []Token) []string {
+		var result []string
+		for _, token := range tokens {
+			result = append(result, fmt.Sprintf("%s %+v %+v %+v", token, token.Span, token.Leading, token.Trailing))
+		}
+		return result
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTokenizer := func(scanner *Scanner) *Tokenizer {
+				tokenizer := NewTokenizer(scanner, testRules()...)
+				tokenizer.SetAttachTrivia(true)
+				tokenizer.SetStopOnError(tt.stop)
+				return tokenizer
+			}
+
+			var expected []Token
+			tokenizer := newTokenizer(NewScanner(tt.input, tt.options...))
+			for {
+				token, err := tokenizer.Next()
+				expected = append(expected, token)
+				if err != nil || token.Kind == TokenEOF {
+					break
+				}
+			}
+
+			for chunks := 1; chunks <= 8; chunks++ {
+				result := TokenizeParallel(tt.input, chunks, newTokenizer, tt.options...)
+				got, want := describe(result), describe(expected)
+				if strings.Join(got, "\n") != strings.Join(want, "\n") {
+					t.Errorf("TokenizeParallel() with %d chunks = \n%s\nexpected\n%s", chunks, strings.Join(got, "\n"), strings.Join(want, "\n"))
+				}
+			}
+		})
+	}
+}
+
+func TestTokenizeParallelProgress(t *testing.T) {
+	input := strings.Repeat("a <= 1\n", 200)
+	// the callback is never invoked concurrently, so it needs no synchronization
+	var reported []float64
+	progress := WithProgress(10, func(progress float64) { reported = append(reported, progress) })
+	TokenizeParallel(input, 4, func(scanner *Scanner) *Tokenizer { return NewTokenizer(scanner, testRules()...) }, progress)
+
+	// each chunk reports every 10 of its runes, so up to 3 reports per chunk are lost to the remainders
+	if runes := len(input); len(reported) < runes/10-3 || len(reported) > runes/10 {
+		t.Errorf("got %d reports for %d runes, expected about %d", len(reported), runes, runes/10)
+	}
+	for i, fraction := range reported {
+		if fraction <= 0 || fraction > 1 || (i > 0 && fraction <= reported[i-1]) {
+			t.Fatalf("reports %v are not increasing within (0, 1]", reported)
+		}
+	}
+}
+
+func TestLineBoundary(t *testing.T) {
+	tests := []struct {
+		input    string
+		offset   int
+		expected int
+	}{
+		{input: "ab\ncd", offset: 0, expected: 3},
+		{input: "ab\ncd", offset: 3, expected: 5},
+		{input: "a\\\nb\nc", offset: 0, expected: 5},
+		{input: "a\\\r\nb\r\nc", offset: 0, expected: 7},
+		{input: "a\\\\\nb\nc", offset: 0, expected: 6},
+		{input: "abc", offset: 1, expected: 3},
+	}
+
+	for _, tt := range tests {
+		if result := lineBoundary(tt.input, tt.offset); result != tt.expected {
+			t.Errorf("lineBoundary(%q, %d) = %d, expected %d", tt.input, tt.offset, result, tt.expected)
+		}
+	}
+}
+
+func BenchmarkTokenizeParallel(b *testing.B) {
+	input := benchmarkInput(10000)
+	newTokenizer := func(scanner *Scanner) *Tokenizer { return NewTokenizer(scanner, benchmarkRules()...) }
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	for b.Loop() {
+		TokenizeParallel(input, 0, newTokenizer)
+	}
+}
@@ -0,0 +1,40 @@
+package scanner
+
+// WithProgress sets a callback that is invoked every time the Scanner has consumed another n runes of its text.
+// The callback receives the fraction of the text consumed so far, between 0 and 1.
+// Runes are only counted the first time they are consumed, so peeking and backtracking do not report progress twice.
+// A non-positive n disables the callback.
+func WithProgress(n int, callback func(progress float64)) Option {
+	return func(scanner *Scanner) {
+		scanner.progressEvery = n
+		scanner.progressCallback = callback
+	}
+}
+
+// Progress returns the fraction of the text in front of the current position, between 0 and 1.
+// An empty text is always fully scanned.
+func (scanner *Scanner) Progress() float64 {
+	return scanner.fractionAt(scanner.Offset)
+}
+
+// fractionAt returns the fraction of the text in front of the given offset, between 0 and 1.
+func (scanner *Scanner) fractionAt(offset int) float64 {
+	if len(scanner.text) == 0 {
+		return 1
+	}
+	return float64(min(max(offset, 0), len(scanner.text))) / float64(len(scanner.text))
+}
+
+// trackProgress counts the rune just consumed by Scanner.Pop if it has not been consumed before,
+// invoking the progress callback every time the configured number of runes is reached.
+func (scanner *Scanner) trackProgress() {
+	if scanner.progressEvery <= 0 || scanner.progressCallback == nil || scanner.Offset <= scanner.furthestOffset {
+		return
+	}
+
+	scanner.furthestOffset = scanner.Offset
+	scanner.progressRunes++
+	if scanner.progressRunes%scanner.progressEvery == 0 {
+		scanner.progressCallback(scanner.fractionAt(scanner.furthestOffset))
+	}
+}
@@ -0,0 +1,90 @@
+package scanner
+
+import "testing"
+
+func TestScannerProgress(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		pops     int
+		expected float64
+	}{
+		{name: "empty string", input: "", pops: 0, expected: 1},
+		{name: "beginning", input: "abcd", pops: 0, expected: 0},
+		{name: "half way", input: "abcd", pops: 2, expected: 0.5},
+		{name: "end", input: "abcd", pops: 4, expected: 1},
+		{name: "past end", input: "abcd", pops: 10, expected: 1},
+		{name: "multi-byte runes", input: "αβ", pops: 1, expected: 0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			for range tt.pops {
+				scanner.Pop()
+			}
+
+			if result := scanner.Progress(); result != tt.expected {
+				t.Errorf("Progress() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestScannerWithProgress(t *testing.T) {
+	var reported []float64
+	scanner := NewScanner("abcdefgh", WithProgress(3, func(progress float64) {
+		reported = append(reported, progress)
+	}))
+
+	scanner.Peek()
+	scanner.Pop()
+	scanner.Pop()
+	saved := scanner.Pos()
+	scanner.Pop()
+	scanner.Pop()
+	// backtracking must not count runes again
+	scanner.SetPos(saved)
+	for scanner.Pop() != EOF {
+	}
+
+	expected := []float64{3.0 / 8, 6.0 / 8}
+	if len(reported) != len(expected) {
+		t.Fatalf("expected %d reports, got %d: %v", len(expected), len(reported), reported)
+	}
+	for i := range expected {
+		if reported[i] != expected[i] {
+			t.Errorf("report %d: expected %v, got %v", i, expected[i], reported[i])
+		}
+	}
+}
+
+func TestScannerWithProgressDisabled(t *testing.T) {
+	called := false
+	scanner := NewScanner("abc", WithProgress(0, func(float64) { called = true }))
+	for scanner.Pop() != EOF {
+	}
+
+	if called {
+		t.Errorf("expected callback not to be called")
+	}
+}
+
+func TestScannerWithProgressCopies(t *testing.T) {
+	calls := 0
+	scanner := NewScanner("ab\n\"cd\"\nef", WithProgress(1, func(float64) { calls++ }))
+
+	for range scanner.Lines() {
+	}
+	if calls != 0 {
+		t.Errorf("Lines() invoked the callback %d times, expected 0", calls)
+	}
+
+	rules := testRules()
+	rules[2] = rules[2].Embed(testRules()...)
+	for range NewTokenizer(scanner, rules...).Tokens() {
+	}
+	if runes := len([]rune(scanner.Text())); calls != runes {
+		t.Errorf("tokenizing with an embedded region invoked the callback %d times, expected once per rune (%d)", calls, runes)
+	}
+}
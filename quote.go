@@ -0,0 +1,193 @@
+package scanner
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalidString is returned when the text at the scanner position is not a well-formed string literal.
+var ErrInvalidString = errors.New("invalid string literal")
+
+// A QuoteOption configures how Scanner.ReadQuotedString reads a string literal.
+type QuoteOption func(*quoteOptions)
+
+// quoteOptions holds the configuration of Scanner.ReadQuotedString.
+type quoteOptions struct {
+	allowNewlines bool
+	raw           bool
+}
+
+// QuoteAllowNewlines allows string literals to contain unescaped line breaks, which are an error by default.
+func QuoteAllowNewlines() QuoteOption {
+	return func(options *quoteOptions) {
+		options.allowNewlines = true
+	}
+}
+
+// QuoteRaw makes backslashes ordinary runes within string literals, so escape sequences are not decoded
+// and the literal ends at the first closing quote.
+func QuoteRaw() QuoteOption {
+	return func(options *quoteOptions) {
+		options.raw = true
+	}
+}
+
+// simpleEscapes maps the runes following a backslash to the rune the escape sequence stands for.
+var simpleEscapes = map[rune]rune{
+	'a': '\a', 'b': '\b', 'f': '\f', 'n': '\n', 'r': '\r', 't': '\t', 'v': '\v',
+	'\\': '\\', '\'': '\'', '"': '"',
+}
+
+// ReadQuotedString consumes a string literal enclosed in quote starting at the current scanner position
+// and returns its decoded content along with the span of the whole literal including the quotes.
+// Unless configured with QuoteRaw, an escaped quote stands for the quote and the other escape sequences are decoded
+// like by Scanner.ReadEscapeSequence, e.g. \n, \x41, \u00e4, \u{1F600} and \101. Like there, \x and octal escapes stand for
+// the rune with the given value, so "\xFF" is decoded to U+00FF rather than the single byte 0xFF.
+// Line breaks are normalized and escaped line breaks are skipped the same way Scanner.Pop does.
+// If the position does not start with quote, the literal is unterminated, contains an invalid escape sequence
+// or an unescaped line break (unless configured with QuoteAllowNewlines), an error wrapping ErrInvalidString is returned
+// along with the span of the offending part, and nothing is consumed.
+func (scanner *Scanner) ReadQuotedString(quote rune, opts ...QuoteOption) (string, Span, error) {
+	var options quoteOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	savedPos := scanner.TextPosition
+	savedComplex := scanner.isComplexSinceMark
+	fail := func(span Span, format string, args ...any) (string, Span, error) {
+		scanner.TextPosition = savedPos
+		scanner.isComplexSinceMark = savedComplex
+		return "", span, scanner.ErrorfAt(span, "%w: "+format, append([]any{ErrInvalidString}, args...)...)
+	}
+
+	start := scanner.TextPosition
+	if !scanner.Accept(quote) {
+		return fail(Span{Start: start, End: start}, "expected %q", quote)
+	}
+
+	var content strings.Builder
+	for {
+		span := scanner.PopSpan()
+		switch {
+		case span.Rune == EOF:
+			return fail(Span{Start: start, End: span.End}, "unterminated string")
+		case span.Rune == '\n' && !options.allowNewlines:
+			return fail(Span{Start: start, End: span.Start}, "unterminated string before line break")
+		case span.Rune == quote:
+			return content.String(), Span{Start: start, End: span.End}, nil
+		case span.Rune == '\\' && !options.raw:
+			if scanner.Accept(quote) {
+				content.WriteRune(quote)
+				continue
+			}
+			value, err := scanner.decodeEscape()
+			if err != nil {
+				escape := Span{Start: span.Start, End: scanner.TextPosition}
+				return fail(escape, "%v", err)
+			}
+			content.WriteRune(value)
+		default:
+			content.WriteRune(span.Rune)
+		}
+	}
+}
+
+// ReadDelimitedRaw consumes a raw literal enclosed in the open and close delimiters starting at the current scanner position,
+// such as a Go raw string (`...`) or a Lua long string ([[...]]), and returns its content along with the span of the whole literal.
+// The content is taken verbatim, except that CR and CRLF line breaks are normalized to LF like in Go raw strings;
+// backslashes, including those in front of line breaks, are kept as they are.
+// If the position does not start with open or the literal is unterminated, an error wrapping ErrInvalidString
+// is returned along with the span of the offending part, and nothing is consumed.
+func (scanner *Scanner) ReadDelimitedRaw(open, close string) (string, Span, error) {
+	start := scanner.TextPosition
+	if scanner.IsEOF() || !strings.HasPrefix(scanner.text[start.Offset:], open) {
+		return "", Span{Start: start, End: start}, scanner.Errorf("%w: expected %q", ErrInvalidString, open)
+	}
+
+	contentStart := start.Offset + len(open)
+	n := strings.Index(scanner.text[contentStart:], close)
+	if n < 0 {
+		end, _ := scanner.positionAfter(len(scanner.text))
+		span := Span{Start: start, End: end}
+		return "", span, scanner.ErrorfAt(span, "%w: unterminated raw string", ErrInvalidString)
+	}
+
+	end, err := scanner.positionAfter(contentStart + n + len(close))
+	if err != nil {
+		return "", Span{Start: start, End: start}, err
+	}
+	scanner.TextPosition = end
+	scanner.isComplexSinceMark = true
+	return normalizeBreaks(scanner.text[contentStart : contentStart+n]), Span{Start: start, End: end}, nil
+}
+
+// ReadHeredoc consumes the body of a here document starting at the current scanner position, which is usually
+// the beginning of the line after the one introducing the here document (e.g. "<<EOT"). The body ends with a line consisting only of tag.
+// It returns the content of the lines in front of the terminating line and the span from the current position to the end of the tag.
+// The content is taken verbatim like by Scanner.ReadDelimitedRaw and does not include the line break in front of the terminating line.
+// If no terminating line is found, an error wrapping ErrInvalidString is returned along with the span of the unterminated body,
+// and nothing is consumed.
+func (scanner *Scanner) ReadHeredoc(tag string) (string, Span, error) {
+	start := scanner.TextPosition
+	if _, err := scanner.positionAfter(start.Offset); err != nil {
+		return "", Span{Start: start, End: start}, err
+	}
+
+	for n := scanner.lineOf(start.Offset); n <= len(scanner.lineIndex()); n++ {
+		lineStart, lineEnd := scanner.lineBounds(n)
+		if lineStart < start.Offset || scanner.text[lineStart:lineEnd] != tag {
+			continue
+		}
+
+		// the line break in front of the terminating line is not part of the content
+		contentEnd := lineStart
+		if contentEnd > start.Offset {
+			contentEnd, _ = scanner.runeBefore(contentEnd)
+		}
+		// line boundaries are always valid
+		end, _ := scanner.positionAfter(lineEnd)
+		scanner.TextPosition = end
+		scanner.isComplexSinceMark = true
+		return normalizeBreaks(scanner.text[start.Offset:contentEnd]), Span{Start: start, End: end}, nil
+	}
+
+	end, _ := scanner.positionAfter(len(scanner.text))
+	span := Span{Start: start, End: end}
+	return "", span, scanner.ErrorfAt(span, "%w: unterminated here document, expected %q", ErrInvalidString, tag)
+}
+
+// normalizeBreaks normalizes CR and CRLF line breaks to LF, leaving escaped line breaks as they are.
+func normalizeBreaks(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.ReplaceAll(text, "\r", "\n")
+}
+
+// ReadUntilUnescaped consumes the runes up to, but not including, the first occurrence of delim that is not preceded by escape,
+// e.g. the body of a regular expression literal or a shell word, and returns the decoded text along with the span consumed.
+// An escape followed by delim or another escape stands for that rune, an escape followed by any other rune is kept as it is.
+// Line breaks are normalized and escaped line breaks are skipped the same way Scanner.Pop does.
+// If delim does not occur unescaped, the scanner advances to the end of the text.
+func (scanner *Scanner) ReadUntilUnescaped(delim, escape rune) (string, Span) {
+	start := scanner.TextPosition
+	var content strings.Builder
+	for {
+		savedPos := scanner.TextPosition
+		savedComplex := scanner.isComplexSinceMark
+
+		r := scanner.Pop()
+		switch r {
+		case EOF:
+			return content.String(), Span{Start: start, End: scanner.TextPosition}
+		case delim:
+			scanner.TextPosition = savedPos
+			scanner.isComplexSinceMark = savedComplex
+			return content.String(), Span{Start: start, End: scanner.TextPosition}
+		case escape:
+			if next := scanner.Peek(); next == delim || next == escape {
+				r = scanner.Pop()
+			}
+		}
+		content.WriteRune(r)
+	}
+}
@@ -0,0 +1,214 @@
+package scanner
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestScannerReadQuotedString(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		quote    rune
+		options  []QuoteOption
+		expected string
+		end      int
+	}{
+		{name: "simple", input: `"hello" x`, quote: '"', expected: "hello", end: 7},
+		{name: "empty", input: `""`, quote: '"', expected: "", end: 2},
+		{name: "single quotes", input: `'it''`, quote: '\'', expected: "it", end: 4},
+		{name: "simple escapes", input: `"a\tb\n\\\"c"`, quote: '"', expected: "a\tb\n\\\"c", end: 13},
+		{name: "escaped custom quote", input: "`a\\`b`", quote: '`', expected: "a`b", end: 6},
+		{name: "hex escape", input: `"\x41\x42"`, quote: '"', expected: "AB", end: 10},
+		{name: "octal escape", input: `"\101"`, quote: '"', expected: "A", end: 6},
+		{name: "hex escape above ASCII", input: `"\xFF"`, quote: '"', expected: "\u00ff", end: 6},
+		{name: "braced unicode escape", input: `"\u{1F600}\0"`, quote: '"', expected: "😀\x00", end: 13},
+		{name: "unicode escapes", input: `"\u00e4\U0001F600"`, quote: '"', expected: "ä😀", end: 18},
+		{name: "multi-byte content", input: `"世界"`, quote: '"', expected: "世界", end: 8},
+		{name: "escaped line break skipped", input: "\"ab\\\ncd\"", quote: '"', expected: "abcd", end: 8},
+		{name: "newline allowed", input: "\"a\r\nb\"", quote: '"', options: []QuoteOption{QuoteAllowNewlines()}, expected: "a\nb", end: 6},
+		{name: "raw", input: `'C:\dir\'`, quote: '\'', options: []QuoteOption{QuoteRaw()}, expected: `C:\dir\`, end: 9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			result, span, err := scanner.ReadQuotedString(tt.quote, tt.options...)
+			if err != nil {
+				t.Fatalf("ReadQuotedString() unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("ReadQuotedString() = %q, expected %q", result, tt.expected)
+			}
+			if span.Start.Offset != 0 || span.End.Offset != tt.end || scanner.Offset != tt.end {
+				t.Errorf("ReadQuotedString() span [%d, %d) at offset %d, expected [0, %d)", span.Start.Offset, span.End.Offset, scanner.Offset, tt.end)
+			}
+		})
+	}
+}
+
+func TestScannerReadQuotedStringErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		start, end int
+		message    string
+	}{
+		{name: "no quote", input: `abc`, start: 0, end: 0, message: `invalid string literal: expected '"' at 1:1`},
+		{name: "unterminated", input: `"abc`, start: 0, end: 4, message: `invalid string literal: unterminated string at 1:1`},
+		{name: "line break", input: "\"ab\ncd\"", start: 0, end: 3, message: `invalid string literal: unterminated string before line break at 1:1`},
+		{name: "unknown escape", input: `"a\qb"`, start: 2, end: 4, message: `invalid string literal: unknown escape sequence \q at 1:3`},
+		{name: "short hex escape", input: `"\x4"`, start: 1, end: 4, message: `invalid string literal: incomplete escape sequence \x at 1:2`},
+		{name: "invalid code point", input: `"\uD800"`, start: 1, end: 7, message: `invalid string literal: escape sequence is an invalid Unicode code point U+D800 at 1:2`},
+		{name: "octal overflow", input: `"\777"`, start: 1, end: 5, message: `invalid string literal: octal escape value 511 > 255 at 1:2`},
+		{name: "escape at EOF", input: `"\`, start: 1, end: 2, message: `invalid string literal: unterminated escape sequence at 1:2`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			_, span, err := scanner.ReadQuotedString('"')
+			if !errors.Is(err, ErrInvalidString) {
+				t.Fatalf("ReadQuotedString() error = %v, expected ErrInvalidString", err)
+			}
+			if err.Error() != tt.message {
+				t.Errorf("ReadQuotedString() error = %q, expected %q", err.Error(), tt.message)
+			}
+			if span.Start.Offset != tt.start || span.End.Offset != tt.end {
+				t.Errorf("ReadQuotedString() span [%d, %d), expected [%d, %d)", span.Start.Offset, span.End.Offset, tt.start, tt.end)
+			}
+			if scanner.Offset != 0 {
+				t.Errorf("expected nothing consumed on error, got offset %d", scanner.Offset)
+			}
+		})
+	}
+}
+
+func TestScannerReadDelimitedRaw(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		open, close string
+		expected    string
+		end         int
+		err         bool
+	}{
+		{name: "go raw string", input: "`a\\nb` x", open: "`", close: "`", expected: "a\\nb", end: 6},
+		{name: "multi-line", input: "[[a\r\nb\\\nc]]", open: "[[", close: "]]", expected: "a\nb\\\nc", end: 11},
+		{name: "empty", input: "``", open: "`", close: "`", expected: "", end: 2},
+		{name: "different delimiters", input: "r#\"x\"#", open: "r#\"", close: "\"#", expected: "x", end: 6},
+		{name: "no opening delimiter", input: "abc", open: "`", close: "`", err: true},
+		{name: "unterminated", input: "`abc", open: "`", close: "`", err: true, end: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			result, span, err := scanner.ReadDelimitedRaw(tt.open, tt.close)
+			if tt.err {
+				if !errors.Is(err, ErrInvalidString) {
+					t.Errorf("ReadDelimitedRaw() error = %v, expected ErrInvalidString", err)
+				}
+				if scanner.Offset != 0 {
+					t.Errorf("expected nothing consumed on error, got offset %d", scanner.Offset)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("ReadDelimitedRaw() unexpected error: %v", err)
+				}
+				if scanner.Offset != tt.end {
+					t.Errorf("expected offset %d, got %d", tt.end, scanner.Offset)
+				}
+			}
+			if result != tt.expected || span.End.Offset != tt.end {
+				t.Errorf("ReadDelimitedRaw() = %q ending at %d, expected %q ending at %d", result, span.End.Offset, tt.expected, tt.end)
+			}
+		})
+	}
+}
+
+func TestScannerReadDelimitedRawPosition(t *testing.T) {
+	scanner := NewScanner("`a\nbc` d")
+	if _, _, err := scanner.ReadDelimitedRaw("`", "`"); err != nil {
+		t.Fatalf("ReadDelimitedRaw() unexpected error: %v", err)
+	}
+	if scanner.Pos().String() != "2:4" {
+		t.Errorf("Pos() = %s, expected 2:4", scanner.Pos())
+	}
+	if r := scanner.Pop(); r != ' ' {
+		t.Errorf("Pop() = %q, expected ' '", r)
+	}
+}
+
+func TestScannerReadHeredoc(t *testing.T) {
+	input := "cat <<EOT\nline 1\r\n  EOT\nline \\\n3\nEOT\nrest"
+	scanner := NewScanner(input)
+	scanner.SkipUntil('\n')
+	scanner.Pop()
+
+	result, span, err := scanner.ReadHeredoc("EOT")
+	if err != nil {
+		t.Fatalf("ReadHeredoc() unexpected error: %v", err)
+	}
+	if expected := "line 1\n  EOT\nline \\\n3"; result != expected {
+		t.Errorf("ReadHeredoc() = %q, expected %q", result, expected)
+	}
+	if span.Start.String() != "2:1" || span.End.String() != "6:4" {
+		t.Errorf("ReadHeredoc() span = %s-%s, expected 2:1-6:4", span.Start, span.End)
+	}
+	if line, _ := scanner.ReadLine(); line.Text != "" {
+		t.Errorf("rest of terminating line = %q, expected empty", line.Text)
+	}
+	if line, _ := scanner.ReadLine(); line.Text != "rest" {
+		t.Errorf("next line = %q, expected %q", line.Text, "rest")
+	}
+}
+
+func TestScannerReadHeredocEdgeCases(t *testing.T) {
+	scanner := NewScanner("END\nx")
+	result, span, err := scanner.ReadHeredoc("END")
+	if err != nil || result != "" || span.End.Offset != 3 {
+		t.Errorf("ReadHeredoc() on empty body = %q ending at %d, %v, expected empty ending at 3", result, span.End.Offset, err)
+	}
+
+	scanner = NewScanner("a\nENDING\n")
+	if _, _, err := scanner.ReadHeredoc("END"); !errors.Is(err, ErrInvalidString) {
+		t.Errorf("ReadHeredoc() error = %v, expected ErrInvalidString", err)
+	}
+	if scanner.Offset != 0 {
+		t.Errorf("expected nothing consumed on error, got offset %d", scanner.Offset)
+	}
+}
+
+func TestScannerReadUntilUnescaped(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		delim    rune
+		escape   rune
+		expected string
+		end      int
+	}{
+		{name: "simple", input: "abc/def", delim: '/', escape: '\\', expected: "abc", end: 3},
+		{name: "escaped delimiter", input: `a\/b/`, delim: '/', escape: '\\', expected: "a/b", end: 4},
+		{name: "escaped escape", input: `a\\/b`, delim: '/', escape: '\\', expected: `a\`, end: 3},
+		{name: "other escapes kept", input: `\d+/`, delim: '/', escape: '\\', expected: `\d+`, end: 3},
+		{name: "custom escape", input: "it^'s'", delim: '\'', escape: '^', expected: "it's", end: 5},
+		{name: "immediate delimiter", input: "/x", delim: '/', escape: '\\', expected: "", end: 0},
+		{name: "no delimiter", input: "a\r\nb", delim: '/', escape: '\\', expected: "a\nb", end: 4},
+		{name: "escape at EOF", input: `ab\`, delim: '/', escape: '\\', expected: `ab\`, end: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			result, span := scanner.ReadUntilUnescaped(tt.delim, tt.escape)
+			if result != tt.expected {
+				t.Errorf("ReadUntilUnescaped() = %q, expected %q", result, tt.expected)
+			}
+			if span.Start.Offset != 0 || span.End.Offset != tt.end || scanner.Offset != tt.end {
+				t.Errorf("ReadUntilUnescaped() span [%d, %d) at offset %d, expected [0, %d)", span.Start.Offset, span.End.Offset, scanner.Offset, tt.end)
+			}
+		})
+	}
+}
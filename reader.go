@@ -0,0 +1,252 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// ErrLookaheadExceeded is returned when a ReaderScanner is asked to return to input it has already dropped from its lookahead window.
+var ErrLookaheadExceeded = errors.New("lookahead window exceeded")
+
+// readSize is the minimum number of bytes a ReaderScanner requests from its reader at once.
+const readSize = 4096
+
+// A ReaderOption configures a ReaderScanner.
+type ReaderOption func(*readerOptions)
+
+// readerOptions holds the configuration of a ReaderScanner.
+type readerOptions struct {
+	lookahead int
+	scanner   []Option
+}
+
+// WithLookahead bounds the input a ReaderScanner retains to the last n bytes in front of the furthest position it has reached,
+// so that scanning a large input does not keep all of it in memory. Marks and checkpoints further back than that can no longer
+// be returned to, see ReaderScanner.Slice and ReaderScanner.Restore. A non-positive n retains the whole input, which is the default.
+func WithLookahead(n int) ReaderOption {
+	return func(options *readerOptions) {
+		options.lookahead = n
+	}
+}
+
+// WithScannerOptions configures how a ReaderScanner tracks positions like the given options configure a Scanner,
+// e.g. WithFilename, WithColumnMode or WithRuneIndex. WithProgress has no effect, as the length of the input is not known in advance.
+func WithScannerOptions(options ...Option) ReaderOption {
+	return func(readerOptions *readerOptions) {
+		readerOptions.scanner = append(readerOptions.scanner, options...)
+	}
+}
+
+// ReaderScanner scans Unicode text read from an io.Reader and tracks line/column information.
+// It yields the same runes and positions as a Scanner does for the whole input, while reading the input only as far as needed.
+type ReaderScanner struct {
+	// scanner scans the window of the retained input from the current position on, its offsets are relative to window
+	scanner   *Scanner
+	reader    io.Reader
+	buffer    []byte // the retained input
+	base      int    // offset of the first retained byte within the input
+	window    int    // offset of the first byte of the text of scanner within the input
+	lookahead int    // set by WithLookahead
+	err       error  // the error that ended reading, io.EOF at the end of the input
+	markedPos TextPosition
+}
+
+// NewReaderScanner creates a new scanner reading its text from the given reader, initialized to the TextPosition at index 0.
+func NewReaderScanner(reader io.Reader, options ...ReaderOption) *ReaderScanner {
+	var readerOptions readerOptions
+	for _, option := range options {
+		option(&readerOptions)
+	}
+
+	scanner := newScanner("", nil, readerOptions.scanner)
+	scanner.progressCallback = nil
+	return &ReaderScanner{scanner: scanner, reader: reader, lookahead: readerOptions.lookahead, markedPos: scanner.TextPosition}
+}
+
+// Err returns the error the reader failed with, or nil if the input was read without errors so far.
+// A failing reader ends the input, so the ReaderScanner returns EOF after the runes read up to the error.
+func (reader *ReaderScanner) Err() error {
+	if reader.err == io.EOF {
+		return nil
+	}
+	return reader.err
+}
+
+// fill reads from the reader until the retained input extends to the given offset or the input ends,
+// and reports whether anything was read.
+func (reader *ReaderScanner) fill(end int) bool {
+	read := false
+	for empty := 0; reader.base+len(reader.buffer) < end && reader.err == nil; {
+		reader.buffer = slices.Grow(reader.buffer, readSize)
+		n, err := reader.reader.Read(reader.buffer[len(reader.buffer):cap(reader.buffer)])
+		reader.buffer = reader.buffer[:len(reader.buffer)+n]
+		reader.err = err
+
+		if empty++; n > 0 {
+			empty, read = 0, true
+		} else if empty >= 100 && err == nil {
+			reader.err = io.ErrNoProgress
+		}
+	}
+	return read
+}
+
+// ensure reads enough input for Scanner.Pop to consume the next rune, including any escaped line breaks in front of it.
+func (reader *ReaderScanner) ensure() {
+	current := reader.Pos().Offset
+	read := false
+	for offset := current; ; {
+		// an escaped CRLF line break and a rune of up to 4 bytes
+		if reader.fill(offset + 7) {
+			read = true
+		}
+
+		rest := reader.buffer[min(offset-reader.base, len(reader.buffer)):]
+		switch {
+		case len(rest) >= 3 && string(rest[:3]) == "\\\r\n":
+			offset += 3
+		case len(rest) >= 2 && (string(rest[:2]) == "\\\n" || string(rest[:2]) == "\\\r"):
+			offset += 2
+		default:
+			if read {
+				reader.slide(reader.scanner.TextPosition, current)
+			}
+			return
+		}
+	}
+}
+
+// slide moves the window scanned by the Scanner to start at the given offset, which must be retained,
+// and sets the Scanner to the given position within the window. Only the window is copied into the text of the Scanner,
+// so that reading more input does not copy all of the retained input again.
+func (reader *ReaderScanner) slide(pos TextPosition, offset int) {
+	reader.scanner.text = string(reader.buffer[offset-reader.base:])
+	reader.scanner.TextPosition = pos
+	reader.scanner.Offset = 0
+	reader.window = offset
+}
+
+// retained returns an error wrapping ErrLookaheadExceeded if the given offset has been dropped from the lookahead window.
+func (reader *ReaderScanner) retained(offset int) error {
+	if offset < reader.base {
+		return fmt.Errorf("%w: offset %d is more than %d bytes behind the furthest offset %d",
+			ErrLookaheadExceeded, offset, reader.lookahead, reader.base+reader.lookahead)
+	}
+	return nil
+}
+
+// compact drops the input in front of the lookahead window.
+func (reader *ReaderScanner) compact() {
+	drop := reader.Pos().Offset - reader.lookahead - reader.base
+	if reader.lookahead <= 0 || drop <= 0 {
+		return
+	}
+
+	reader.buffer = reader.buffer[drop:]
+	reader.base += drop
+}
+
+// Pos returns the TextPosition the reader scanner is currently at. Its offset is counted from the beginning of the input.
+func (reader *ReaderScanner) Pos() TextPosition {
+	pos := reader.scanner.TextPosition
+	pos.Offset += reader.window
+	return pos
+}
+
+// IsEOF returns whether the reader scanner has moved past the end of the input.
+func (reader *ReaderScanner) IsEOF() bool {
+	reader.ensure()
+	return reader.scanner.IsEOF()
+}
+
+// Pop returns the rune at the current position and advances the position to the next rune.
+// If the current position is past the end of the input, EOF is returned.
+// All line breaks (CR, LF and CRLF) are normalized to LF and escaped line breaks are skipped, see Scanner.Pop.
+func (reader *ReaderScanner) Pop() rune {
+	reader.ensure()
+	r := reader.scanner.Pop()
+	reader.compact()
+	return r
+}
+
+// PopSpan returns the RuneSpan at the current position and advances the position to the next rune.
+// If the current position is past the end of the input, EOF is returned.
+// All line breaks (CR, LF and CRLF) are normalized to LF and escaped line breaks are skipped, see Scanner.PopSpan.
+func (reader *ReaderScanner) PopSpan() RuneSpan {
+	start := reader.Pos()
+	r := reader.Pop()
+	return newRuneSpan(r, start, reader.Pos())
+}
+
+// Peek returns the rune at the current position without advancing.
+// If the current position is past the end of the input, EOF is returned.
+func (reader *ReaderScanner) Peek() rune {
+	reader.ensure()
+	return reader.scanner.Peek()
+}
+
+// PeekSpan returns the RuneSpan at the current position without advancing.
+// If the current position is past the end of the input, EOF is returned.
+func (reader *ReaderScanner) PeekSpan() RuneSpan {
+	reader.ensure()
+	span := reader.scanner.PeekSpan()
+	span.Start.Offset += reader.window
+	span.End.Offset += reader.window
+	return newRuneSpan(span.Rune, span.Start, span.End)
+}
+
+// Mark marks the current position to be the start of the next ReaderScanner.Slice call.
+func (reader *ReaderScanner) Mark() {
+	reader.markedPos = reader.Pos()
+}
+
+// Marked returns the TextPosition that was last marked using ReaderScanner.Mark.
+func (reader *ReaderScanner) Marked() TextPosition {
+	return reader.markedPos
+}
+
+// Slice returns the text scanned since the last call to ReaderScanner.Mark.
+// Line breaks are normalized and escaped line breaks are skipped the same way Scanner.Slice does.
+// If the mark has been dropped from the lookahead window (see WithLookahead), an error wrapping ErrLookaheadExceeded is returned.
+func (reader *ReaderScanner) Slice() (string, error) {
+	start, end := reader.markedPos.Offset, reader.Pos().Offset
+	if err := reader.retained(start); err != nil {
+		return "", err
+	}
+	if start > end {
+		return "", nil
+	}
+	return normalize(string(reader.buffer[start-reader.base : end-reader.base])), nil
+}
+
+// A ReaderCheckpoint is a saved state of a ReaderScanner, created by ReaderScanner.Save.
+type ReaderCheckpoint struct {
+	reader    *ReaderScanner
+	pos       TextPosition
+	markedPos TextPosition
+}
+
+// Save returns a ReaderCheckpoint capturing the current position and mark of the ReaderScanner, to be restored with ReaderScanner.Restore.
+func (reader *ReaderScanner) Save() ReaderCheckpoint {
+	return ReaderCheckpoint{reader: reader, pos: reader.Pos(), markedPos: reader.markedPos}
+}
+
+// Restore resets the position and mark of the ReaderScanner to the state captured by the given ReaderCheckpoint,
+// so that the runes scanned since are returned again. A checkpoint can be restored any number of times.
+// If its position has been dropped from the lookahead window (see WithLookahead), an error wrapping ErrLookaheadExceeded
+// is returned and the state is left unchanged.
+// Restore panics if the checkpoint was not created by Save of the same reader scanner.
+func (reader *ReaderScanner) Restore(checkpoint ReaderCheckpoint) error {
+	if checkpoint.reader != reader {
+		panic("scanner: restore of foreign ReaderCheckpoint")
+	}
+	if err := reader.retained(checkpoint.pos.Offset); err != nil {
+		return err
+	}
+
+	reader.slide(checkpoint.pos, checkpoint.pos.Offset)
+	reader.markedPos = checkpoint.markedPos
+	return nil
+}
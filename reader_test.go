@@ -0,0 +1,162 @@
+package scanner
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestReaderScannerMatchesScanner(t *testing.T) {
+	inputs := []string{
+		"",
+		"abc",
+		"a\nb\rc\r\nd",
+		"αβ\n世界",
+		"a\\\nb\\\r\nc\\\\\nd\\",
+		"x\\\n\\\n\\\ny",
+		"\n\n\r\r\n",
+	}
+
+	for _, input := range inputs {
+		for _, lookahead := range []int{0, 1, 3} {
+			scanner := NewScanner(input, WithRuneIndex())
+			// reading one byte at a time splits runes and line breaks across reads
+			reader := NewReaderScanner(iotest.OneByteReader(strings.NewReader(input)), WithScannerOptions(WithRuneIndex()), WithLookahead(lookahead))
+			for {
+				expected := scanner.PopSpan()
+				if span := reader.PopSpan(); span != expected {
+					t.Fatalf("input %q, lookahead %d: PopSpan() = %+v, expected %+v", input, lookahead, span, expected)
+				}
+				if expected.Rune == EOF {
+					break
+				}
+			}
+			if !reader.IsEOF() {
+				t.Errorf("input %q, lookahead %d: IsEOF() = false after popping all runes", input, lookahead)
+			}
+		}
+	}
+}
+
+func TestReaderScannerPeek(t *testing.T) {
+	reader := NewReaderScanner(strings.NewReader("a\\\nb"))
+	if r := reader.Peek(); r != 'a' {
+		t.Errorf("Peek() = %q, expected 'a'", r)
+	}
+	reader.Pop()
+
+	span := reader.PeekSpan()
+	expected := newRuneSpan('b', TextPosition{Offset: 1, Line: 1, Col: 2}, TextPosition{Offset: 4, Line: 2, Col: 2})
+	if span != expected {
+		t.Errorf("PeekSpan() = %+v, expected %+v", span, expected)
+	}
+	if pos := reader.Pos(); pos.Offset != 1 {
+		t.Errorf("Peek advanced the position to %+v", pos)
+	}
+}
+
+func TestReaderScannerSlice(t *testing.T) {
+	tests := []struct {
+		name      string
+		lookahead int
+		pops      int
+		expected  string
+		err       error
+	}{
+		{name: "unbounded", lookahead: 0, pops: 6, expected: "bc\nde"},
+		{name: "within the window", lookahead: 6, pops: 6, expected: "bc\nde"},
+		{name: "beyond the window", lookahead: 5, pops: 6, err: ErrLookaheadExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := NewReaderScanner(strings.NewReader("abc\r\ndef"), WithLookahead(tt.lookahead))
+			reader.Pop()
+			reader.Mark()
+			for range tt.pops - 1 {
+				reader.Pop()
+			}
+
+			result, err := reader.Slice()
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Slice() error = %v, expected %v", err, tt.err)
+			}
+			if result != tt.expected {
+				t.Errorf("Slice() = %q, expected %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReaderScannerRestore(t *testing.T) {
+	reader := NewReaderScanner(strings.NewReader("abcdefgh"), WithLookahead(3))
+	reader.Pop()
+	checkpoint := reader.Save()
+
+	reader.Pop()
+	reader.Pop()
+	reader.Pop()
+	if err := reader.Restore(checkpoint); err != nil {
+		t.Fatalf("Restore() within the window: unexpected error: %v", err)
+	}
+	if r := reader.Pop(); r != 'b' {
+		t.Errorf("Pop() after Restore() = %q, expected 'b'", r)
+	}
+
+	for range 3 {
+		reader.Pop()
+	}
+	pos := reader.Pos()
+	if err := reader.Restore(checkpoint); !errors.Is(err, ErrLookaheadExceeded) {
+		t.Fatalf("Restore() beyond the window: expected ErrLookaheadExceeded, got %v", err)
+	}
+	if reader.Pos() != pos {
+		t.Errorf("position changed on error: expected %+v, got %+v", pos, reader.Pos())
+	}
+	if r := reader.Pop(); r != 'f' {
+		t.Errorf("Pop() after failed Restore() = %q, expected 'f'", r)
+	}
+}
+
+func TestReaderScannerRestoreForeign(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Restore() of a foreign checkpoint did not panic")
+		}
+	}()
+	checkpoint := NewReaderScanner(strings.NewReader("a")).Save()
+	NewReaderScanner(strings.NewReader("a")).Restore(checkpoint)
+}
+
+func TestReaderScannerErr(t *testing.T) {
+	failure := errors.New("failure")
+	reader := NewReaderScanner(io.MultiReader(strings.NewReader("ab"), iotest.ErrReader(failure)))
+
+	var result []rune
+	for r := reader.Pop(); r != EOF; r = reader.Pop() {
+		result = append(result, r)
+	}
+	if string(result) != "ab" {
+		t.Errorf("popped %q, expected %q", string(result), "ab")
+	}
+	if err := reader.Err(); !errors.Is(err, failure) {
+		t.Errorf("Err() = %v, expected %v", err, failure)
+	}
+
+	if err := NewReaderScanner(strings.NewReader("")).Err(); err != nil {
+		t.Errorf("Err() = %v before reading, expected nil", err)
+	}
+}
+
+func BenchmarkReaderScanner(b *testing.B) {
+	input := strings.Repeat("word \\\nline\r\n", 16*1024)
+	b.SetBytes(int64(len(input)))
+	for b.Loop() {
+		// reading one byte at a time is the worst case for appending to the retained input
+		reader := NewReaderScanner(iotest.OneByteReader(strings.NewReader(input)))
+		for reader.Pop() != EOF {
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package scanner
+
+import "regexp"
+
+// Match tries to match re at the current scanner position and, if it matches, consumes the match and returns it along with its span.
+// The expression is matched against the raw text following the position, so line breaks are not normalized
+// and escaped line breaks are not skipped, and it only matches if the match starts right at the position.
+// Assertions like \b or ^ at the start of the expression only see the text from the position on.
+// If re does not match, nothing is consumed and false is returned along with an empty string and an empty span.
+func (scanner *Scanner) Match(re *regexp.Regexp) (string, Span, bool) {
+	start := scanner.TextPosition
+	if start.Offset < 0 || start.Offset > len(scanner.text) {
+		return "", Span{Start: start, End: start}, false
+	}
+
+	loc := scanner.anchoredRegexp(re).FindStringIndex(scanner.text[start.Offset:])
+	if loc == nil {
+		return "", Span{Start: start, End: start}, false
+	}
+
+	end, err := scanner.positionAfter(start.Offset + loc[1])
+	if err != nil {
+		// the match ends between the CR and LF of a line break
+		return "", Span{Start: start, End: start}, false
+	}
+	scanner.TextPosition = end
+	scanner.isComplexSinceMark = true
+	return scanner.text[start.Offset:end.Offset], Span{Start: start, End: end}, true
+}
+
+// anchoredRegexp returns a version of re that only matches at the beginning of the text.
+// The anchored expressions are compiled on first use and cached.
+func (scanner *Scanner) anchoredRegexp(re *regexp.Regexp) *regexp.Regexp {
+	if anchored, ok := scanner.anchored[re]; ok {
+		return anchored
+	}
+	if scanner.anchored == nil {
+		scanner.anchored = make(map[*regexp.Regexp]*regexp.Regexp)
+	}
+
+	// re is valid, so is the anchored expression
+	anchored := regexp.MustCompile(`\A(?:` + re.String() + `)`)
+	scanner.anchored[re] = anchored
+	return anchored
+}
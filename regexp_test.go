@@ -0,0 +1,74 @@
+package scanner
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestScannerMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		skip     int
+		pattern  string
+		expected string
+		ok       bool
+		end      string
+	}{
+		{name: "at start", input: "abc123", pattern: `[a-z]+`, expected: "abc", ok: true, end: "1:4"},
+		{name: "not anchored elsewhere", input: "  abc", pattern: `[a-z]+`, expected: "", ok: false, end: "1:1"},
+		{name: "after skip", input: "x = 0x1F;", skip: 4, pattern: `0x[0-9A-F]+`, expected: "0x1F", ok: true, end: "1:9"},
+		{name: "alternation anchored as a whole", input: "ba", pattern: `a|b`, expected: "b", ok: true, end: "1:2"},
+		{name: "across line breaks", input: "/* a\r\nb */c", pattern: `(?s)/\*.*?\*/`, expected: "/* a\r\nb */", ok: true, end: "2:5"},
+		{name: "empty match", input: "abc", pattern: `\d*`, expected: "", ok: true, end: "1:1"},
+		{name: "multi-byte", input: "äöüx", pattern: `\pL+`, expected: "äöüx", ok: true, end: "1:5"},
+		{name: "ends inside CRLF", input: "a\r\nb", pattern: `a\r`, expected: "", ok: false, end: "1:1"},
+		{name: "at EOF", input: "ab", skip: 2, pattern: `$`, expected: "", ok: true, end: "1:3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			scanner.PopN(tt.skip)
+			start := scanner.Pos()
+
+			result, span, ok := scanner.Match(regexp.MustCompile(tt.pattern))
+			if result != tt.expected || ok != tt.ok {
+				t.Errorf("Match(%s) = %q, %v, expected %q, %v", tt.pattern, result, ok, tt.expected, tt.ok)
+			}
+			if span.Start != start || scanner.Pos().String() != tt.end || span.End != scanner.Pos() {
+				t.Errorf("Match(%s) span = %s-%s at %s, expected %s-%s", tt.pattern, span.Start, span.End, scanner.Pos(), start, tt.end)
+			}
+		})
+	}
+}
+
+func TestScannerMatchReusesAnchoredRegexp(t *testing.T) {
+	scanner := NewScanner("a1b2")
+	letter, digit := regexp.MustCompile(`[a-z]`), regexp.MustCompile(`\d`)
+
+	for range 2 {
+		if _, _, ok := scanner.Match(letter); !ok {
+			t.Fatalf("Match(letter) = false at %s", scanner.Pos())
+		}
+		if _, _, ok := scanner.Match(digit); !ok {
+			t.Fatalf("Match(digit) = false at %s", scanner.Pos())
+		}
+	}
+	if len(scanner.anchored) != 2 {
+		t.Errorf("expected 2 cached expressions, got %d", len(scanner.anchored))
+	}
+}
+
+func BenchmarkScannerMatchLongLine(b *testing.B) {
+	// matching a single long line piece by piece must not rescan the line for every match
+	input := strings.Repeat("word ", 16*1024)
+	re := regexp.MustCompile(`\w+ `)
+	b.SetBytes(int64(len(input)))
+	for b.Loop() {
+		scanner := NewScanner(input)
+		for _, _, ok := scanner.Match(re); ok; _, _, ok = scanner.Match(re) {
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package scanner
+
+// ReverseScanner scans Unicode text from its end to its beginning and tracks line/column information.
+// It yields the same runes and positions as a Scanner does, only in reverse order.
+type ReverseScanner struct {
+	scanner   *Scanner
+	markedPos TextPosition
+}
+
+// NewReverseScanner creates a new reverse scanner for the given piece of text initialized to the TextPosition at the end of the text.
+func NewReverseScanner(text string, options ...Option) *ReverseScanner {
+	scanner := newScanner(text, nil, options)
+	// the end of the text is always a valid position
+	end, _ := scanner.positionOf(len(text))
+	scanner.TextPosition = end
+	scanner.markedPos = end
+	return &ReverseScanner{scanner: scanner, markedPos: end}
+}
+
+// Text returns the text set in the ReverseScanner.
+func (reverse *ReverseScanner) Text() string {
+	return reverse.scanner.text
+}
+
+// Pos returns the TextPosition the reverse scanner is currently at, that is the position after the rune returned by the next Pop.
+func (reverse *ReverseScanner) Pos() TextPosition {
+	return reverse.scanner.TextPosition
+}
+
+// SetPos hard sets the ReverseScanner to be at the given TextPosition.
+func (reverse *ReverseScanner) SetPos(pos TextPosition) {
+	reverse.scanner.TextPosition = pos
+}
+
+// IsEOF returns whether the reverse scanner has moved back to the beginning of the input.
+// Positions past the end of the input also count as EOF.
+func (reverse *ReverseScanner) IsEOF() bool {
+	return reverse.scanner.Offset <= 0 || reverse.scanner.Offset > len(reverse.scanner.text)
+}
+
+// Pop returns the rune in front of the current position and moves the position back to the start of that rune.
+// If the current position is at the beginning of the text, EOF is returned.
+// All line breaks (CR, LF and CRLF) are normalized to LF and escaped line breaks are skipped, see Scanner.Prev.
+func (reverse *ReverseScanner) Pop() rune {
+	return reverse.scanner.Prev()
+}
+
+// PopSpan returns the RuneSpan in front of the current position and moves the position back to the start of that rune.
+// If the current position is at the beginning of the text, EOF is returned.
+// All line breaks (CR, LF and CRLF) are normalized to LF and escaped line breaks are skipped, see Scanner.PrevSpan.
+func (reverse *ReverseScanner) PopSpan() RuneSpan {
+	return reverse.scanner.PrevSpan()
+}
+
+// Peek returns the rune in front of the current position without moving.
+// If the current position is at the beginning of the text, EOF is returned.
+func (reverse *ReverseScanner) Peek() rune {
+	return reverse.scanner.PeekBack()
+}
+
+// PeekSpan returns the RuneSpan in front of the current position without moving.
+// If the current position is at the beginning of the text, EOF is returned.
+func (reverse *ReverseScanner) PeekSpan() RuneSpan {
+	return reverse.scanner.PeekBackSpan()
+}
+
+// Mark marks the current position to be the end of the next ReverseScanner.Slice call.
+func (reverse *ReverseScanner) Mark() {
+	reverse.markedPos = reverse.scanner.TextPosition
+}
+
+// Marked returns the TextPosition that was last marked using ReverseScanner.Mark.
+func (reverse *ReverseScanner) Marked() TextPosition {
+	return reverse.markedPos
+}
+
+// Slice returns the text scanned since the last call to ReverseScanner.Mark, in its original (forward) order.
+// Line breaks are normalized and escaped line breaks are skipped the same way Scanner.Slice does.
+func (reverse *ReverseScanner) Slice() string {
+	return reverse.scanner.SliceBetween(reverse.scanner.TextPosition, reverse.markedPos)
+}
@@ -0,0 +1,92 @@
+package scanner
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestReverseScannerPop(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []rune
+	}{
+		{name: "empty", input: "", expected: nil},
+		{name: "ascii", input: "abc", expected: []rune{'c', 'b', 'a'}},
+		{name: "multi-byte", input: "aä世", expected: []rune{'世', 'ä', 'a'}},
+		{name: "CRLF", input: "a\r\nb", expected: []rune{'b', '\n', 'a'}},
+		{name: "CR", input: "a\rb", expected: []rune{'b', '\n', 'a'}},
+		{name: "escaped line break", input: "a\\\nb", expected: []rune{'b', 'a'}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reverse := NewReverseScanner(tt.input)
+			var result []rune
+			for r := reverse.Pop(); r != EOF; r = reverse.Pop() {
+				result = append(result, r)
+			}
+
+			if !slices.Equal(result, tt.expected) {
+				t.Errorf("Pop() sequence = %q, expected %q", result, tt.expected)
+			}
+			if !reverse.IsEOF() {
+				t.Errorf("IsEOF() = false after popping all runes")
+			}
+		})
+	}
+}
+
+func TestReverseScannerMatchesForward(t *testing.T) {
+	input := "func f() {\r\n\tx := \"ä\\\n世\"\n}\n"
+	var forward []RuneSpan
+	ForEach(input, func(span RuneSpan) bool {
+		forward = append(forward, span)
+		return true
+	})
+
+	reverse := NewReverseScanner(input)
+	for i := len(forward) - 1; i >= 0; i-- {
+		span := reverse.PopSpan()
+		if span != forward[i] {
+			t.Fatalf("PopSpan() = %+v (%s-%s), expected %+v (%s-%s)", span, span.Start, span.End, forward[i], forward[i].Start, forward[i].End)
+		}
+	}
+	if span := reverse.PopSpan(); span.Rune != EOF {
+		t.Errorf("PopSpan() = %q, expected EOF", span.Rune)
+	}
+}
+
+func TestReverseScannerMatchingBrace(t *testing.T) {
+	input := "if (a) {\n\tb({c})\n}"
+	reverse := NewReverseScanner(input)
+	if r := reverse.Pop(); r != '}' {
+		t.Fatalf("Pop() = %q, expected '}'", r)
+	}
+	reverse.Mark()
+
+	depth := 1
+	for depth > 0 {
+		switch reverse.Peek() {
+		case '}':
+			depth++
+		case '{':
+			depth--
+		case EOF:
+			t.Fatal("no matching brace found")
+		}
+		if depth > 0 {
+			reverse.Pop()
+		}
+	}
+
+	if reverse.Pos().Line != 1 || reverse.Pos().Col != 9 {
+		t.Errorf("Pos() = %s, expected 1:9", reverse.Pos())
+	}
+	if result, expected := reverse.Slice(), "\n\tb({c})\n"; result != expected {
+		t.Errorf("Slice() = %q, expected %q", result, expected)
+	}
+	if r := reverse.Peek(); r != '{' {
+		t.Errorf("Peek() = %q, expected '{'", r)
+	}
+}
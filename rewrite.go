@@ -0,0 +1,80 @@
+package scanner
+
+import "strings"
+
+// RewrittenTokens is the result of MapTokens and ReplaceTokens: a synthetic source made of rewritten tokens,
+// the tokens with their spans within it and a SourceMap mapping it back to the original text.
+type RewrittenTokens struct {
+	// Text is the synthetic source.
+	Text string
+	// Tokens are the rewritten tokens, with spans within Text. They carry no trivia, which stays part of Text.
+	Tokens []Token
+	// SourceMap maps spans of Text to the spans of the original text they come from, see WithSourceMap.
+	// Text copied from the original text maps rune by rune, the text of replaced tokens maps to the whole token they replace.
+	SourceMap *SourceMap
+}
+
+// MapTokens rewrites the given tokens of the text of the Scanner into a synthetic source, replacing each token by the result of fn,
+// e.g. to rename keywords. See ReplaceTokens.
+func MapTokens(scanner *Scanner, tokens []Token, fn func(Token) Token) RewrittenTokens {
+	return ReplaceTokens(scanner, tokens, func(token Token) []Token { return []Token{fn(token)} })
+}
+
+// ReplaceTokens rewrites the given tokens of the text of the Scanner into a synthetic source, replacing each token by the tokens
+// fn returns for it, none to delete it, e.g. to substitute macros. The text between the tokens, such as whitespace and comments,
+// is copied from the original text, starting at the first token or its leading trivia. Tokens replaced by themselves are copied
+// verbatim, the lexemes of other tokens are written in their place, separated by a space if fn returns several.
+// The spans of the resulting tokens are recomputed within the synthetic source, and a Scanner created for it
+// with WithSourceMap reports the original positions via Scanner.AdjustedPos.
+func ReplaceTokens(scanner *Scanner, tokens []Token, fn func(Token) []Token) RewrittenTokens {
+	var text strings.Builder
+	sourceMap := &SourceMap{}
+	var result []Token
+	pos := TextPosition{Line: scanner.lineBase, Col: scanner.colBase}
+	write := func(s string) Span {
+		start := pos
+		text.WriteString(s)
+		pos = AdvancePosition(pos, s, scanner.positionOptions()...)
+		return Span{Start: start, End: pos}
+	}
+
+	var cursor TextPosition
+	if len(tokens) > 0 {
+		cursor = tokens[0].Start
+		if len(tokens[0].Leading) > 0 {
+			cursor = tokens[0].Leading[0].Start
+		}
+	}
+	for _, token := range tokens {
+		if gap := (Span{Start: cursor, End: token.Start}); gap.Len() > 0 {
+			sourceMap.Add(write(scanner.RawTextOf(gap)), gap)
+		}
+		cursor = token.End
+
+		replacements := fn(token)
+		if len(replacements) == 1 && replacements[0].Lexeme == token.Lexeme {
+			replacement := replacements[0]
+			replacement.Span = write(scanner.RawTextOf(token.Span))
+			replacement.Leading, replacement.Trailing = nil, nil
+			if replacement.Len() > 0 {
+				sourceMap.Add(replacement.Span, token.Span)
+			}
+			result = append(result, replacement)
+			continue
+		}
+
+		for i, replacement := range replacements {
+			if i > 0 {
+				sourceMap.Add(write(" "), token.Span)
+			}
+			replacement.Span = write(replacement.Lexeme)
+			replacement.Leading, replacement.Trailing = nil, nil
+			if replacement.Len() > 0 {
+				sourceMap.Add(replacement.Span, token.Span)
+			}
+			result = append(result, replacement)
+		}
+	}
+
+	return RewrittenTokens{Text: text.String(), Tokens: result, SourceMap: sourceMap}
+}
@@ -0,0 +1,117 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+)
+
+// tokenize returns all tokens of the text of the scanner according to testRules, including the TokenEOF token, with trivia attached.
+func tokenize(scanner *Scanner) []Token {
+	tokenizer := NewTokenizer(scanner, testRules()...)
+	tokenizer.SetAttachTrivia(true)
+	var tokens []Token
+	for {
+		token, _ := tokenizer.Next()
+		tokens = append(tokens, token)
+		if token.Kind == TokenEOF {
+			return tokens
+		}
+	}
+}
+
+func TestReplaceTokens(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		replace  func(Token) []Token
+		expected string
+		tokens   []string
+		original []string // original start of each rewritten token
+	}{
+		{
+			name:  "macro",
+			input: "a <= MAX\r\nb",
+			replace: func(token Token) []Token {
+				if token.Lexeme != "MAX" {
+					return []Token{token}
+				}
+				return []Token{{Kind: testNumber, Lexeme: "1"}, {Kind: testOperator, Lexeme: "<"}, {Kind: testNumber, Lexeme: "2"}}
+			},
+			expected: "a <= 1 < 2\r\nb",
+			tokens:   []string{`Ident "a" at 1:1`, `Operator "<=" at 1:3`, `Number "1" at 1:6`, `Operator "<" at 1:8`, `Number "2" at 1:10`, `Ident "b" at 2:1`, `EOF "" at 2:2`},
+			original: []string{"1:1", "1:3", "1:6", "1:6", "1:6", "2:1", "2:2"},
+		},
+		{
+			name:  "delete",
+			input: "a  b c",
+			replace: func(token Token) []Token {
+				if token.Lexeme == "b" {
+					return nil
+				}
+				return []Token{token}
+			},
+			expected: "a   c",
+			tokens:   []string{`Ident "a" at 1:1`, `Ident "c" at 1:5`, `EOF "" at 1:6`},
+			original: []string{"1:1", "1:6", "1:7"},
+		},
+		{
+			name:     "leading trivia kept",
+			input:    "  x",
+			replace:  func(token Token) []Token { return []Token{token} },
+			expected: "  x",
+			tokens:   []string{`Ident "x" at 1:3`, `EOF "" at 1:4`},
+			original: []string{"1:3", "1:4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			rewritten := ReplaceTokens(scanner, tokenize(scanner), tt.replace)
+			if rewritten.Text != tt.expected {
+				t.Errorf("Text = %q, expected %q", rewritten.Text, tt.expected)
+			}
+
+			synthetic := NewScanner(rewritten.Text, WithSourceMap(rewritten.SourceMap))
+			var tokens, original []string
+			for _, token := range rewritten.Tokens {
+				tokens = append(tokens, token.String())
+				original = append(original, synthetic.AdjustedPos(token.Start).String())
+				if lexeme := synthetic.TextOf(token.Span); lexeme != token.Lexeme {
+					t.Errorf("token %s covers %q in the synthetic source", token, lexeme)
+				}
+			}
+			if strings.Join(tokens, ", ") != strings.Join(tt.tokens, ", ") {
+				t.Errorf("Tokens = %s\nexpected %s", strings.Join(tokens, ", "), strings.Join(tt.tokens, ", "))
+			}
+			if strings.Join(original, ", ") != strings.Join(tt.original, ", ") {
+				t.Errorf("original positions = %s, expected %s", strings.Join(original, ", "), strings.Join(tt.original, ", "))
+			}
+		})
+	}
+}
+
+func TestMapTokens(t *testing.T) {
+	scanner := NewScanner("let x = 1 // let\nlet y")
+	tokenizer := NewTokenizer(scanner, append([]TokenRule{FuncRule(testSpace, func(s *Scanner) bool {
+		if !s.ConsumeString("//") {
+			return false
+		}
+		s.SkipUntil('\n')
+		return true
+	}).Skipped()}, testRules()...)...)
+	var tokens []Token
+	for token := range tokenizer.Tokens() {
+		tokens = append(tokens, token)
+	}
+
+	rewritten := MapTokens(scanner, tokens, func(token Token) Token {
+		if token.Lexeme == "let" {
+			token.Lexeme = "var"
+		}
+		return token
+	})
+	if expected := "var x = 1 // let\nvar y"; rewritten.Text != expected {
+		t.Errorf("Text = %q, expected %q", rewritten.Text, expected)
+	}
+}
@@ -0,0 +1,51 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrTokenGap is returned when tokens do not cover their text without gaps or overlaps, see RoundTrip.
+var ErrTokenGap = errors.New("tokens do not cover the text contiguously")
+
+// RoundTrip reassembles the raw text of the given tokens of the text of the Scanner, i.e. the raw text of the leading trivia,
+// the token itself and the trailing trivia of each token in order, as returned by Scanner.RawTextOf.
+// A Tokenizer configured with Tokenizer.SetAttachTrivia is lossless: the tokens it produces from its start to the TokenEOF token,
+// including error tokens, inserted terminators and embedded regions, round-trip to its text from where it started byte for byte,
+// so tools such as formatters can rewrite tokens without corrupting the rest of the file.
+// Empty tokens, such as terminators, may appear anywhere. If the other tokens or trivia leave a gap or overlap,
+// e.g. because trivia was not attached, an error wrapping ErrTokenGap describing the position is returned along with the text up to there.
+func RoundTrip(scanner *Scanner, tokens []Token) (string, error) {
+	var text strings.Builder
+	started := false
+	var end TextPosition
+	add := func(token Token) error {
+		if token.Len() == 0 {
+			return nil
+		}
+		if started && token.Start.Offset != end.Offset {
+			return fmt.Errorf("%w: %s follows text ending at %s", ErrTokenGap, token, end)
+		}
+		text.WriteString(scanner.RawTextOf(token.Span))
+		started, end = true, token.End
+		return nil
+	}
+
+	for _, token := range tokens {
+		for _, trivia := range token.Leading {
+			if err := add(trivia); err != nil {
+				return text.String(), err
+			}
+		}
+		if err := add(token); err != nil {
+			return text.String(), err
+		}
+		for _, trivia := range token.Trailing {
+			if err := add(trivia); err != nil {
+				return text.String(), err
+			}
+		}
+	}
+	return text.String(), nil
+}
@@ -0,0 +1,101 @@
+package scanner
+
+import (
+	"errors"
+	"testing"
+	"unicode"
+)
+
+func TestRoundTrip(t *testing.T) {
+	inputs := []string{
+		"",
+		"   ",
+		"x <= 3.5\n\"a b\"<y",
+		"  a\r\n\tb \\\n c\r\rd  \n",
+		"a ?! b\n? c ??\n",
+		"\"unterminated\nx",
+		"a // comment\n// line\n\n  b /* c */\n",
+		"x = [1 2 ] y\n[]\n[ ]",
+		"é ü < 1\n",
+		"x { }\n{} y",
+	}
+	comment := FuncRule(testSpace, func(s *Scanner) bool {
+		if !s.ConsumeString("//") {
+			return false
+		}
+		s.SkipUntil('\n')
+		return true
+	}).Skipped()
+	block := FuncRule(testSpace, func(s *Scanner) bool {
+		if !s.ConsumeString("/*") {
+			return false
+		}
+		s.SkipUntilString("*/")
+		return s.ConsumeString("*/")
+	}).Skipped()
+	embedded := FuncRule(testString, func(s *Scanner) bool {
+		_, _, err := s.ReadBalanced('[', ']')
+		return err == nil
+	}).Embed(testRules()...)
+	// a region of trivia only
+	braces := FuncRule(testString, func(s *Scanner) bool {
+		_, _, err := s.ReadBalanced('{', '}')
+		return err == nil
+	}).Embed(PredicateRule(testSpace, func(r rune) bool { return r == '{' || r == '}' || unicode.IsSpace(r) }).Skipped())
+
+	configurations := []struct {
+		name      string
+		configure func(*Tokenizer)
+	}{
+		{name: "default", configure: func(*Tokenizer) {}},
+		{name: "resync line", configure: func(tokenizer *Tokenizer) { tokenizer.SetResync(ResyncLine) }},
+		{name: "resync whitespace", configure: func(tokenizer *Tokenizer) { tokenizer.SetResync(ResyncWhitespace) }},
+		{name: "terminators", configure: func(tokenizer *Tokenizer) {
+			tokenizer.SetTerminatorHook(func(Token) (TokenKind, bool) { return testOperator, true })
+		}},
+		{name: "first match", configure: func(tokenizer *Tokenizer) { tokenizer.SetFirstMatch(true) }},
+	}
+
+	for _, configuration := range configurations {
+		t.Run(configuration.name, func(t *testing.T) {
+			for _, input := range inputs {
+				scanner := NewScanner(input)
+				tokenizer := NewTokenizer(scanner, append([]TokenRule{comment, block, embedded, braces}, testRules()...)...)
+				tokenizer.SetAttachTrivia(true)
+				configuration.configure(tokenizer)
+
+				var tokens []Token
+				for {
+					token, _ := tokenizer.Next()
+					tokens = append(tokens, token)
+					if token.Kind == TokenEOF {
+						break
+					}
+				}
+
+				result, err := RoundTrip(scanner, tokens)
+				if err != nil || result != input {
+					t.Errorf("RoundTrip() of %q = %q, %v, expected the input", input, result, err)
+				}
+			}
+		})
+	}
+}
+
+func TestRoundTripGap(t *testing.T) {
+	scanner := NewScanner("a b c")
+	tokenizer := NewTokenizer(scanner, PredicateRule(testSpace, unicode.IsSpace).Skipped(), PredicateRule(testIdent, unicode.IsLetter))
+
+	var tokens []Token
+	for token := range tokenizer.Tokens() {
+		tokens = append(tokens, token)
+	}
+
+	result, err := RoundTrip(scanner, tokens)
+	if !errors.Is(err, ErrTokenGap) || result != "a" {
+		t.Errorf("RoundTrip() without trivia = %q, %v, expected \"a\" and an error wrapping ErrTokenGap", result, err)
+	}
+	if message := `tokens do not cover the text contiguously: Ident "b" at 1:3 follows text ending at 1:2`; err != nil && err.Error() != message {
+		t.Errorf("error = %q, expected %q", err.Error(), message)
+	}
+}
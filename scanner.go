@@ -2,6 +2,12 @@
 package scanner
 
 import (
+	"cmp"
+	"context"
+	"go/token"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"unicode/utf8"
 )
@@ -11,45 +17,204 @@ const EOF rune = -1
 
 // TextPosition represents a position within a piece of text (string).
 type TextPosition struct {
+	// Filename is the name of the source the text originates from. May be empty.
+	Filename string
 	// Offset is the offset in bytes from the beginning of the string.
 	Offset int
 	// Line is the line component of the position. Can also be seen as the number of line breaks since the beginning of the string plus one.
+	// Scanners configured with WithZeroBasedLines omit the plus one.
 	Line int
 	// Column is the column component of the position. Can also be seen as the number of runes since the last line break plus one.
+	// Scanners using a ColumnMode other than ColumnRunes count columns in the units of that mode instead.
+	// Scanners configured with WithZeroBasedColumns omit the plus one.
 	Col int
+	// RuneIdx is the number of runes from the beginning of the string, counting CRLF line breaks and escaped line breaks as they appear in the text.
+	// It is only tracked by Scanners configured with WithRuneIndex and is 0 otherwise.
+	RuneIdx int
+}
+
+// String returns the position formatted as "filename:line:col", or "line:col" if the position has no filename.
+func (pos TextPosition) String() string {
+	s := strconv.Itoa(pos.Line) + ":" + strconv.Itoa(pos.Col)
+	if pos.Filename != "" {
+		s = pos.Filename + ":" + s
+	}
+	return s
+}
+
+// Compare returns -1 if the position comes before the other position, +1 if it comes after it and 0 if both are at the same place.
+// Positions are ordered by filename first and byte offset second, so the result does not depend on how lines and columns are counted.
+func (pos TextPosition) Compare(other TextPosition) int {
+	if c := strings.Compare(pos.Filename, other.Filename); c != 0 {
+		return c
+	}
+	return cmp.Compare(pos.Offset, other.Offset)
+}
+
+// Before returns whether the position comes before the other position, see TextPosition.Compare.
+func (pos TextPosition) Before(other TextPosition) bool {
+	return pos.Compare(other) < 0
+}
+
+// After returns whether the position comes after the other position, see TextPosition.Compare.
+func (pos TextPosition) After(other TextPosition) bool {
+	return pos.Compare(other) > 0
 }
 
 // A RuneSpan represents a rune within text, including the matching positional data.
 type RuneSpan struct {
 	// Rune is the rune.
 	Rune rune
-	// Pos is the position the rune is at.
+	// Span is the range the rune covers. Its Start is the position the rune is at, its End the position after the rune.
+	Span
+	// Pos is the position the rune is at, the same as Span.Start.
+	//
+	// Deprecated: Use Span.Start instead.
 	Pos TextPosition
-	// End is the position after the rune.
-	End TextPosition
+}
+
+// newRuneSpan returns the RuneSpan of the given rune covering the text from start to end.
+func newRuneSpan(r rune, start, end TextPosition) RuneSpan {
+	return RuneSpan{Rune: r, Span: Span{Start: start, End: end}, Pos: start}
 }
 
 // Scanner scans Unicode text and tracks line/column information.
 type Scanner struct {
 	TextPosition
-	text string
+	text       string
+	filename   string
+	columnMode ColumnMode
+	lineBase   int           // number of the first line, 1 unless WithZeroBasedLines
+	colBase    int           // number of the first column, 1 unless WithZeroBasedColumns
+	shift      positionShift // set by NewScannerAt
+	runeIndex  bool
+	whitespace WhitespacePolicy
+	tabWidth   int  // set by WithTabWidth
+	errorLines bool // set by WithErrorLines
+
+	identStart    func(rune) bool // set by WithIdentifierRules
+	identContinue func(rune) bool // set by WithIdentifierRules
+
+	lineComments  []string       // set by WithLineComments
+	blockComments []blockComment // set by WithBlockComments
 
 	markedPos          TextPosition
-	isComplexSinceMark bool // true if can't be directly sliced
+	isComplexSinceMark bool                    // true if can't be directly sliced
+	namedMarks         map[string]TextPosition // set by MarkNamed
+	namedMarksShared   bool                    // namedMarks is captured by a saved state and must be copied before writing
+	markStack          []TextPosition          // set by PushMark
+
+	lineStarts     []int           // lazily built by lineIndex
+	lineRuneStarts []int           // lazily built by lineRuneIndex
+	tokenFile      *token.File     // set by AddToFileSet
+	lineDirectives []lineDirective // sorted by offset
+	sourceMap      *SourceMap      // set by WithSourceMap
+	memos          map[int]*Memo   // lazily created by Memo
+
+	anchored map[*regexp.Regexp]*regexp.Regexp // lazily built by Match
+
+	progressEvery    int                    // set by WithProgress
+	progressCallback func(progress float64) // set by WithProgress
+	progressRunes    int                    // runes consumed for the first time
+	furthestOffset   int                    // furthest offset consumed so far
+}
+
+// An Option configures a Scanner on creation.
+type Option func(*Scanner)
+
+// WithFilename sets the name of the source the scanned text originates from.
+// The name is included in every TextPosition produced by the Scanner.
+func WithFilename(filename string) Option {
+	return func(scanner *Scanner) {
+		scanner.filename = filename
+	}
+}
+
+// WithZeroBasedLines makes the Scanner count lines starting at 0 instead of 1.
+func WithZeroBasedLines() Option {
+	return func(scanner *Scanner) {
+		scanner.lineBase = 0
+	}
+}
+
+// WithZeroBasedColumns makes the Scanner count columns starting at 0 instead of 1.
+func WithZeroBasedColumns() Option {
+	return func(scanner *Scanner) {
+		scanner.colBase = 0
+	}
+}
+
+// WithRuneIndex makes the Scanner track the RuneIdx of the positions it produces.
+func WithRuneIndex() Option {
+	return func(scanner *Scanner) {
+		scanner.runeIndex = true
+	}
 }
 
 // NewScanner creates a new scanner for the given piece of text initialized to the TextPosition at index 0.
-func NewScanner(text string) *Scanner {
-	return NewScannerAt(text, TextPosition{Offset: 0, Line: 1, Col: 1})
+func NewScanner(text string, options ...Option) *Scanner {
+	return newScanner(text, nil, options)
 }
 
 // NewScannerAt creates a new scanner for the given piece of text initialized to the given starting TextPosition.
-func NewScannerAt(text string, startingPosition TextPosition) *Scanner {
+// If no filename is set through WithFilename, the filename of the starting TextPosition is used.
+// Positions derived from the text, e.g. by Scanner.SetOffset or Scanner.LineAt, continue from the starting position
+// the same way as the positions reached through Scanner.Pop.
+func NewScannerAt(text string, startingPosition TextPosition, options ...Option) *Scanner {
+	return newScanner(text, &startingPosition, options)
+}
+
+// newScanner creates a new scanner configured by the given options, initialized to the given starting TextPosition
+// or to the beginning of the text if the starting position is nil.
+func newScanner(text string, startingPosition *TextPosition, options []Option) *Scanner {
+	scanner := &Scanner{
+		text:     text,
+		lineBase: 1,
+		colBase:  1,
+		tabWidth: 8,
+	}
+	if startingPosition != nil {
+		scanner.filename = startingPosition.Filename
+	}
+	for _, option := range options {
+		option(scanner)
+	}
+
+	start := TextPosition{Offset: 0, Line: scanner.lineBase, Col: scanner.colBase}
+	if startingPosition != nil {
+		start = *startingPosition
+		scanner.shiftTo(start)
+	}
+	start.Filename = scanner.filename
+
+	scanner.TextPosition = start
+	scanner.markedPos = start
+	return scanner
+}
+
+// derive returns a new Scanner of the given text at the given position, configured like the Scanner.
+// Only the configuration set by options and NewScannerAt is copied, all scanning state of the new Scanner starts out fresh.
+// Progress is not reported by the new Scanner.
+func (scanner *Scanner) derive(text string, pos TextPosition) *Scanner {
 	return &Scanner{
-		TextPosition:       startingPosition,
-		text:               text,
-		markedPos:          startingPosition,
-		isComplexSinceMark: false,
+		TextPosition:   pos,
+		text:           text,
+		filename:       scanner.filename,
+		columnMode:     scanner.columnMode,
+		lineBase:       scanner.lineBase,
+		colBase:        scanner.colBase,
+		shift:          scanner.shift,
+		runeIndex:      scanner.runeIndex,
+		whitespace:     scanner.whitespace,
+		tabWidth:       scanner.tabWidth,
+		errorLines:     scanner.errorLines,
+		identStart:     scanner.identStart,
+		identContinue:  scanner.identContinue,
+		lineComments:   scanner.lineComments,
+		blockComments:  scanner.blockComments,
+		markedPos:      pos,
+		lineDirectives: slices.Clip(scanner.lineDirectives),
+		sourceMap:      scanner.sourceMap,
 	}
 }
 
@@ -58,6 +223,11 @@ func (scanner *Scanner) Text() string {
 	return scanner.text
 }
 
+// Filename returns the name of the source the scanned text originates from, or an empty string if none was set.
+func (scanner *Scanner) Filename() string {
+	return scanner.filename
+}
+
 // Pos returns the TextPosition the scanner is currently at.
 func (scanner *Scanner) Pos() TextPosition {
 	return scanner.TextPosition
@@ -86,16 +256,20 @@ func (scanner *Scanner) Pop() rune {
 	r, w := utf8.DecodeRuneInString(scanner.text[scanner.Offset:])
 
 	scanner.Offset += w
-	scanner.Col++
+	scanner.Col += scanner.columnMode.columnWidth(r)
+	if scanner.runeIndex {
+		scanner.RuneIdx++
+	}
+	scanner.trackProgress()
 
 	switch r {
 	case '\n':
 		scanner.Line++
-		scanner.Col = 1
+		scanner.Col = scanner.colBase
 
 	case '\r':
 		scanner.Line++
-		scanner.Col = 1
+		scanner.Col = scanner.colBase
 
 		scanner.isComplexSinceMark = true
 
@@ -103,6 +277,9 @@ func (scanner *Scanner) Pop() rune {
 		if !scanner.IsEOF() {
 			if nextR, nextW := utf8.DecodeRuneInString(scanner.text[scanner.Offset:]); nextR == '\n' {
 				scanner.Offset += nextW
+				if scanner.runeIndex {
+					scanner.RuneIdx++
+				}
 			}
 		}
 
@@ -134,11 +311,7 @@ func (scanner *Scanner) Pop() rune {
 func (scanner *Scanner) PopSpan() RuneSpan {
 	startPos := scanner.TextPosition
 	r := scanner.Pop()
-	return RuneSpan{
-		Rune: r,
-		Pos:  startPos,
-		End:  scanner.TextPosition,
-	}
+	return newRuneSpan(r, startPos, scanner.TextPosition)
 }
 
 // PopN returns a string of up to n runes from the current position and advances to the rune after.
@@ -174,7 +347,7 @@ func (scanner *Scanner) Peek() rune {
 // A backslash followed by a line break is skipped and the first rune of the next line is returned instead.
 func (scanner *Scanner) PeekSpan() RuneSpan {
 	span := scanner.PopSpan()
-	scanner.TextPosition = span.Pos
+	scanner.TextPosition = span.Start
 	return span
 }
 
@@ -196,6 +369,67 @@ func (scanner *Scanner) PeekN(n int) string {
 	return text
 }
 
+// PopSpans returns the RuneSpans of up to n runes from the current position and advances to the rune after.
+// When trying to retrieve runes past the end of the input, the returned slice is cut short. EOF is never included.
+// All line breaks (CR, LF and CRLF) are normalized to LF.
+// A backslash followed by a line break is skipped and the first rune of the next line is returned instead.
+func (scanner *Scanner) PopSpans(n int) []RuneSpan {
+	spans := make([]RuneSpan, 0, max(n, 0))
+	for range n {
+		span := scanner.PopSpan()
+		if span.Rune == EOF {
+			break
+		}
+		spans = append(spans, span)
+	}
+	return spans
+}
+
+// PeekSpans returns the RuneSpans of up to n runes from the current position without advancing.
+// When trying to retrieve runes past the end of the input, the returned slice is cut short. EOF is never included.
+// All line breaks (CR, LF and CRLF) are normalized to LF.
+// A backslash followed by a line break is skipped and the first rune of the next line is returned instead.
+func (scanner *Scanner) PeekSpans(n int) []RuneSpan {
+	savedPos := scanner.TextPosition
+	savedComplex := scanner.isComplexSinceMark
+
+	spans := scanner.PopSpans(n)
+
+	scanner.TextPosition = savedPos
+	scanner.isComplexSinceMark = savedComplex
+	return spans
+}
+
+// LookAhead returns the rune n runes ahead of the current scanner position without advancing, LookAhead(0) being equivalent to Scanner.Peek.
+// If that position is past the end of the text or n is negative, EOF is returned.
+// All line breaks (CR, LF and CRLF) are normalized to LF.
+// A backslash followed by a line break is skipped and the first rune of the next line is returned instead.
+func (scanner *Scanner) LookAhead(n int) rune {
+	return scanner.LookAheadSpan(n).Rune
+}
+
+// LookAheadSpan returns the RuneSpan n runes ahead of the current scanner position without advancing, LookAheadSpan(0) being equivalent to Scanner.PeekSpan.
+// If that position is past the end of the text or n is negative, EOF is returned.
+// All line breaks (CR, LF and CRLF) are normalized to LF.
+// A backslash followed by a line break is skipped and the first rune of the next line is returned instead.
+func (scanner *Scanner) LookAheadSpan(n int) RuneSpan {
+	if n < 0 {
+		return newRuneSpan(EOF, scanner.TextPosition, scanner.TextPosition)
+	}
+
+	savedPos := scanner.TextPosition
+	savedComplex := scanner.isComplexSinceMark
+
+	for range n {
+		scanner.Pop()
+	}
+	span := scanner.PopSpan()
+
+	scanner.TextPosition = savedPos
+	scanner.isComplexSinceMark = savedComplex
+	return span
+}
+
 // Next consumes the rune at the current scanner position and returns the next rune.
 // If the current position is past the end of the text, EOF is returned.
 // All line breaks (CR, LF and CRLF) are normalized to LF.
@@ -234,9 +468,7 @@ func (scanner *Scanner) Slice() string {
 	slice := scanner.text[scanner.markedPos.Offset:scanner.Offset]
 
 	if scanner.isComplexSinceMark {
-		slice = strings.ReplaceAll(slice, "\r\n", "\n")
-		slice = strings.ReplaceAll(slice, "\r", "\n")
-		slice = strings.ReplaceAll(slice, "\\\n", "")
+		slice = normalize(slice)
 	}
 
 	return slice
@@ -256,14 +488,20 @@ func (scanner *Scanner) SliceInc() string {
 	slice := scanner.text[scanner.markedPos.Offset:endIdx]
 
 	if scanner.isComplexSinceMark {
-		slice = strings.ReplaceAll(slice, "\r\n", "\n")
-		slice = strings.ReplaceAll(slice, "\r", "\n")
-		slice = strings.ReplaceAll(slice, "\\\n", "")
+		slice = normalize(slice)
 	}
 
 	return slice
 }
 
+// normalize applies the line break normalization and escaped line break skipping of Scanner.Pop to a raw piece of text.
+func normalize(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	text = strings.ReplaceAll(text, "\\\n", "")
+	return text
+}
+
 // Stream returns a channel of RuneSpans that are lazily created for the given piece of text.
 // The same skipping rules as for Scanner.Pop are applied.
 // The use of a channel may add allocation overhead, prefer manual iteration for performance critical applications.
@@ -284,6 +522,29 @@ func Stream(text string) <-chan RuneSpan {
 	return ch
 }
 
+// StreamCtx is like Stream, but stops producing RuneSpans, closes the channel and releases its goroutine once the given context
+// is canceled, even if the receiver stopped reading. RuneSpans may still be received for a short time after the cancellation.
+func StreamCtx(ctx context.Context, text string) <-chan RuneSpan {
+	ch := make(chan RuneSpan)
+	scanner := NewScanner(text)
+
+	go func() {
+		defer close(ch)
+		for {
+			span := scanner.PopSpan()
+			if span.Rune == EOF {
+				return
+			}
+			select {
+			case ch <- span:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
 // ForEach applies the given function for each rule in the provided piece of text.
 // The same skipping rules as for Scanner.Pop are applied.
 func ForEach(text string, fn func(RuneSpan) bool) {
@@ -294,3 +555,16 @@ func ForEach(text string, fn func(RuneSpan) bool) {
 		}
 	}
 }
+
+// AdvancePosition returns the position after the given piece of text, assuming the text starts at the given position.
+// The same line break and skipping rules as for Scanner.Pop are applied. The options configure the Scanner used to walk the text,
+// e.g. to count columns with a different ColumnMode or to advance the RuneIdx with WithRuneIndex.
+func AdvancePosition(pos TextPosition, text string, options ...Option) TextPosition {
+	scanner := NewScannerAt(text, TextPosition{Filename: pos.Filename, Offset: 0, Line: pos.Line, Col: pos.Col, RuneIdx: pos.RuneIdx}, options...)
+	for scanner.Pop() != EOF {
+	}
+
+	end := scanner.Pos()
+	end.Offset += pos.Offset
+	return end
+}
@@ -1,6 +1,8 @@
 package scanner
 
 import (
+	"context"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -781,54 +783,54 @@ func TestScannerPopSpan(t *testing.T) {
 			name:  "empty string",
 			input: "",
 			expected: []RuneSpan{
-				{Rune: EOF, Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 0, Line: 1, Col: 1}},
+				newRuneSpan(EOF, TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 0, Line: 1, Col: 1}),
 			},
 		},
 		{
 			name:  "simple ASCII",
 			input: "ab",
 			expected: []RuneSpan{
-				{Rune: 'a', Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 1, Line: 1, Col: 2}},
-				{Rune: 'b', Pos: TextPosition{Offset: 1, Line: 1, Col: 2}, End: TextPosition{Offset: 2, Line: 1, Col: 3}},
-				{Rune: EOF, Pos: TextPosition{Offset: 2, Line: 1, Col: 3}, End: TextPosition{Offset: 2, Line: 1, Col: 3}},
+				newRuneSpan('a', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 1, Line: 1, Col: 2}),
+				newRuneSpan('b', TextPosition{Offset: 1, Line: 1, Col: 2}, TextPosition{Offset: 2, Line: 1, Col: 3}),
+				newRuneSpan(EOF, TextPosition{Offset: 2, Line: 1, Col: 3}, TextPosition{Offset: 2, Line: 1, Col: 3}),
 			},
 		},
 		{
 			name:  "UTF-8 characters",
 			input: "αβ",
 			expected: []RuneSpan{
-				{Rune: 'α', Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 2, Line: 1, Col: 2}},
-				{Rune: 'β', Pos: TextPosition{Offset: 2, Line: 1, Col: 2}, End: TextPosition{Offset: 4, Line: 1, Col: 3}},
-				{Rune: EOF, Pos: TextPosition{Offset: 4, Line: 1, Col: 3}, End: TextPosition{Offset: 4, Line: 1, Col: 3}},
+				newRuneSpan('α', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 2, Line: 1, Col: 2}),
+				newRuneSpan('β', TextPosition{Offset: 2, Line: 1, Col: 2}, TextPosition{Offset: 4, Line: 1, Col: 3}),
+				newRuneSpan(EOF, TextPosition{Offset: 4, Line: 1, Col: 3}, TextPosition{Offset: 4, Line: 1, Col: 3}),
 			},
 		},
 		{
 			name:  "line break normalization",
 			input: "a\nb",
 			expected: []RuneSpan{
-				{Rune: 'a', Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 1, Line: 1, Col: 2}},
-				{Rune: '\n', Pos: TextPosition{Offset: 1, Line: 1, Col: 2}, End: TextPosition{Offset: 2, Line: 2, Col: 1}},
-				{Rune: 'b', Pos: TextPosition{Offset: 2, Line: 2, Col: 1}, End: TextPosition{Offset: 3, Line: 2, Col: 2}},
-				{Rune: EOF, Pos: TextPosition{Offset: 3, Line: 2, Col: 2}, End: TextPosition{Offset: 3, Line: 2, Col: 2}},
+				newRuneSpan('a', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 1, Line: 1, Col: 2}),
+				newRuneSpan('\n', TextPosition{Offset: 1, Line: 1, Col: 2}, TextPosition{Offset: 2, Line: 2, Col: 1}),
+				newRuneSpan('b', TextPosition{Offset: 2, Line: 2, Col: 1}, TextPosition{Offset: 3, Line: 2, Col: 2}),
+				newRuneSpan(EOF, TextPosition{Offset: 3, Line: 2, Col: 2}, TextPosition{Offset: 3, Line: 2, Col: 2}),
 			},
 		},
 		{
 			name:  "CRLF normalization",
 			input: "a\r\nb",
 			expected: []RuneSpan{
-				{Rune: 'a', Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 1, Line: 1, Col: 2}},
-				{Rune: '\n', Pos: TextPosition{Offset: 1, Line: 1, Col: 2}, End: TextPosition{Offset: 3, Line: 2, Col: 1}},
-				{Rune: 'b', Pos: TextPosition{Offset: 3, Line: 2, Col: 1}, End: TextPosition{Offset: 4, Line: 2, Col: 2}},
-				{Rune: EOF, Pos: TextPosition{Offset: 4, Line: 2, Col: 2}, End: TextPosition{Offset: 4, Line: 2, Col: 2}},
+				newRuneSpan('a', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 1, Line: 1, Col: 2}),
+				newRuneSpan('\n', TextPosition{Offset: 1, Line: 1, Col: 2}, TextPosition{Offset: 3, Line: 2, Col: 1}),
+				newRuneSpan('b', TextPosition{Offset: 3, Line: 2, Col: 1}, TextPosition{Offset: 4, Line: 2, Col: 2}),
+				newRuneSpan(EOF, TextPosition{Offset: 4, Line: 2, Col: 2}, TextPosition{Offset: 4, Line: 2, Col: 2}),
 			},
 		},
 		{
 			name:  "escaped line break",
 			input: "a\\\nb",
 			expected: []RuneSpan{
-				{Rune: 'a', Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 1, Line: 1, Col: 2}},
-				{Rune: 'b', Pos: TextPosition{Offset: 1, Line: 1, Col: 2}, End: TextPosition{Offset: 4, Line: 2, Col: 2}},
-				{Rune: EOF, Pos: TextPosition{Offset: 4, Line: 2, Col: 2}, End: TextPosition{Offset: 4, Line: 2, Col: 2}},
+				newRuneSpan('a', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 1, Line: 1, Col: 2}),
+				newRuneSpan('b', TextPosition{Offset: 1, Line: 1, Col: 2}, TextPosition{Offset: 4, Line: 2, Col: 2}),
+				newRuneSpan(EOF, TextPosition{Offset: 4, Line: 2, Col: 2}, TextPosition{Offset: 4, Line: 2, Col: 2}),
 			},
 		},
 	}
@@ -1011,55 +1013,55 @@ func TestScannerPeekSpan(t *testing.T) {
 			name:  "empty string",
 			input: "",
 			expected: []RuneSpan{
-				{Rune: EOF, Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 0, Line: 1, Col: 1}},
+				newRuneSpan(EOF, TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 0, Line: 1, Col: 1}),
 			},
 		},
 		{
 			name:  "simple ASCII",
 			input: "ab",
 			expected: []RuneSpan{
-				{Rune: 'a', Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 1, Line: 1, Col: 2}},
-				{Rune: 'a', Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 1, Line: 1, Col: 2}},
+				newRuneSpan('a', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 1, Line: 1, Col: 2}),
+				newRuneSpan('a', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 1, Line: 1, Col: 2}),
 			},
 		},
 		{
 			name:  "UTF-8 characters",
 			input: "αβ",
 			expected: []RuneSpan{
-				{Rune: 'α', Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 2, Line: 1, Col: 2}},
-				{Rune: 'α', Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 2, Line: 1, Col: 2}},
+				newRuneSpan('α', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 2, Line: 1, Col: 2}),
+				newRuneSpan('α', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 2, Line: 1, Col: 2}),
 			},
 		},
 		{
 			name:  "line break normalization",
 			input: "a\nb",
 			expected: []RuneSpan{
-				{Rune: 'a', Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 1, Line: 1, Col: 2}},
-				{Rune: 'a', Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 1, Line: 1, Col: 2}},
+				newRuneSpan('a', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 1, Line: 1, Col: 2}),
+				newRuneSpan('a', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 1, Line: 1, Col: 2}),
 			},
 		},
 		{
 			name:  "CR normalization",
 			input: "a\rb",
 			expected: []RuneSpan{
-				{Rune: 'a', Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 1, Line: 1, Col: 2}},
-				{Rune: 'a', Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 1, Line: 1, Col: 2}},
+				newRuneSpan('a', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 1, Line: 1, Col: 2}),
+				newRuneSpan('a', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 1, Line: 1, Col: 2}),
 			},
 		},
 		{
 			name:  "CRLF normalization",
 			input: "a\r\nb",
 			expected: []RuneSpan{
-				{Rune: 'a', Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 1, Line: 1, Col: 2}},
-				{Rune: 'a', Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 1, Line: 1, Col: 2}},
+				newRuneSpan('a', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 1, Line: 1, Col: 2}),
+				newRuneSpan('a', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 1, Line: 1, Col: 2}),
 			},
 		},
 		{
 			name:  "escaped line break",
 			input: "a\\\nb",
 			expected: []RuneSpan{
-				{Rune: 'a', Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 1, Line: 1, Col: 2}},
-				{Rune: 'a', Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 1, Line: 1, Col: 2}},
+				newRuneSpan('a', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 1, Line: 1, Col: 2}),
+				newRuneSpan('a', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 1, Line: 1, Col: 2}),
 			},
 		},
 	}
@@ -1147,11 +1149,7 @@ func TestScannerPeekSpanPosition(t *testing.T) {
 	}
 
 	// Verify span has correct positions
-	expectedSpan := RuneSpan{
-		Rune: 'a',
-		Pos:  TextPosition{Offset: 0, Line: 1, Col: 1},
-		End:  TextPosition{Offset: 1, Line: 1, Col: 2},
-	}
+	expectedSpan := newRuneSpan('a', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 1, Line: 1, Col: 2})
 	if span != expectedSpan {
 		t.Errorf("expected span %+v, got %+v", expectedSpan, span)
 	}
@@ -1178,49 +1176,29 @@ func TestScannerPeekSpanSpecialCases(t *testing.T) {
 		expectedSpan RuneSpan
 	}{
 		{
-			name:  "CR at start",
-			input: "\rabc",
-			expectedSpan: RuneSpan{
-				Rune: '\n',
-				Pos:  TextPosition{Offset: 0, Line: 1, Col: 1},
-				End:  TextPosition{Offset: 1, Line: 2, Col: 1},
-			},
+			name:         "CR at start",
+			input:        "\rabc",
+			expectedSpan: newRuneSpan('\n', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 1, Line: 2, Col: 1}),
 		},
 		{
-			name:  "CRLF at start",
-			input: "\r\nabc",
-			expectedSpan: RuneSpan{
-				Rune: '\n',
-				Pos:  TextPosition{Offset: 0, Line: 1, Col: 1},
-				End:  TextPosition{Offset: 2, Line: 2, Col: 1},
-			},
+			name:         "CRLF at start",
+			input:        "\r\nabc",
+			expectedSpan: newRuneSpan('\n', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 2, Line: 2, Col: 1}),
 		},
 		{
-			name:  "escaped line break at start",
-			input: "\\\nabc",
-			expectedSpan: RuneSpan{
-				Rune: 'a',
-				Pos:  TextPosition{Offset: 0, Line: 1, Col: 1},
-				End:  TextPosition{Offset: 3, Line: 2, Col: 2},
-			},
+			name:         "escaped line break at start",
+			input:        "\\\nabc",
+			expectedSpan: newRuneSpan('a', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 3, Line: 2, Col: 2}),
 		},
 		{
-			name:  "regular backslash at start",
-			input: "\\abc",
-			expectedSpan: RuneSpan{
-				Rune: '\\',
-				Pos:  TextPosition{Offset: 0, Line: 1, Col: 1},
-				End:  TextPosition{Offset: 1, Line: 1, Col: 2},
-			},
+			name:         "regular backslash at start",
+			input:        "\\abc",
+			expectedSpan: newRuneSpan('\\', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 1, Line: 1, Col: 2}),
 		},
 		{
-			name:  "backslash at end",
-			input: "\\",
-			expectedSpan: RuneSpan{
-				Rune: '\\',
-				Pos:  TextPosition{Offset: 0, Line: 1, Col: 1},
-				End:  TextPosition{Offset: 1, Line: 1, Col: 2},
-			},
+			name:         "backslash at end",
+			input:        "\\",
+			expectedSpan: newRuneSpan('\\', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 1, Line: 1, Col: 2}),
 		},
 	}
 
@@ -1250,11 +1228,7 @@ func TestScannerPeekSpanAfterAdvancement(t *testing.T) {
 
 	// PeekSpan should return span for second character
 	span := scanner.PeekSpan()
-	expectedSpan := RuneSpan{
-		Rune: 'b',
-		Pos:  TextPosition{Offset: 1, Line: 1, Col: 2},
-		End:  TextPosition{Offset: 2, Line: 1, Col: 3},
-	}
+	expectedSpan := newRuneSpan('b', TextPosition{Offset: 1, Line: 1, Col: 2}, TextPosition{Offset: 2, Line: 1, Col: 3})
 
 	if span != expectedSpan {
 		t.Errorf("expected span %+v, got %+v", expectedSpan, span)
@@ -1632,49 +1606,49 @@ func TestScannerNextSpan(t *testing.T) {
 			name:  "empty string",
 			input: "",
 			expected: []RuneSpan{
-				{Rune: EOF, Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 0, Line: 1, Col: 1}},
+				newRuneSpan(EOF, TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 0, Line: 1, Col: 1}),
 			},
 		},
 		{
 			name:  "simple ASCII",
 			input: "ab",
 			expected: []RuneSpan{
-				{Rune: 'b', Pos: TextPosition{Offset: 1, Line: 1, Col: 2}, End: TextPosition{Offset: 2, Line: 1, Col: 3}},
-				{Rune: EOF, Pos: TextPosition{Offset: 2, Line: 1, Col: 3}, End: TextPosition{Offset: 2, Line: 1, Col: 3}},
+				newRuneSpan('b', TextPosition{Offset: 1, Line: 1, Col: 2}, TextPosition{Offset: 2, Line: 1, Col: 3}),
+				newRuneSpan(EOF, TextPosition{Offset: 2, Line: 1, Col: 3}, TextPosition{Offset: 2, Line: 1, Col: 3}),
 			},
 		},
 		{
 			name:  "UTF-8 characters",
 			input: "αβ",
 			expected: []RuneSpan{
-				{Rune: 'β', Pos: TextPosition{Offset: 2, Line: 1, Col: 2}, End: TextPosition{Offset: 4, Line: 1, Col: 3}},
-				{Rune: EOF, Pos: TextPosition{Offset: 4, Line: 1, Col: 3}, End: TextPosition{Offset: 4, Line: 1, Col: 3}},
+				newRuneSpan('β', TextPosition{Offset: 2, Line: 1, Col: 2}, TextPosition{Offset: 4, Line: 1, Col: 3}),
+				newRuneSpan(EOF, TextPosition{Offset: 4, Line: 1, Col: 3}, TextPosition{Offset: 4, Line: 1, Col: 3}),
 			},
 		},
 		{
 			name:  "line break normalization",
 			input: "a\nb",
 			expected: []RuneSpan{
-				{Rune: '\n', Pos: TextPosition{Offset: 1, Line: 1, Col: 2}, End: TextPosition{Offset: 2, Line: 2, Col: 1}},
-				{Rune: 'b', Pos: TextPosition{Offset: 2, Line: 2, Col: 1}, End: TextPosition{Offset: 3, Line: 2, Col: 2}},
-				{Rune: EOF, Pos: TextPosition{Offset: 3, Line: 2, Col: 2}, End: TextPosition{Offset: 3, Line: 2, Col: 2}},
+				newRuneSpan('\n', TextPosition{Offset: 1, Line: 1, Col: 2}, TextPosition{Offset: 2, Line: 2, Col: 1}),
+				newRuneSpan('b', TextPosition{Offset: 2, Line: 2, Col: 1}, TextPosition{Offset: 3, Line: 2, Col: 2}),
+				newRuneSpan(EOF, TextPosition{Offset: 3, Line: 2, Col: 2}, TextPosition{Offset: 3, Line: 2, Col: 2}),
 			},
 		},
 		{
 			name:  "CRLF normalization",
 			input: "a\r\nb",
 			expected: []RuneSpan{
-				{Rune: '\n', Pos: TextPosition{Offset: 1, Line: 1, Col: 2}, End: TextPosition{Offset: 3, Line: 2, Col: 1}},
-				{Rune: 'b', Pos: TextPosition{Offset: 3, Line: 2, Col: 1}, End: TextPosition{Offset: 4, Line: 2, Col: 2}},
-				{Rune: EOF, Pos: TextPosition{Offset: 4, Line: 2, Col: 2}, End: TextPosition{Offset: 4, Line: 2, Col: 2}},
+				newRuneSpan('\n', TextPosition{Offset: 1, Line: 1, Col: 2}, TextPosition{Offset: 3, Line: 2, Col: 1}),
+				newRuneSpan('b', TextPosition{Offset: 3, Line: 2, Col: 1}, TextPosition{Offset: 4, Line: 2, Col: 2}),
+				newRuneSpan(EOF, TextPosition{Offset: 4, Line: 2, Col: 2}, TextPosition{Offset: 4, Line: 2, Col: 2}),
 			},
 		},
 		{
 			name:  "escaped line break",
 			input: "a\\\nb",
 			expected: []RuneSpan{
-				{Rune: 'b', Pos: TextPosition{Offset: 1, Line: 1, Col: 2}, End: TextPosition{Offset: 4, Line: 2, Col: 2}},
-				{Rune: EOF, Pos: TextPosition{Offset: 4, Line: 2, Col: 2}, End: TextPosition{Offset: 4, Line: 2, Col: 2}},
+				newRuneSpan('b', TextPosition{Offset: 1, Line: 1, Col: 2}, TextPosition{Offset: 4, Line: 2, Col: 2}),
+				newRuneSpan(EOF, TextPosition{Offset: 4, Line: 2, Col: 2}, TextPosition{Offset: 4, Line: 2, Col: 2}),
 			},
 		},
 	}
@@ -1774,11 +1748,7 @@ func TestScannerNextSpanPosition(t *testing.T) {
 	}
 
 	// Verify span has correct positions
-	expectedSpan := RuneSpan{
-		Rune: 'b',
-		Pos:  TextPosition{Offset: 1, Line: 1, Col: 2},
-		End:  TextPosition{Offset: 2, Line: 1, Col: 3},
-	}
+	expectedSpan := newRuneSpan('b', TextPosition{Offset: 1, Line: 1, Col: 2}, TextPosition{Offset: 2, Line: 1, Col: 3})
 	if span != expectedSpan {
 		t.Errorf("expected span %+v, got %+v", expectedSpan, span)
 	}
@@ -1789,11 +1759,7 @@ func TestScannerNextSpanAtEOF(t *testing.T) {
 
 	// NextSpan should return EOF span when at end
 	span := scanner.NextSpan()
-	expectedSpan := RuneSpan{
-		Rune: EOF,
-		Pos:  TextPosition{Offset: 1, Line: 1, Col: 2},
-		End:  TextPosition{Offset: 1, Line: 1, Col: 2},
-	}
+	expectedSpan := newRuneSpan(EOF, TextPosition{Offset: 1, Line: 1, Col: 2}, TextPosition{Offset: 1, Line: 1, Col: 2})
 	if span != expectedSpan {
 		t.Errorf("NextSpan at EOF: expected %+v, got %+v", expectedSpan, span)
 	}
@@ -3091,52 +3057,52 @@ func TestStream(t *testing.T) {
 			name:  "simple ASCII",
 			input: "abc",
 			expected: []RuneSpan{
-				{Rune: 'a', Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 1, Line: 1, Col: 2}},
-				{Rune: 'b', Pos: TextPosition{Offset: 1, Line: 1, Col: 2}, End: TextPosition{Offset: 2, Line: 1, Col: 3}},
-				{Rune: 'c', Pos: TextPosition{Offset: 2, Line: 1, Col: 3}, End: TextPosition{Offset: 3, Line: 1, Col: 4}},
+				newRuneSpan('a', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 1, Line: 1, Col: 2}),
+				newRuneSpan('b', TextPosition{Offset: 1, Line: 1, Col: 2}, TextPosition{Offset: 2, Line: 1, Col: 3}),
+				newRuneSpan('c', TextPosition{Offset: 2, Line: 1, Col: 3}, TextPosition{Offset: 3, Line: 1, Col: 4}),
 			},
 		},
 		{
 			name:  "UTF-8 characters",
 			input: "αβ",
 			expected: []RuneSpan{
-				{Rune: 'α', Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 2, Line: 1, Col: 2}},
-				{Rune: 'β', Pos: TextPosition{Offset: 2, Line: 1, Col: 2}, End: TextPosition{Offset: 4, Line: 1, Col: 3}},
+				newRuneSpan('α', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 2, Line: 1, Col: 2}),
+				newRuneSpan('β', TextPosition{Offset: 2, Line: 1, Col: 2}, TextPosition{Offset: 4, Line: 1, Col: 3}),
 			},
 		},
 		{
 			name:  "line breaks",
 			input: "a\nb",
 			expected: []RuneSpan{
-				{Rune: 'a', Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 1, Line: 1, Col: 2}},
-				{Rune: '\n', Pos: TextPosition{Offset: 1, Line: 1, Col: 2}, End: TextPosition{Offset: 2, Line: 2, Col: 1}},
-				{Rune: 'b', Pos: TextPosition{Offset: 2, Line: 2, Col: 1}, End: TextPosition{Offset: 3, Line: 2, Col: 2}},
+				newRuneSpan('a', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 1, Line: 1, Col: 2}),
+				newRuneSpan('\n', TextPosition{Offset: 1, Line: 1, Col: 2}, TextPosition{Offset: 2, Line: 2, Col: 1}),
+				newRuneSpan('b', TextPosition{Offset: 2, Line: 2, Col: 1}, TextPosition{Offset: 3, Line: 2, Col: 2}),
 			},
 		},
 		{
 			name:  "CR normalization",
 			input: "a\rb",
 			expected: []RuneSpan{
-				{Rune: 'a', Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 1, Line: 1, Col: 2}},
-				{Rune: '\n', Pos: TextPosition{Offset: 1, Line: 1, Col: 2}, End: TextPosition{Offset: 2, Line: 2, Col: 1}},
-				{Rune: 'b', Pos: TextPosition{Offset: 2, Line: 2, Col: 1}, End: TextPosition{Offset: 3, Line: 2, Col: 2}},
+				newRuneSpan('a', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 1, Line: 1, Col: 2}),
+				newRuneSpan('\n', TextPosition{Offset: 1, Line: 1, Col: 2}, TextPosition{Offset: 2, Line: 2, Col: 1}),
+				newRuneSpan('b', TextPosition{Offset: 2, Line: 2, Col: 1}, TextPosition{Offset: 3, Line: 2, Col: 2}),
 			},
 		},
 		{
 			name:  "CRLF normalization",
 			input: "a\r\nb",
 			expected: []RuneSpan{
-				{Rune: 'a', Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 1, Line: 1, Col: 2}},
-				{Rune: '\n', Pos: TextPosition{Offset: 1, Line: 1, Col: 2}, End: TextPosition{Offset: 3, Line: 2, Col: 1}},
-				{Rune: 'b', Pos: TextPosition{Offset: 3, Line: 2, Col: 1}, End: TextPosition{Offset: 4, Line: 2, Col: 2}},
+				newRuneSpan('a', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 1, Line: 1, Col: 2}),
+				newRuneSpan('\n', TextPosition{Offset: 1, Line: 1, Col: 2}, TextPosition{Offset: 3, Line: 2, Col: 1}),
+				newRuneSpan('b', TextPosition{Offset: 3, Line: 2, Col: 1}, TextPosition{Offset: 4, Line: 2, Col: 2}),
 			},
 		},
 		{
 			name:  "escaped line break",
 			input: "a\\\nb",
 			expected: []RuneSpan{
-				{Rune: 'a', Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 1, Line: 1, Col: 2}},
-				{Rune: 'b', Pos: TextPosition{Offset: 1, Line: 1, Col: 2}, End: TextPosition{Offset: 4, Line: 2, Col: 2}},
+				newRuneSpan('a', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 1, Line: 1, Col: 2}),
+				newRuneSpan('b', TextPosition{Offset: 1, Line: 1, Col: 2}, TextPosition{Offset: 4, Line: 2, Col: 2}),
 			},
 		},
 	}
@@ -3276,6 +3242,35 @@ func TestStreamVsPopSpan(t *testing.T) {
 	}
 }
 
+func TestStreamCtx(t *testing.T) {
+	var result []rune
+	for span := range StreamCtx(context.Background(), "a\r\nb") {
+		result = append(result, span.Rune)
+	}
+	if string(result) != "a\nb" {
+		t.Errorf("StreamCtx() = %q, expected %q", string(result), "a\nb")
+	}
+}
+
+func TestStreamCtxCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := StreamCtx(ctx, "abcdef")
+
+	if span := <-ch; span.Rune != 'a' {
+		t.Errorf("expected first rune 'a', got %q", span.Rune)
+	}
+	cancel()
+
+	// The channel must be closed after the cancellation, at most one span already being sent may still arrive.
+	count := 0
+	for range ch {
+		count++
+	}
+	if count > 1 {
+		t.Errorf("expected at most 1 span after cancellation, got %d", count)
+	}
+}
+
 func TestStreamEarlyTermination(t *testing.T) {
 	ch := Stream("abcdef")
 
@@ -3316,52 +3311,52 @@ func TestForEach(t *testing.T) {
 			name:  "simple ASCII",
 			input: "abc",
 			expected: []RuneSpan{
-				{Rune: 'a', Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 1, Line: 1, Col: 2}},
-				{Rune: 'b', Pos: TextPosition{Offset: 1, Line: 1, Col: 2}, End: TextPosition{Offset: 2, Line: 1, Col: 3}},
-				{Rune: 'c', Pos: TextPosition{Offset: 2, Line: 1, Col: 3}, End: TextPosition{Offset: 3, Line: 1, Col: 4}},
+				newRuneSpan('a', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 1, Line: 1, Col: 2}),
+				newRuneSpan('b', TextPosition{Offset: 1, Line: 1, Col: 2}, TextPosition{Offset: 2, Line: 1, Col: 3}),
+				newRuneSpan('c', TextPosition{Offset: 2, Line: 1, Col: 3}, TextPosition{Offset: 3, Line: 1, Col: 4}),
 			},
 		},
 		{
 			name:  "UTF-8 characters",
 			input: "αβ",
 			expected: []RuneSpan{
-				{Rune: 'α', Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 2, Line: 1, Col: 2}},
-				{Rune: 'β', Pos: TextPosition{Offset: 2, Line: 1, Col: 2}, End: TextPosition{Offset: 4, Line: 1, Col: 3}},
+				newRuneSpan('α', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 2, Line: 1, Col: 2}),
+				newRuneSpan('β', TextPosition{Offset: 2, Line: 1, Col: 2}, TextPosition{Offset: 4, Line: 1, Col: 3}),
 			},
 		},
 		{
 			name:  "line breaks",
 			input: "a\nb",
 			expected: []RuneSpan{
-				{Rune: 'a', Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 1, Line: 1, Col: 2}},
-				{Rune: '\n', Pos: TextPosition{Offset: 1, Line: 1, Col: 2}, End: TextPosition{Offset: 2, Line: 2, Col: 1}},
-				{Rune: 'b', Pos: TextPosition{Offset: 2, Line: 2, Col: 1}, End: TextPosition{Offset: 3, Line: 2, Col: 2}},
+				newRuneSpan('a', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 1, Line: 1, Col: 2}),
+				newRuneSpan('\n', TextPosition{Offset: 1, Line: 1, Col: 2}, TextPosition{Offset: 2, Line: 2, Col: 1}),
+				newRuneSpan('b', TextPosition{Offset: 2, Line: 2, Col: 1}, TextPosition{Offset: 3, Line: 2, Col: 2}),
 			},
 		},
 		{
 			name:  "CR normalization",
 			input: "a\rb",
 			expected: []RuneSpan{
-				{Rune: 'a', Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 1, Line: 1, Col: 2}},
-				{Rune: '\n', Pos: TextPosition{Offset: 1, Line: 1, Col: 2}, End: TextPosition{Offset: 2, Line: 2, Col: 1}},
-				{Rune: 'b', Pos: TextPosition{Offset: 2, Line: 2, Col: 1}, End: TextPosition{Offset: 3, Line: 2, Col: 2}},
+				newRuneSpan('a', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 1, Line: 1, Col: 2}),
+				newRuneSpan('\n', TextPosition{Offset: 1, Line: 1, Col: 2}, TextPosition{Offset: 2, Line: 2, Col: 1}),
+				newRuneSpan('b', TextPosition{Offset: 2, Line: 2, Col: 1}, TextPosition{Offset: 3, Line: 2, Col: 2}),
 			},
 		},
 		{
 			name:  "CRLF normalization",
 			input: "a\r\nb",
 			expected: []RuneSpan{
-				{Rune: 'a', Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 1, Line: 1, Col: 2}},
-				{Rune: '\n', Pos: TextPosition{Offset: 1, Line: 1, Col: 2}, End: TextPosition{Offset: 3, Line: 2, Col: 1}},
-				{Rune: 'b', Pos: TextPosition{Offset: 3, Line: 2, Col: 1}, End: TextPosition{Offset: 4, Line: 2, Col: 2}},
+				newRuneSpan('a', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 1, Line: 1, Col: 2}),
+				newRuneSpan('\n', TextPosition{Offset: 1, Line: 1, Col: 2}, TextPosition{Offset: 3, Line: 2, Col: 1}),
+				newRuneSpan('b', TextPosition{Offset: 3, Line: 2, Col: 1}, TextPosition{Offset: 4, Line: 2, Col: 2}),
 			},
 		},
 		{
 			name:  "escaped line break",
 			input: "a\\\nb",
 			expected: []RuneSpan{
-				{Rune: 'a', Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 1, Line: 1, Col: 2}},
-				{Rune: 'b', Pos: TextPosition{Offset: 1, Line: 1, Col: 2}, End: TextPosition{Offset: 4, Line: 2, Col: 2}},
+				newRuneSpan('a', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 1, Line: 1, Col: 2}),
+				newRuneSpan('b', TextPosition{Offset: 1, Line: 1, Col: 2}, TextPosition{Offset: 4, Line: 2, Col: 2}),
 			},
 		},
 	}
@@ -3401,8 +3396,8 @@ func TestForEachEarlyTermination(t *testing.T) {
 			input:  "abcdef",
 			stopAt: 'b',
 			expected: []RuneSpan{
-				{Rune: 'a', Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 1, Line: 1, Col: 2}},
-				{Rune: 'b', Pos: TextPosition{Offset: 1, Line: 1, Col: 2}, End: TextPosition{Offset: 2, Line: 1, Col: 3}},
+				newRuneSpan('a', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 1, Line: 1, Col: 2}),
+				newRuneSpan('b', TextPosition{Offset: 1, Line: 1, Col: 2}, TextPosition{Offset: 2, Line: 1, Col: 3}),
 			},
 		},
 		{
@@ -3410,7 +3405,7 @@ func TestForEachEarlyTermination(t *testing.T) {
 			input:  "hello",
 			stopAt: 'h',
 			expected: []RuneSpan{
-				{Rune: 'h', Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 1, Line: 1, Col: 2}},
+				newRuneSpan('h', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 1, Line: 1, Col: 2}),
 			},
 		},
 		{
@@ -3418,8 +3413,8 @@ func TestForEachEarlyTermination(t *testing.T) {
 			input:  "a\nb\nc",
 			stopAt: '\n',
 			expected: []RuneSpan{
-				{Rune: 'a', Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 1, Line: 1, Col: 2}},
-				{Rune: '\n', Pos: TextPosition{Offset: 1, Line: 1, Col: 2}, End: TextPosition{Offset: 2, Line: 2, Col: 1}},
+				newRuneSpan('a', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 1, Line: 1, Col: 2}),
+				newRuneSpan('\n', TextPosition{Offset: 1, Line: 1, Col: 2}, TextPosition{Offset: 2, Line: 2, Col: 1}),
 			},
 		},
 		{
@@ -3427,8 +3422,8 @@ func TestForEachEarlyTermination(t *testing.T) {
 			input:  "aβγ",
 			stopAt: 'β',
 			expected: []RuneSpan{
-				{Rune: 'a', Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 1, Line: 1, Col: 2}},
-				{Rune: 'β', Pos: TextPosition{Offset: 1, Line: 1, Col: 2}, End: TextPosition{Offset: 3, Line: 1, Col: 3}},
+				newRuneSpan('a', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 1, Line: 1, Col: 2}),
+				newRuneSpan('β', TextPosition{Offset: 1, Line: 1, Col: 2}, TextPosition{Offset: 3, Line: 1, Col: 3}),
 			},
 		},
 		{
@@ -3436,9 +3431,9 @@ func TestForEachEarlyTermination(t *testing.T) {
 			input:  "abc",
 			stopAt: 'x',
 			expected: []RuneSpan{
-				{Rune: 'a', Pos: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 1, Line: 1, Col: 2}},
-				{Rune: 'b', Pos: TextPosition{Offset: 1, Line: 1, Col: 2}, End: TextPosition{Offset: 2, Line: 1, Col: 3}},
-				{Rune: 'c', Pos: TextPosition{Offset: 2, Line: 1, Col: 3}, End: TextPosition{Offset: 3, Line: 1, Col: 4}},
+				newRuneSpan('a', TextPosition{Offset: 0, Line: 1, Col: 1}, TextPosition{Offset: 1, Line: 1, Col: 2}),
+				newRuneSpan('b', TextPosition{Offset: 1, Line: 1, Col: 2}, TextPosition{Offset: 2, Line: 1, Col: 3}),
+				newRuneSpan('c', TextPosition{Offset: 2, Line: 1, Col: 3}, TextPosition{Offset: 3, Line: 1, Col: 4}),
 			},
 		},
 	}
@@ -3828,3 +3823,605 @@ func min(a, b int) int {
 	}
 	return b
 }
+
+func TestTextPositionString(t *testing.T) {
+	tests := []struct {
+		name     string
+		pos      TextPosition
+		expected string
+	}{
+		{
+			name:     "without filename",
+			pos:      TextPosition{Offset: 0, Line: 1, Col: 1},
+			expected: "1:1",
+		},
+		{
+			name:     "with filename",
+			pos:      TextPosition{Filename: "main.go", Offset: 12, Line: 3, Col: 7},
+			expected: "main.go:3:7",
+		},
+		{
+			name:     "with path",
+			pos:      TextPosition{Filename: "dir/file.txt", Offset: 5, Line: 10, Col: 42},
+			expected: "dir/file.txt:10:42",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.pos.String(); result != tt.expected {
+				t.Errorf("String() = %q, expected %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestScannerDerive(t *testing.T) {
+	scanner := NewScanner("ab\tc", WithFilename("input.txt"), WithLineComments("//"), WithProgress(1, func(float64) {}))
+	scanner.Pop()
+	scanner.MarkNamed("a")
+	scanner.PushMark()
+
+	start := TextPosition{Filename: "input.txt", Offset: 1, Line: 1, Col: 2}
+	derived := scanner.derive(scanner.Text(), start)
+	if derived.Pos() != start || derived.Marked() != start {
+		t.Errorf("derived scanner at %+v marked at %+v, expected %+v", derived.Pos(), derived.Marked(), start)
+	}
+	if derived.Filename() != "input.txt" || !slices.Equal(derived.lineComments, []string{"//"}) {
+		t.Errorf("derived scanner did not keep the configuration: filename %q, line comments %q", derived.Filename(), derived.lineComments)
+	}
+	if _, ok := derived.MarkedNamed("a"); ok || len(derived.markStack) > 0 || derived.progressCallback != nil {
+		t.Errorf("derived scanner shares the scanning state of the Scanner")
+	}
+}
+
+func TestScannerWithFilename(t *testing.T) {
+	scanner := NewScanner("ab\ncd", WithFilename("input.txt"))
+
+	if scanner.Filename() != "input.txt" {
+		t.Errorf("Filename() = %q, expected %q", scanner.Filename(), "input.txt")
+	}
+
+	expected := []RuneSpan{
+		newRuneSpan('a',
+			TextPosition{Filename: "input.txt", Offset: 0, Line: 1, Col: 1},
+			TextPosition{Filename: "input.txt", Offset: 1, Line: 1, Col: 2}),
+		newRuneSpan('b',
+			TextPosition{Filename: "input.txt", Offset: 1, Line: 1, Col: 2},
+			TextPosition{Filename: "input.txt", Offset: 2, Line: 1, Col: 3}),
+		newRuneSpan('\n',
+			TextPosition{Filename: "input.txt", Offset: 2, Line: 1, Col: 3},
+			TextPosition{Filename: "input.txt", Offset: 3, Line: 2, Col: 1}),
+		newRuneSpan('c',
+			TextPosition{Filename: "input.txt", Offset: 3, Line: 2, Col: 1},
+			TextPosition{Filename: "input.txt", Offset: 4, Line: 2, Col: 2}),
+	}
+	for i, expectedSpan := range expected {
+		if span := scanner.PopSpan(); span != expectedSpan {
+			t.Errorf("at step %d: expected %+v, got %+v", i, expectedSpan, span)
+		}
+	}
+
+	if result := scanner.Pos().String(); result != "input.txt:2:2" {
+		t.Errorf("Pos().String() = %q, expected %q", result, "input.txt:2:2")
+	}
+	if result := scanner.Marked().Filename; result != "input.txt" {
+		t.Errorf("Marked().Filename = %q, expected %q", result, "input.txt")
+	}
+}
+
+func TestScannerFilenameWithNewScannerAt(t *testing.T) {
+	tests := []struct {
+		name     string
+		startPos TextPosition
+		options  []Option
+		expected string
+	}{
+		{
+			name:     "no filename",
+			startPos: TextPosition{Offset: 1, Line: 1, Col: 2},
+			expected: "",
+		},
+		{
+			name:     "filename from starting position",
+			startPos: TextPosition{Filename: "a.txt", Offset: 1, Line: 1, Col: 2},
+			expected: "a.txt",
+		},
+		{
+			name:     "filename from option",
+			startPos: TextPosition{Offset: 1, Line: 1, Col: 2},
+			options:  []Option{WithFilename("b.txt")},
+			expected: "b.txt",
+		},
+		{
+			name:     "option overrides starting position",
+			startPos: TextPosition{Filename: "a.txt", Offset: 1, Line: 1, Col: 2},
+			options:  []Option{WithFilename("b.txt")},
+			expected: "b.txt",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScannerAt("abc", tt.startPos, tt.options...)
+
+			if scanner.Filename() != tt.expected {
+				t.Errorf("Filename() = %q, expected %q", scanner.Filename(), tt.expected)
+			}
+			if scanner.Pos().Filename != tt.expected {
+				t.Errorf("Pos().Filename = %q, expected %q", scanner.Pos().Filename, tt.expected)
+			}
+			scanner.Pop()
+			if scanner.Pos().Filename != tt.expected {
+				t.Errorf("Pos().Filename after Pop = %q, expected %q", scanner.Pos().Filename, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAdvancePosition(t *testing.T) {
+	tests := []struct {
+		name     string
+		pos      TextPosition
+		text     string
+		options  []Option
+		expected TextPosition
+	}{
+		{
+			name:     "empty text",
+			pos:      TextPosition{Offset: 5, Line: 2, Col: 3},
+			text:     "",
+			expected: TextPosition{Offset: 5, Line: 2, Col: 3},
+		},
+		{
+			name:     "single line",
+			pos:      TextPosition{Offset: 5, Line: 2, Col: 3},
+			text:     "abc",
+			expected: TextPosition{Offset: 8, Line: 2, Col: 6},
+		},
+		{
+			name:     "UTF-8 characters",
+			pos:      TextPosition{Offset: 0, Line: 1, Col: 1},
+			text:     "αβ",
+			expected: TextPosition{Offset: 4, Line: 1, Col: 3},
+		},
+		{
+			name:     "line breaks",
+			pos:      TextPosition{Offset: 5, Line: 2, Col: 3},
+			text:     "a\nb\r\ncd\re",
+			expected: TextPosition{Offset: 14, Line: 5, Col: 2},
+		},
+		{
+			name:     "trailing line break",
+			pos:      TextPosition{Offset: 0, Line: 1, Col: 1},
+			text:     "ab\r\n",
+			expected: TextPosition{Offset: 4, Line: 2, Col: 1},
+		},
+		{
+			name:     "escaped line break",
+			pos:      TextPosition{Offset: 0, Line: 1, Col: 1},
+			text:     "a\\\nb",
+			expected: TextPosition{Offset: 4, Line: 2, Col: 2},
+		},
+		{
+			name:     "filename is kept",
+			pos:      TextPosition{Filename: "macro.h", Offset: 1, Line: 1, Col: 2},
+			text:     "xy",
+			expected: TextPosition{Filename: "macro.h", Offset: 3, Line: 1, Col: 4},
+		},
+		{
+			name:     "column mode option",
+			pos:      TextPosition{Offset: 0, Line: 1, Col: 1},
+			text:     "😀",
+			options:  []Option{WithColumnMode(ColumnUTF16)},
+			expected: TextPosition{Offset: 4, Line: 1, Col: 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := AdvancePosition(tt.pos, tt.text, tt.options...)
+			if result != tt.expected {
+				t.Errorf("AdvancePosition(%+v, %q) = %+v, expected %+v", tt.pos, tt.text, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAdvancePositionMatchesScanner(t *testing.T) {
+	text := "ab\ncd\r\nαβ\\\nγ"
+	scanner := NewScanner(text)
+	scanner.PopN(4)
+	mid := scanner.Pos()
+	for scanner.Pop() != EOF {
+	}
+
+	if result := AdvancePosition(mid, text[mid.Offset:]); result != scanner.Pos() {
+		t.Errorf("AdvancePosition() = %+v, expected %+v", result, scanner.Pos())
+	}
+}
+
+func TestTextPositionCompare(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     TextPosition
+		expected int
+	}{
+		{
+			name:     "same position",
+			a:        TextPosition{Offset: 3, Line: 1, Col: 4},
+			b:        TextPosition{Offset: 3, Line: 1, Col: 4},
+			expected: 0,
+		},
+		{
+			name:     "earlier offset",
+			a:        TextPosition{Offset: 2, Line: 1, Col: 3},
+			b:        TextPosition{Offset: 3, Line: 1, Col: 4},
+			expected: -1,
+		},
+		{
+			name:     "later offset",
+			a:        TextPosition{Offset: 5, Line: 2, Col: 1},
+			b:        TextPosition{Offset: 3, Line: 1, Col: 4},
+			expected: 1,
+		},
+		{
+			name:     "different column modes",
+			a:        TextPosition{Offset: 4, Line: 1, Col: 3}, // UTF-16 column after 😀
+			b:        TextPosition{Offset: 4, Line: 1, Col: 2}, // rune column after 😀
+			expected: 0,
+		},
+		{
+			name:     "filename takes precedence",
+			a:        TextPosition{Filename: "a.txt", Offset: 10, Line: 2, Col: 1},
+			b:        TextPosition{Filename: "b.txt", Offset: 0, Line: 1, Col: 1},
+			expected: -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.a.Compare(tt.b); result != tt.expected {
+				t.Errorf("a.Compare(b) = %d, expected %d", result, tt.expected)
+			}
+			if result := tt.b.Compare(tt.a); result != -tt.expected {
+				t.Errorf("b.Compare(a) = %d, expected %d", result, -tt.expected)
+			}
+			if result := tt.a.Before(tt.b); result != (tt.expected < 0) {
+				t.Errorf("a.Before(b) = %t, expected %t", result, tt.expected < 0)
+			}
+			if result := tt.a.After(tt.b); result != (tt.expected > 0) {
+				t.Errorf("a.After(b) = %t, expected %t", result, tt.expected > 0)
+			}
+		})
+	}
+}
+
+func TestScannerZeroBased(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []Option
+		expected []TextPosition // initial position, then position after each pop
+	}{
+		{
+			name:    "one-based by default",
+			options: nil,
+			expected: []TextPosition{
+				{Offset: 0, Line: 1, Col: 1},
+				{Offset: 1, Line: 1, Col: 2},
+				{Offset: 2, Line: 2, Col: 1},
+				{Offset: 3, Line: 2, Col: 2},
+			},
+		},
+		{
+			name:    "zero-based lines",
+			options: []Option{WithZeroBasedLines()},
+			expected: []TextPosition{
+				{Offset: 0, Line: 0, Col: 1},
+				{Offset: 1, Line: 0, Col: 2},
+				{Offset: 2, Line: 1, Col: 1},
+				{Offset: 3, Line: 1, Col: 2},
+			},
+		},
+		{
+			name:    "zero-based columns",
+			options: []Option{WithZeroBasedColumns()},
+			expected: []TextPosition{
+				{Offset: 0, Line: 1, Col: 0},
+				{Offset: 1, Line: 1, Col: 1},
+				{Offset: 2, Line: 2, Col: 0},
+				{Offset: 3, Line: 2, Col: 1},
+			},
+		},
+		{
+			name:    "zero-based lines and columns",
+			options: []Option{WithZeroBasedLines(), WithZeroBasedColumns()},
+			expected: []TextPosition{
+				{Offset: 0, Line: 0, Col: 0},
+				{Offset: 1, Line: 0, Col: 1},
+				{Offset: 2, Line: 1, Col: 0},
+				{Offset: 3, Line: 1, Col: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner("a\nb", tt.options...)
+			lookup := NewScanner("a\nb", tt.options...)
+
+			for i, expectedPos := range tt.expected {
+				if i > 0 {
+					scanner.Pop()
+				}
+				if scanner.Pos() != expectedPos {
+					t.Errorf("at step %d: expected position %+v, got %+v", i, expectedPos, scanner.Pos())
+				}
+
+				offset, err := lookup.OffsetOf(expectedPos.Line, expectedPos.Col)
+				if err != nil {
+					t.Errorf("OffsetOf(%d, %d): unexpected error: %v", expectedPos.Line, expectedPos.Col, err)
+				} else if offset != expectedPos.Offset {
+					t.Errorf("OffsetOf(%d, %d): expected %d, got %d", expectedPos.Line, expectedPos.Col, expectedPos.Offset, offset)
+				}
+
+				if err := lookup.SetOffset(expectedPos.Offset); err != nil {
+					t.Errorf("SetOffset(%d): unexpected error: %v", expectedPos.Offset, err)
+				} else if lookup.Pos() != expectedPos {
+					t.Errorf("SetOffset(%d): expected %+v, got %+v", expectedPos.Offset, expectedPos, lookup.Pos())
+				}
+			}
+		})
+	}
+}
+
+func TestScannerZeroBasedLineAt(t *testing.T) {
+	scanner := NewScanner("ab\ncd", WithZeroBasedLines(), WithZeroBasedColumns())
+
+	text, span := scanner.LineAt(1)
+	expected := Span{
+		Start: TextPosition{Offset: 3, Line: 1, Col: 0},
+		End:   TextPosition{Offset: 5, Line: 1, Col: 2},
+	}
+	if text != "cd" || span != expected {
+		t.Errorf("LineAt(1) = %q, %+v, expected %q, %+v", text, span, "cd", expected)
+	}
+
+	if text, span := scanner.LineAt(2); text != "" || span != (Span{}) {
+		t.Errorf("LineAt(2) = %q, %+v, expected no line", text, span)
+	}
+	if _, err := scanner.OffsetOf(-1, 0); err == nil {
+		t.Errorf("OffsetOf(-1, 0): expected error")
+	}
+	if _, err := scanner.OffsetOf(0, -1); err == nil {
+		t.Errorf("OffsetOf(0, -1): expected error")
+	}
+}
+
+func TestScannerRuneIndex(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []TextPosition // position after each pop
+	}{
+		{
+			name:  "ASCII",
+			input: "ab",
+			expected: []TextPosition{
+				{Offset: 1, Line: 1, Col: 2, RuneIdx: 1},
+				{Offset: 2, Line: 1, Col: 3, RuneIdx: 2},
+			},
+		},
+		{
+			name:  "multi-byte runes",
+			input: "α😀b",
+			expected: []TextPosition{
+				{Offset: 2, Line: 1, Col: 2, RuneIdx: 1},
+				{Offset: 6, Line: 1, Col: 3, RuneIdx: 2},
+				{Offset: 7, Line: 1, Col: 4, RuneIdx: 3},
+			},
+		},
+		{
+			name:  "CRLF counts as two runes",
+			input: "a\r\nb",
+			expected: []TextPosition{
+				{Offset: 1, Line: 1, Col: 2, RuneIdx: 1},
+				{Offset: 3, Line: 2, Col: 1, RuneIdx: 3},
+				{Offset: 4, Line: 2, Col: 2, RuneIdx: 4},
+			},
+		},
+		{
+			name:  "escaped line break",
+			input: "a\\\nb",
+			expected: []TextPosition{
+				{Offset: 1, Line: 1, Col: 2, RuneIdx: 1},
+				{Offset: 4, Line: 2, Col: 2, RuneIdx: 4},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input, WithRuneIndex())
+			lookup := NewScanner(tt.input, WithRuneIndex())
+
+			for i, expectedPos := range tt.expected {
+				scanner.Pop()
+				if scanner.Pos() != expectedPos {
+					t.Errorf("at step %d: expected position %+v, got %+v", i, expectedPos, scanner.Pos())
+				}
+
+				if err := lookup.SetOffset(expectedPos.Offset); err != nil {
+					t.Errorf("SetOffset(%d): unexpected error: %v", expectedPos.Offset, err)
+				} else if lookup.Pos() != expectedPos {
+					t.Errorf("SetOffset(%d): expected %+v, got %+v", expectedPos.Offset, expectedPos, lookup.Pos())
+				}
+			}
+		})
+	}
+}
+
+func TestScannerRuneIndexDisabled(t *testing.T) {
+	scanner := NewScanner("αβ")
+	scanner.Pop()
+
+	if scanner.RuneIdx != 0 {
+		t.Errorf("expected RuneIdx 0 without WithRuneIndex, got %d", scanner.RuneIdx)
+	}
+}
+
+func TestScannerRuneIndexStrict(t *testing.T) {
+	scanner := NewScanner("αβ", WithRuneIndex())
+
+	if err := scanner.SetPosStrict(TextPosition{Offset: 2, Line: 1, Col: 2, RuneIdx: 1}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := scanner.SetPosStrict(TextPosition{Offset: 2, Line: 1, Col: 2, RuneIdx: 2}); err == nil {
+		t.Errorf("expected error for wrong rune index")
+	}
+}
+
+func TestAdvancePositionRuneIndex(t *testing.T) {
+	pos := TextPosition{Offset: 3, Line: 1, Col: 3, RuneIdx: 2}
+	expected := TextPosition{Offset: 9, Line: 2, Col: 2, RuneIdx: 6}
+
+	if result := AdvancePosition(pos, "α\r\nβ", WithRuneIndex()); result != expected {
+		t.Errorf("AdvancePosition() = %+v, expected %+v", result, expected)
+	}
+}
+
+func TestScannerLookAhead(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		n        int
+		expected rune
+	}{
+		{name: "zero is peek", input: "abc", n: 0, expected: 'a'},
+		{name: "one ahead", input: "abc", n: 1, expected: 'b'},
+		{name: "two ahead", input: "abc", n: 2, expected: 'c'},
+		{name: "past end", input: "abc", n: 3, expected: EOF},
+		{name: "far past end", input: "abc", n: 100, expected: EOF},
+		{name: "negative", input: "abc", n: -1, expected: EOF},
+		{name: "empty string", input: "", n: 0, expected: EOF},
+		{name: "UTF-8 characters", input: "αβγ", n: 2, expected: 'γ'},
+		{name: "CRLF normalization", input: "a\r\nb", n: 1, expected: '\n'},
+		{name: "after CRLF", input: "a\r\nb", n: 2, expected: 'b'},
+		{name: "escaped line break", input: "a\\\nb", n: 1, expected: 'b'},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			before := scanner.Pos()
+
+			if r := scanner.LookAhead(tt.n); r != tt.expected {
+				t.Errorf("LookAhead(%d) = %q, expected %q", tt.n, r, tt.expected)
+			}
+			if scanner.Pos() != before {
+				t.Errorf("position changed: expected %+v, got %+v", before, scanner.Pos())
+			}
+		})
+	}
+}
+
+func TestScannerLookAheadSpan(t *testing.T) {
+	scanner := NewScanner("ab\r\ncd")
+	scanner.Pop()
+	scanner.Mark()
+
+	expected := newRuneSpan('c', TextPosition{Offset: 4, Line: 2, Col: 1}, TextPosition{Offset: 5, Line: 2, Col: 2})
+	if span := scanner.LookAheadSpan(2); span != expected {
+		t.Errorf("LookAheadSpan(2) = %+v, expected %+v", span, expected)
+	}
+	if span := scanner.LookAheadSpan(0); span != scanner.PeekSpan() {
+		t.Errorf("LookAheadSpan(0) = %+v, expected %+v", span, scanner.PeekSpan())
+	}
+
+	// looking ahead across a CRLF must not affect slicing
+	scanner.Pop()
+	if result := scanner.Slice(); result != "b" {
+		t.Errorf("Slice() = %q, expected %q", result, "b")
+	}
+}
+
+func TestScannerPopSpans(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		n        int
+		expected []rune
+		endPos   TextPosition
+	}{
+		{
+			name:     "zero",
+			input:    "abc",
+			n:        0,
+			expected: []rune{},
+			endPos:   TextPosition{Offset: 0, Line: 1, Col: 1},
+		},
+		{
+			name:     "some runes",
+			input:    "abc",
+			n:        2,
+			expected: []rune{'a', 'b'},
+			endPos:   TextPosition{Offset: 2, Line: 1, Col: 3},
+		},
+		{
+			name:     "cut short at EOF",
+			input:    "ab",
+			n:        5,
+			expected: []rune{'a', 'b'},
+			endPos:   TextPosition{Offset: 2, Line: 1, Col: 3},
+		},
+		{
+			name:     "negative",
+			input:    "ab",
+			n:        -1,
+			expected: []rune{},
+			endPos:   TextPosition{Offset: 0, Line: 1, Col: 1},
+		},
+		{
+			name:     "normalization",
+			input:    "a\r\nb\\\nc",
+			n:        4,
+			expected: []rune{'a', '\n', 'b', 'c'},
+			endPos:   TextPosition{Offset: 7, Line: 3, Col: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			peekScanner := NewScanner(tt.input)
+			popScanner := NewScanner(tt.input)
+
+			peeked := peekScanner.PeekSpans(tt.n)
+			popped := popScanner.PopSpans(tt.n)
+
+			if peekScanner.Pos() != (TextPosition{Offset: 0, Line: 1, Col: 1}) {
+				t.Errorf("PeekSpans changed position to %+v", peekScanner.Pos())
+			}
+			if popScanner.Pos() != tt.endPos {
+				t.Errorf("PopSpans: expected position %+v, got %+v", tt.endPos, popScanner.Pos())
+			}
+
+			if len(peeked) != len(tt.expected) || len(popped) != len(tt.expected) {
+				t.Fatalf("expected %d spans, got %d peeked and %d popped", len(tt.expected), len(peeked), len(popped))
+			}
+
+			reference := NewScanner(tt.input)
+			for i, expected := range tt.expected {
+				span := reference.PopSpan()
+				if span.Rune != expected {
+					t.Errorf("at %d: expected rune %q, got %q", i, expected, span.Rune)
+				}
+				if peeked[i] != span {
+					t.Errorf("at %d: peeked %+v, expected %+v", i, peeked[i], span)
+				}
+				if popped[i] != span {
+					t.Errorf("at %d: popped %+v, expected %+v", i, popped[i], span)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package scanner
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// SkipUntil consumes the runes up to, but not including, the next occurrence of r and returns the span skipped.
+// If r does not occur, the scanner advances to the end of the text.
+// The runes are compared after line break normalization, so SkipUntil('\n') skips to the end of the line.
+func (scanner *Scanner) SkipUntil(r rune) Span {
+	_, span := scanner.TakeWhile(func(next rune) bool { return next != r })
+	return span
+}
+
+// SkipUntilAny consumes the runes up to, but not including, the next rune contained in set and returns the span skipped.
+// If none of the runes occur, the scanner advances to the end of the text.
+func (scanner *Scanner) SkipUntilAny(set string) Span {
+	_, span := scanner.TakeWhile(func(next rune) bool { return !strings.ContainsRune(set, next) })
+	return span
+}
+
+// ReadUntilAny consumes the runes up to the next rune contained in set and returns the text consumed, normalized the same way Scanner.Slice does,
+// along with its span. If include is true, the terminating rune is consumed and included as well, otherwise it is left in front of the scanner.
+// If none of the runes occur, the scanner advances to the end of the text.
+func (scanner *Scanner) ReadUntilAny(set string, include bool) (string, Span) {
+	text, span := scanner.TakeWhile(func(next rune) bool { return !strings.ContainsRune(set, next) })
+	if include && !scanner.IsEOF() {
+		scanner.Pop()
+		span.End = scanner.TextPosition
+		text = scanner.TextOf(span)
+	}
+	return text, span
+}
+
+// SkipUntilString consumes the runes up to, but not including, the next occurrence of s and returns the span skipped.
+// If s does not occur, the scanner advances to the end of the text.
+// The upcoming runes are compared to s the same way as by Scanner.HasPrefix.
+func (scanner *Scanner) SkipUntilString(s string) Span {
+	start := scanner.TextPosition
+	first, _ := utf8.DecodeRuneInString(s)
+	for !scanner.IsEOF() && !scanner.HasPrefix(s) {
+		scanner.Pop()
+		// jump ahead to the next candidate
+		scanner.SkipUntil(first)
+	}
+	return Span{Start: start, End: scanner.TextPosition}
+}
+
+// IndexOf returns the position of the next occurrence of r without advancing, and whether r occurs at all.
+// The runes are compared after line break normalization like by Scanner.SkipUntil.
+// If r does not occur, the position of the end of the text is returned along with false.
+func (scanner *Scanner) IndexOf(r rune) (TextPosition, bool) {
+	return scanner.lookUntil(func() Span { return scanner.SkipUntil(r) }, func() bool { return scanner.Peek() == r })
+}
+
+// IndexOfString returns the position of the next occurrence of s without advancing, and whether s occurs at all.
+// The upcoming runes are compared to s the same way as by Scanner.HasPrefix.
+// If s does not occur, the position of the end of the text is returned along with false.
+func (scanner *Scanner) IndexOfString(s string) (TextPosition, bool) {
+	return scanner.lookUntil(func() Span { return scanner.SkipUntilString(s) }, func() bool { return scanner.HasPrefix(s) })
+}
+
+// Count returns the number of occurrences of r from the current scanner position to the end of the text without advancing.
+// The runes are compared after line break normalization like by Scanner.SkipUntil.
+func (scanner *Scanner) Count(r rune) int {
+	savedPos := scanner.TextPosition
+	savedComplex := scanner.isComplexSinceMark
+
+	n := 0
+	for scanner.SkipUntil(r); scanner.Pop() != EOF; scanner.SkipUntil(r) {
+		n++
+	}
+
+	scanner.TextPosition = savedPos
+	scanner.isComplexSinceMark = savedComplex
+	return n
+}
+
+// lookUntil calls skip and returns the position it skipped to without advancing, and whether found reports a match at that position.
+// Skipping may stop in front of escaped line breaks trailing the text, so stopping before the end of the text is no match by itself.
+func (scanner *Scanner) lookUntil(skip func() Span, found func() bool) (TextPosition, bool) {
+	savedPos := scanner.TextPosition
+	savedComplex := scanner.isComplexSinceMark
+
+	end := skip().End
+	matched := found()
+
+	scanner.TextPosition = savedPos
+	scanner.isComplexSinceMark = savedComplex
+	return end, matched
+}
@@ -0,0 +1,144 @@
+package scanner
+
+import "testing"
+
+func TestScannerSkipUntil(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		skip     func(*Scanner) Span
+		expected int
+	}{
+		{name: "rune", input: "abc;d", skip: func(s *Scanner) Span { return s.SkipUntil(';') }, expected: 3},
+		{name: "rune at start", input: ";d", skip: func(s *Scanner) Span { return s.SkipUntil(';') }, expected: 0},
+		{name: "rune missing", input: "abc", skip: func(s *Scanner) Span { return s.SkipUntil(';') }, expected: 3},
+		{name: "end of line", input: "ab\r\ncd", skip: func(s *Scanner) Span { return s.SkipUntil('\n') }, expected: 2},
+		{name: "any", input: "hello, world.", skip: func(s *Scanner) Span { return s.SkipUntilAny(".,") }, expected: 5},
+		{name: "any missing", input: "hello", skip: func(s *Scanner) Span { return s.SkipUntilAny(".,") }, expected: 5},
+		{name: "string", input: "a * b */ c", skip: func(s *Scanner) Span { return s.SkipUntilString("*/") }, expected: 6},
+		{name: "string missing", input: "a * b", skip: func(s *Scanner) Span { return s.SkipUntilString("*/") }, expected: 5},
+		{name: "string at start", input: "*/", skip: func(s *Scanner) Span { return s.SkipUntilString("*/") }, expected: 0},
+		{name: "string overlapping", input: "aaab", skip: func(s *Scanner) Span { return s.SkipUntilString("aab") }, expected: 1},
+		{name: "string across escaped line break", input: "x *\\\n/", skip: func(s *Scanner) Span { return s.SkipUntilString("*/") }, expected: 2},
+		{name: "empty string", input: "abc", skip: func(s *Scanner) Span { return s.SkipUntilString("") }, expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			span := tt.skip(scanner)
+			if span.Start.Offset != 0 || span.End.Offset != tt.expected {
+				t.Errorf("skipped [%d, %d), expected [0, %d)", span.Start.Offset, span.End.Offset, tt.expected)
+			}
+			if scanner.Offset != tt.expected {
+				t.Errorf("expected offset %d, got %d", tt.expected, scanner.Offset)
+			}
+		})
+	}
+}
+
+func TestScannerIndexOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		index    func(*Scanner) (TextPosition, bool)
+		expected int
+		found    bool
+	}{
+		{name: "rune", input: "key: value", index: func(s *Scanner) (TextPosition, bool) { return s.IndexOf(':') }, expected: 3, found: true},
+		{name: "rune at start", input: ":x", index: func(s *Scanner) (TextPosition, bool) { return s.IndexOf(':') }, expected: 0, found: true},
+		{name: "rune missing", input: "abc", index: func(s *Scanner) (TextPosition, bool) { return s.IndexOf(':') }, expected: 3, found: false},
+		{name: "rune missing before escaped line break", input: "a\\\n", index: func(s *Scanner) (TextPosition, bool) { return s.IndexOf('x') }, expected: 1, found: false},
+		{name: "line break", input: "ab\r\ncd", index: func(s *Scanner) (TextPosition, bool) { return s.IndexOf('\n') }, expected: 2, found: true},
+		{name: "string", input: "a -> b", index: func(s *Scanner) (TextPosition, bool) { return s.IndexOfString("->") }, expected: 2, found: true},
+		{name: "string missing", input: "a - > b", index: func(s *Scanner) (TextPosition, bool) { return s.IndexOfString("->") }, expected: 7, found: false},
+		{name: "string missing before escaped line break", input: "a\\\r\n", index: func(s *Scanner) (TextPosition, bool) { return s.IndexOfString("->") }, expected: 4, found: false},
+		{name: "empty string", input: "abc", index: func(s *Scanner) (TextPosition, bool) { return s.IndexOfString("") }, expected: 0, found: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			pos, found := tt.index(scanner)
+			if pos.Offset != tt.expected || found != tt.found {
+				t.Errorf("got offset %d, %v, expected %d, %v", pos.Offset, found, tt.expected, tt.found)
+			}
+			if scanner.Offset != 0 {
+				t.Errorf("expected nothing consumed, got offset %d", scanner.Offset)
+			}
+		})
+	}
+}
+
+func TestScannerIndexOfPosition(t *testing.T) {
+	scanner := NewScanner("ab\ncd:e")
+	scanner.Pop()
+
+	pos, found := scanner.IndexOf(':')
+	if !found || pos.Line != 2 || pos.Col != 3 {
+		t.Errorf("IndexOf() = %s, %v, expected 2:3, true", pos, found)
+	}
+
+	colon, _ := scanner.IndexOf(':')
+	newline, _ := scanner.IndexOf('\n')
+	if !newline.Before(colon) {
+		t.Errorf("expected line break at %s before colon at %s", newline, colon)
+	}
+}
+
+func TestScannerCount(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		r        rune
+		expected int
+	}{
+		{name: "none", input: "abc", r: ',', expected: 0},
+		{name: "several", input: "a,b,,c,", r: ',', expected: 4},
+		{name: "line breaks", input: "a\r\nb\rc\nd", r: '\n', expected: 3},
+		{name: "escaped line break skipped", input: "a\\\nb\n", r: '\n', expected: 1},
+		{name: "empty", input: "", r: 'a', expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			if n := scanner.Count(tt.r); n != tt.expected {
+				t.Errorf("Count() = %d, expected %d", n, tt.expected)
+			}
+			if scanner.Offset != 0 {
+				t.Errorf("expected nothing consumed, got offset %d", scanner.Offset)
+			}
+		})
+	}
+}
+
+func TestScannerReadUntilAny(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		set      string
+		include  bool
+		expected string
+	}{
+		{name: "excluded", input: `value" x`, set: `">`, include: false, expected: "value"},
+		{name: "included", input: `value" x`, set: `">`, include: true, expected: `value"`},
+		{name: "first of set", input: "a>b\"", set: `">`, include: true, expected: "a>"},
+		{name: "immediate", input: ">", set: `">`, include: false, expected: ""},
+		{name: "missing", input: "abc", set: `">`, include: true, expected: "abc"},
+		{name: "line break included", input: "ab\r\ncd", set: "\n", include: true, expected: "ab\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			result, span := scanner.ReadUntilAny(tt.set, tt.include)
+			if result != tt.expected {
+				t.Errorf("ReadUntilAny() = %q, expected %q", result, tt.expected)
+			}
+			if span.Start.Offset != 0 || span.End.Offset != scanner.Offset {
+				t.Errorf("ReadUntilAny() span [%d, %d), expected [0, %d)", span.Start.Offset, span.End.Offset, scanner.Offset)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package scanner
+
+import "sort"
+
+// A SourceMapping maps a span of generated text to the span of original text it was generated from.
+type SourceMapping struct {
+	// Generated is the span within the generated text.
+	Generated Span
+	// Original is the span within the original text.
+	Original Span
+}
+
+// A SourceMap maps spans of generated text (e.g. the output of a template engine) back to the original text.
+// The zero value is an empty SourceMap ready to use. Generated spans must not overlap.
+type SourceMap struct {
+	mappings []SourceMapping // sorted by generated start offset
+}
+
+// Add records that the generated span was generated from the original span.
+// A mapping with the same generated start offset as a previously added one replaces it.
+func (sourceMap *SourceMap) Add(generated, original Span) {
+	mapping := SourceMapping{Generated: generated, Original: original}
+	mappings := sourceMap.mappings
+	i := sort.Search(len(mappings), func(i int) bool { return mappings[i].Generated.Start.Offset >= generated.Start.Offset })
+	if i < len(mappings) && mappings[i].Generated.Start.Offset == generated.Start.Offset {
+		mappings[i] = mapping
+		return
+	}
+
+	sourceMap.mappings = append(mappings[:i], append([]SourceMapping{mapping}, mappings[i:]...)...)
+}
+
+// Lookup returns the mapping whose generated span contains the given position.
+// A position at the end of a generated span is considered contained if no other span starts there.
+func (sourceMap *SourceMap) Lookup(pos TextPosition) (SourceMapping, bool) {
+	mappings := sourceMap.mappings
+	i := sort.Search(len(mappings), func(i int) bool { return mappings[i].Generated.Start.Offset > pos.Offset }) - 1
+	if i < 0 {
+		return SourceMapping{}, false
+	}
+
+	if mapping := mappings[i]; mapping.Generated.Contains(pos) || mapping.Generated.End.Offset == pos.Offset {
+		return mapping, true
+	}
+	return SourceMapping{}, false
+}
+
+// WithSourceMap sets the SourceMap the text of the Scanner was generated with, see Scanner.AdjustedPos.
+func WithSourceMap(sourceMap *SourceMap) Option {
+	return func(scanner *Scanner) {
+		scanner.sourceMap = sourceMap
+	}
+}
+
+// mapToOriginal maps the given position through the SourceMap of the Scanner.
+// If the generated and original spans of the mapping have the same length, the generated text is assumed to be
+// a verbatim copy of the original and the position is mapped rune by rune. Otherwise, positions inside the
+// generated span map to the start of the original span and the end of the generated span maps to its end.
+func (scanner *Scanner) mapToOriginal(pos TextPosition) (TextPosition, bool) {
+	if scanner.sourceMap == nil {
+		return pos, false
+	}
+	mapping, ok := scanner.sourceMap.Lookup(pos)
+	if !ok {
+		return pos, false
+	}
+
+	generated, original := mapping.Generated, mapping.Original
+	switch {
+	case generated.Len() == original.Len() && generated.Start.Offset >= 0 && pos.Offset <= len(scanner.text):
+		copied := scanner.text[generated.Start.Offset:pos.Offset]
+		return AdvancePosition(original.Start, copied, scanner.positionOptions()...), true
+	case pos.Offset == generated.End.Offset:
+		return original.End, true
+	default:
+		return original.Start, true
+	}
+}
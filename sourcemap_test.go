@@ -0,0 +1,122 @@
+package scanner
+
+import "testing"
+
+func TestSourceMapLookup(t *testing.T) {
+	var sourceMap SourceMap
+	sourceMap.Add(spanAt(5, 8), spanAt(20, 30))
+	sourceMap.Add(spanAt(0, 3), spanAt(0, 3))
+	sourceMap.Add(spanAt(8, 10), spanAt(40, 42))
+
+	tests := []struct {
+		name     string
+		offset   int
+		expected Span // generated span, zero if not found
+	}{
+		{name: "start of first mapping", offset: 0, expected: spanAt(0, 3)},
+		{name: "end of first mapping", offset: 3, expected: spanAt(0, 3)},
+		{name: "gap between mappings", offset: 4, expected: Span{}},
+		{name: "inside mapping", offset: 6, expected: spanAt(5, 8)},
+		{name: "adjacent mappings prefer the starting one", offset: 8, expected: spanAt(8, 10)},
+		{name: "end of last mapping", offset: 10, expected: spanAt(8, 10)},
+		{name: "after last mapping", offset: 11, expected: Span{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapping, ok := sourceMap.Lookup(TextPosition{Offset: tt.offset, Line: 1, Col: tt.offset + 1})
+			if ok != (tt.expected != Span{}) {
+				t.Fatalf("Lookup(%d): expected found = %t, got %t", tt.offset, tt.expected != Span{}, ok)
+			}
+			if mapping.Generated != tt.expected {
+				t.Errorf("Lookup(%d): expected generated span %+v, got %+v", tt.offset, tt.expected, mapping.Generated)
+			}
+		})
+	}
+}
+
+func TestScannerAdjustedPosWithSourceMap(t *testing.T) {
+	// the template "Hi {{name}}!\nBye" in page.tmpl expanded to "Hi Bob!\nBye"
+	var sourceMap SourceMap
+	sourceMap.Add(
+		Span{Start: TextPosition{Offset: 0, Line: 1, Col: 1}, End: TextPosition{Offset: 3, Line: 1, Col: 4}},
+		Span{Start: TextPosition{Filename: "page.tmpl", Offset: 0, Line: 1, Col: 1}, End: TextPosition{Filename: "page.tmpl", Offset: 3, Line: 1, Col: 4}},
+	)
+	sourceMap.Add(
+		Span{Start: TextPosition{Offset: 3, Line: 1, Col: 4}, End: TextPosition{Offset: 6, Line: 1, Col: 7}},
+		Span{Start: TextPosition{Filename: "page.tmpl", Offset: 3, Line: 1, Col: 4}, End: TextPosition{Filename: "page.tmpl", Offset: 11, Line: 1, Col: 12}},
+	)
+	sourceMap.Add(
+		Span{Start: TextPosition{Offset: 6, Line: 1, Col: 7}, End: TextPosition{Offset: 11, Line: 2, Col: 4}},
+		Span{Start: TextPosition{Filename: "page.tmpl", Offset: 11, Line: 1, Col: 12}, End: TextPosition{Filename: "page.tmpl", Offset: 16, Line: 2, Col: 4}},
+	)
+
+	scanner := NewScanner("Hi Bob!\nBye", WithFilename("page.html"), WithSourceMap(&sourceMap))
+
+	tests := []struct {
+		name     string
+		offset   int
+		expected TextPosition
+	}{
+		{
+			name:     "verbatim text",
+			offset:   1,
+			expected: TextPosition{Filename: "page.tmpl", Offset: 1, Line: 1, Col: 2},
+		},
+		{
+			name:     "inside expansion",
+			offset:   4,
+			expected: TextPosition{Filename: "page.tmpl", Offset: 3, Line: 1, Col: 4},
+		},
+		{
+			name:     "verbatim text after expansion",
+			offset:   6,
+			expected: TextPosition{Filename: "page.tmpl", Offset: 11, Line: 1, Col: 12},
+		},
+		{
+			name:     "verbatim text across line break",
+			offset:   9,
+			expected: TextPosition{Filename: "page.tmpl", Offset: 14, Line: 2, Col: 2},
+		},
+		{
+			name:     "end of text",
+			offset:   11,
+			expected: TextPosition{Filename: "page.tmpl", Offset: 16, Line: 2, Col: 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := scanner.SetOffset(tt.offset); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if result := scanner.AdjustedPos(scanner.Pos()); result != tt.expected {
+				t.Errorf("AdjustedPos(%+v) = %+v, expected %+v", scanner.Pos(), result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestScannerAdjustedPosWithSourceMapEndOfExpansion(t *testing.T) {
+	var sourceMap SourceMap
+	sourceMap.Add(spanAt(0, 3), spanAt(10, 20))
+
+	scanner := NewScanner("abc", WithSourceMap(&sourceMap))
+	if result, expected := scanner.AdjustedPos(TextPosition{Offset: 3, Line: 1, Col: 4}), spanAt(10, 20).End; result != expected {
+		t.Errorf("AdjustedPos() = %+v, expected %+v", result, expected)
+	}
+}
+
+func TestScannerAdjustedPosWithoutSourceMapMapping(t *testing.T) {
+	var sourceMap SourceMap
+	sourceMap.Add(spanAt(0, 1), spanAt(10, 11))
+
+	scanner := NewScanner("a\nb", WithSourceMap(&sourceMap))
+	scanner.AddLineDirective(2, "orig.c", 7)
+
+	expected := TextPosition{Filename: "orig.c", Offset: 2, Line: 7, Col: 1}
+	if result := scanner.AdjustedPos(TextPosition{Offset: 2, Line: 2, Col: 1}); result != expected {
+		t.Errorf("AdjustedPos() = %+v, expected %+v", result, expected)
+	}
+}
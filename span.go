@@ -0,0 +1,156 @@
+package scanner
+
+import "strings"
+
+// A Span represents a range of text between two positions.
+type Span struct {
+	// Start is the position of the first rune in the span.
+	Start TextPosition
+	// End is the position after the last rune in the span.
+	End TextPosition
+}
+
+// Len returns the length of the span in bytes.
+func (span Span) Len() int {
+	return span.End.Offset - span.Start.Offset
+}
+
+// Contains returns whether the given position lies within the span.
+// The start of the span is included, its end is not.
+func (span Span) Contains(pos TextPosition) bool {
+	return span.Start.Offset <= pos.Offset && pos.Offset < span.End.Offset
+}
+
+// Overlaps returns whether the span and the other span share at least one byte of text.
+// Empty spans never overlap.
+func (span Span) Overlaps(other Span) bool {
+	if span.Len() <= 0 || other.Len() <= 0 {
+		return false
+	}
+	return span.Start.Offset < other.End.Offset && other.Start.Offset < span.End.Offset
+}
+
+// Union returns the smallest span covering both the span and the other span, including any text in between.
+func (span Span) Union(other Span) Span {
+	union := span
+	if other.Start.Offset < union.Start.Offset {
+		union.Start = other.Start
+	}
+	if other.End.Offset > union.End.Offset {
+		union.End = other.End
+	}
+	return union
+}
+
+// TextOf returns the text covered by the given span.
+// Line breaks are normalized and escaped line breaks are skipped the same way Scanner.Slice does.
+// An empty string is returned if the span does not lie within the text of the Scanner.
+func (scanner *Scanner) TextOf(span Span) string {
+	return normalize(scanner.RawTextOf(span))
+}
+
+// RawTextOf returns the text covered by the given span exactly as it appears in the text of the Scanner, without any normalization.
+// An empty string is returned if the span does not lie within the text of the Scanner.
+func (scanner *Scanner) RawTextOf(span Span) string {
+	start, end := span.Start.Offset, span.End.Offset
+	if start < 0 || end > len(scanner.text) || start > end {
+		return ""
+	}
+	return scanner.text[start:end]
+}
+
+// A LexemeSegment maps a piece of the normalized text of a span, as returned by Scanner.TextOf, back to the raw text it was taken from.
+type LexemeSegment struct {
+	// Offset is the byte offset of the piece within the normalized text.
+	Offset int
+	// Text is the normalized piece of text.
+	Text string
+	// Raw is the span of the raw text of the Scanner the piece was taken from.
+	Raw Span
+}
+
+// Segments splits the normalized text of the given span, e.g. the lexeme of a token, into pieces along with the raw text they were
+// taken from, so that tools can edit the raw text precisely. Each run of text taken over verbatim forms one segment,
+// each line break normalized from "\r\n" or "\r" forms a segment of its own and escaped line breaks, which are skipped,
+// form no segment at all, leaving a gap between the raw spans of the surrounding segments.
+// Nil is returned if the span does not lie within the text of the Scanner.
+func (scanner *Scanner) Segments(span Span) []LexemeSegment {
+	start, end := span.Start.Offset, span.End.Offset
+	if start < 0 || end > len(scanner.text) || start > end {
+		return nil
+	}
+
+	raw := scanner.text[start:end]
+	var segments []LexemeSegment
+	pos, offset := span.Start, 0
+	add := func(text string, rawLen int) {
+		rawStart := pos.Offset - start
+		next := AdvancePosition(pos, raw[rawStart:rawStart+rawLen], scanner.positionOptions()...)
+		if text != "" {
+			segments = append(segments, LexemeSegment{Offset: offset, Text: text, Raw: Span{Start: pos, End: next}})
+			offset += len(text)
+		}
+		pos = next
+	}
+
+	run := 0
+	for i := 0; i < len(raw); {
+		switch {
+		case raw[i] == '\\' && lineBreakLen(raw[i+1:]) > 0:
+			add(raw[run:i], i-run)
+			n := 1 + lineBreakLen(raw[i+1:])
+			add("", n)
+			i += n
+		case raw[i] == '\r':
+			add(raw[run:i], i-run)
+			n := lineBreakLen(raw[i:])
+			add("\n", n)
+			i += n
+		default:
+			i++
+			continue
+		}
+		run = i
+	}
+	add(raw[run:], len(raw)-run)
+	return segments
+}
+
+// lineBreakLen returns the length of the line break at the start of text, 0 if there is none.
+func lineBreakLen(text string) int {
+	switch {
+	case strings.HasPrefix(text, "\r\n"):
+		return 2
+	case strings.HasPrefix(text, "\n"), strings.HasPrefix(text, "\r"):
+		return 1
+	}
+	return 0
+}
+
+// RawOffset maps a byte offset within the normalized text of the given span, as returned by Scanner.TextOf, to the corresponding
+// byte offset within the raw text of the Scanner, e.g. to find where a character of a lexeme is in the source.
+// An offset within a normalized line break maps to the start of the raw line break and the end of the normalized text maps to
+// the end of the span. It returns false if the offset does not lie within the normalized text or the span not within the text of the Scanner.
+func (scanner *Scanner) RawOffset(span Span, offset int) (int, bool) {
+	start, end := span.Start.Offset, span.End.Offset
+	if start < 0 || end > len(scanner.text) || start > end || offset < 0 {
+		return 0, false
+	}
+
+	length := 0
+	for _, segment := range scanner.Segments(span) {
+		length = segment.Offset + len(segment.Text)
+		if offset >= length {
+			continue
+		}
+		if len(segment.Text) != segment.Raw.Len() {
+			return segment.Raw.Start.Offset, true
+		}
+		return segment.Raw.Start.Offset + offset - segment.Offset, true
+	}
+
+	if offset == length {
+		return end, true
+	}
+	return 0, false
+}
@@ -0,0 +1,263 @@
+package scanner
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func spanAt(start, end int) Span {
+	return Span{
+		Start: TextPosition{Offset: start, Line: 1, Col: start + 1},
+		End:   TextPosition{Offset: end, Line: 1, Col: end + 1},
+	}
+}
+
+func TestSpanLen(t *testing.T) {
+	tests := []struct {
+		name     string
+		span     Span
+		expected int
+	}{
+		{name: "empty", span: spanAt(3, 3), expected: 0},
+		{name: "single byte", span: spanAt(0, 1), expected: 1},
+		{name: "several bytes", span: spanAt(2, 7), expected: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.span.Len(); result != tt.expected {
+				t.Errorf("Len() = %d, expected %d", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSpanContains(t *testing.T) {
+	tests := []struct {
+		name     string
+		span     Span
+		offset   int
+		expected bool
+	}{
+		{name: "before start", span: spanAt(2, 5), offset: 1, expected: false},
+		{name: "at start", span: spanAt(2, 5), offset: 2, expected: true},
+		{name: "inside", span: spanAt(2, 5), offset: 3, expected: true},
+		{name: "last byte", span: spanAt(2, 5), offset: 4, expected: true},
+		{name: "at end", span: spanAt(2, 5), offset: 5, expected: false},
+		{name: "empty span", span: spanAt(2, 2), offset: 2, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pos := TextPosition{Offset: tt.offset, Line: 1, Col: tt.offset + 1}
+			if result := tt.span.Contains(pos); result != tt.expected {
+				t.Errorf("Contains(%d) = %t, expected %t", tt.offset, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSpanOverlaps(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     Span
+		expected bool
+	}{
+		{name: "disjoint", a: spanAt(0, 2), b: spanAt(3, 5), expected: false},
+		{name: "adjacent", a: spanAt(0, 2), b: spanAt(2, 5), expected: false},
+		{name: "overlapping", a: spanAt(0, 3), b: spanAt(2, 5), expected: true},
+		{name: "nested", a: spanAt(0, 5), b: spanAt(1, 2), expected: true},
+		{name: "identical", a: spanAt(1, 4), b: spanAt(1, 4), expected: true},
+		{name: "empty inside", a: spanAt(0, 5), b: spanAt(2, 2), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.a.Overlaps(tt.b); result != tt.expected {
+				t.Errorf("a.Overlaps(b) = %t, expected %t", result, tt.expected)
+			}
+			if result := tt.b.Overlaps(tt.a); result != tt.expected {
+				t.Errorf("b.Overlaps(a) = %t, expected %t", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSpanUnion(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     Span
+		expected Span
+	}{
+		{name: "disjoint", a: spanAt(0, 2), b: spanAt(4, 6), expected: spanAt(0, 6)},
+		{name: "overlapping", a: spanAt(0, 3), b: spanAt(2, 5), expected: spanAt(0, 5)},
+		{name: "nested", a: spanAt(0, 5), b: spanAt(1, 2), expected: spanAt(0, 5)},
+		{name: "reversed", a: spanAt(4, 6), b: spanAt(0, 2), expected: spanAt(0, 6)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.a.Union(tt.b); result != tt.expected {
+				t.Errorf("Union() = %+v, expected %+v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRuneSpanEmbedsSpan(t *testing.T) {
+	scanner := NewScanner("αb")
+	span := scanner.PopSpan()
+
+	if span.Len() != 2 {
+		t.Errorf("Len() = %d, expected 2", span.Len())
+	}
+	if !span.Contains(TextPosition{Offset: 0, Line: 1, Col: 1}) {
+		t.Errorf("expected span %+v to contain its start", span)
+	}
+	if span.Contains(span.End) {
+		t.Errorf("expected span %+v not to contain its end", span)
+	}
+}
+
+func TestRuneSpanPos(t *testing.T) {
+	scanner := NewScanner("a\\\nb")
+	spans := []RuneSpan{scanner.PeekSpan(), scanner.PopSpan(), scanner.PopSpan(), scanner.PrevSpan(), scanner.LookAheadSpan(2)}
+
+	for _, span := range spans {
+		if span.Pos != span.Start {
+			t.Errorf("Pos = %+v, expected Start %+v", span.Pos, span.Start)
+		}
+	}
+}
+
+func TestScannerTextOf(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		start, end  int
+		expected    string
+		expectedRaw string
+	}{
+		{name: "whole text", input: "abc", start: 0, end: 3, expected: "abc", expectedRaw: "abc"},
+		{name: "middle", input: "abcde", start: 1, end: 4, expected: "bcd", expectedRaw: "bcd"},
+		{name: "empty span", input: "abc", start: 1, end: 1, expected: "", expectedRaw: ""},
+		{name: "UTF-8 characters", input: "αβγ", start: 2, end: 6, expected: "βγ", expectedRaw: "βγ"},
+		{name: "CRLF normalized", input: "a\r\nb", start: 0, end: 4, expected: "a\nb", expectedRaw: "a\r\nb"},
+		{name: "CR normalized", input: "a\rb", start: 0, end: 3, expected: "a\nb", expectedRaw: "a\rb"},
+		{name: "continuation skipped", input: "a\\\nb", start: 0, end: 4, expected: "ab", expectedRaw: "a\\\nb"},
+		{name: "end past text", input: "abc", start: 1, end: 4, expected: "", expectedRaw: ""},
+		{name: "negative start", input: "abc", start: -1, end: 2, expected: "", expectedRaw: ""},
+		{name: "reversed span", input: "abc", start: 2, end: 1, expected: "", expectedRaw: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			span := Span{Start: TextPosition{Offset: tt.start}, End: TextPosition{Offset: tt.end}}
+
+			if result := scanner.TextOf(span); result != tt.expected {
+				t.Errorf("TextOf() = %q, expected %q", result, tt.expected)
+			}
+			if result := scanner.RawTextOf(span); result != tt.expectedRaw {
+				t.Errorf("RawTextOf() = %q, expected %q", result, tt.expectedRaw)
+			}
+		})
+	}
+}
+
+func TestScannerTextOfMatchesSlice(t *testing.T) {
+	scanner := NewScanner("ab\r\ncd\\\nef")
+	scanner.Pop()
+	scanner.Mark()
+	start := scanner.Pos()
+	for range 5 {
+		scanner.Pop()
+	}
+
+	span := Span{Start: start, End: scanner.Pos()}
+	if result, expected := scanner.TextOf(span), scanner.Slice(); result != expected {
+		t.Errorf("TextOf() = %q, Slice() = %q", result, expected)
+	}
+}
+
+func TestScannerSegments(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string // normalized text, then raw start and end offset, of each segment
+	}{
+		{name: "verbatim", input: "abc", expected: []string{`"abc" [0,3)`}},
+		{name: "LF kept", input: "a\nb", expected: []string{`"a\nb" [0,3)`}},
+		{name: "CRLF", input: "a\r\nb", expected: []string{`"a" [0,1)`, `"\n" [1,3)`, `"b" [3,4)`}},
+		{name: "CR", input: "\rb", expected: []string{`"\n" [0,1)`, `"b" [1,2)`}},
+		{name: "continuation", input: "ab\\\r\ncd", expected: []string{`"ab" [0,2)`, `"cd" [5,7)`}},
+		{name: "escaped backslash", input: "a\\\\\nb", expected: []string{`"a\\" [0,2)`, `"b" [4,5)`}},
+		{name: "only continuation", input: "\\\n", expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input)
+			span := Span{Start: scanner.Pos(), End: AdvancePosition(scanner.Pos(), tt.input)}
+
+			var result []string
+			var joined strings.Builder
+			for _, segment := range scanner.Segments(span) {
+				if segment.Offset != joined.Len() {
+					t.Errorf("segment %q at offset %d, expected %d", segment.Text, segment.Offset, joined.Len())
+				}
+				joined.WriteString(segment.Text)
+				result = append(result, fmt.Sprintf("%q [%d,%d)", segment.Text, segment.Raw.Start.Offset, segment.Raw.End.Offset))
+			}
+			if !slices.Equal(result, tt.expected) {
+				t.Errorf("Segments() = %v, expected %v", result, tt.expected)
+			}
+			if joined.String() != scanner.TextOf(span) {
+				t.Errorf("joined segments = %q, expected TextOf() = %q", joined.String(), scanner.TextOf(span))
+			}
+		})
+	}
+}
+
+func TestScannerSegmentsPositions(t *testing.T) {
+	scanner := NewScanner("x\r\ny\\\nz")
+	segments := scanner.Segments(Span{Start: scanner.Pos(), End: AdvancePosition(scanner.Pos(), scanner.Text())})
+
+	expected := []string{"1:1", "1:2", "2:1", "3:1"}
+	if len(segments) != len(expected) {
+		t.Fatalf("Segments() returned %d segments, expected %d", len(segments), len(expected))
+	}
+	for i, segment := range segments {
+		if start := segment.Raw.Start.String(); start != expected[i] {
+			t.Errorf("segment %d %q starts at %s, expected %s", i, segment.Text, start, expected[i])
+		}
+	}
+}
+
+func TestScannerRawOffset(t *testing.T) {
+	scanner := NewScanner("ab\\\ncd\r\ne")
+	span := Span{Start: scanner.Pos(), End: AdvancePosition(scanner.Pos(), scanner.Text())}
+
+	tests := []struct {
+		offset   int
+		expected int
+		ok       bool
+	}{
+		{offset: 0, expected: 0, ok: true},
+		{offset: 1, expected: 1, ok: true},
+		{offset: 2, expected: 4, ok: true},
+		{offset: 4, expected: 6, ok: true},
+		{offset: 5, expected: 8, ok: true},
+		{offset: 6, expected: 9, ok: true},
+		{offset: 7, expected: 0, ok: false},
+		{offset: -1, expected: 0, ok: false},
+	}
+
+	for _, tt := range tests {
+		if result, ok := scanner.RawOffset(span, tt.offset); result != tt.expected || ok != tt.ok {
+			t.Errorf("RawOffset(%d) = %d, %v, expected %d, %v", tt.offset, result, ok, tt.expected, tt.ok)
+		}
+	}
+}
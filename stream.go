@@ -0,0 +1,164 @@
+package scanner
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// A TokenSource produces tokens one at a time, such as a Tokenizer.
+type TokenSource interface {
+	// Next returns the next token. At the end of the text, it returns a token of kind TokenEOF.
+	Next() (Token, error)
+}
+
+// A TokenStream buffers the tokens of a TokenSource to provide the arbitrary lookahead and the conditional consumption
+// recursive-descent parsers are written against.
+// Once the source returns an error, the stream returns that error in place of any further token.
+type TokenStream struct {
+	source      TokenSource
+	buffer      []Token // tokens read from the source, consumed up to pos
+	pos         int     // index of the next token in buffer
+	checkpoints int     // number of checkpoints not discarded yet, which keep the consumed tokens in buffer
+	err         error   // the error returned by the source after the buffered tokens
+}
+
+// NewTokenStream creates a TokenStream reading from the given source.
+func NewTokenStream(source TokenSource) *TokenStream {
+	return &TokenStream{source: source}
+}
+
+// fill reads tokens from the source until k+1 tokens are buffered and reports whether it succeeded.
+// Reading stops at the first error or once a TokenEOF token is buffered, which stands for all tokens after it.
+func (stream *TokenStream) fill(k int) bool {
+	for len(stream.buffer)-stream.pos <= k {
+		if stream.err != nil {
+			return false
+		}
+		if n := len(stream.buffer); n > 0 && stream.buffer[n-1].Kind == TokenEOF {
+			return true
+		}
+
+		token, err := stream.source.Next()
+		if err != nil {
+			stream.err = err
+			return false
+		}
+		stream.buffer = append(stream.buffer, token)
+	}
+	return true
+}
+
+// Peek returns the token k tokens ahead without consuming anything, Peek(0) being the next token.
+// Past the end of the text, the TokenEOF token is returned. If the source fails before that token, its error is returned.
+func (stream *TokenStream) Peek(k int) (Token, error) {
+	if !stream.fill(k) {
+		return Token{}, stream.err
+	}
+	return stream.buffer[stream.pos+min(max(k, 0), len(stream.buffer)-stream.pos-1)], nil
+}
+
+// Next consumes and returns the next token. At the end of the text, the TokenEOF token is returned any number of times.
+func (stream *TokenStream) Next() (Token, error) {
+	token, err := stream.Peek(0)
+	if err == nil && token.Kind != TokenEOF {
+		stream.pos++
+		stream.compact()
+	}
+	return token, err
+}
+
+// compact drops the consumed tokens from the buffer unless a checkpoint may still restore them.
+func (stream *TokenStream) compact() {
+	if stream.checkpoints == 0 {
+		stream.buffer = stream.buffer[stream.pos:]
+		stream.pos = 0
+	}
+}
+
+// Accept consumes the next token if it is of the given kind and returns it along with whether it was consumed.
+// If the source fails, nothing is consumed and false is returned.
+func (stream *TokenStream) Accept(kind TokenKind) (Token, bool) {
+	token, err := stream.Peek(0)
+	if err != nil || token.Kind != kind {
+		return token, false
+	}
+	stream.Next()
+	return token, true
+}
+
+// Expect consumes the next token if it is of the given kind and returns it. Otherwise, an *Error about the span of the next token
+// wrapping ErrUnexpected and describing both kinds is returned, e.g. `expected Ident but found Number "3" at 1:5`, and nothing is consumed.
+// If the source fails, its error is returned.
+func (stream *TokenStream) Expect(kind TokenKind) (Token, error) {
+	token, err := stream.Peek(0)
+	if err != nil {
+		return Token{}, err
+	}
+	if token.Kind != kind {
+		msg := fmt.Sprintf("%v: expected %s but found %s %s", ErrUnexpected, kind, token.Kind, strconv.Quote(token.Lexeme))
+		return token, &Error{Span: token.Span, Msg: msg, Err: ErrUnexpected}
+	}
+	stream.Next()
+	return token, nil
+}
+
+// A StreamCheckpoint is a saved state of a TokenStream, created by TokenStream.Save.
+type StreamCheckpoint struct {
+	state *streamCheckpointState
+}
+
+// streamCheckpointState is the state of a TokenStream captured by a StreamCheckpoint.
+type streamCheckpointState struct {
+	stream    *TokenStream
+	pos       int
+	discarded bool
+}
+
+// Save returns a StreamCheckpoint capturing the position of the TokenStream, to be restored with TokenStream.Restore,
+// so that a parser with limited backtracking can speculatively consume tokens, e.g. to tell a cast from a parenthesized expression.
+// While the checkpoint is not discarded, the stream keeps the tokens consumed after it, so that restoring it replays them
+// instead of rewinding the source. The state of the source, such as the position of the Scanner of a Tokenizer or the indentation
+// levels of an Offside filter, thus always stays consistent with the tokens the stream returns.
+func (stream *TokenStream) Save() StreamCheckpoint {
+	stream.checkpoints++
+	return StreamCheckpoint{state: &streamCheckpointState{stream: stream, pos: stream.pos}}
+}
+
+// Restore resets the TokenStream to the position captured by the given StreamCheckpoint, so that the tokens consumed since
+// are returned again. A checkpoint can be restored any number of times until it is discarded.
+// Restore panics if the checkpoint was discarded or not created by Save of the same stream.
+func (stream *TokenStream) Restore(checkpoint StreamCheckpoint) {
+	state := checkpoint.state
+	if state == nil || state.stream != stream {
+		panic("scanner: restore of foreign StreamCheckpoint")
+	}
+	if state.discarded {
+		panic("scanner: restore of discarded StreamCheckpoint")
+	}
+	stream.pos = state.pos
+}
+
+// Discard releases the given StreamCheckpoint once it is no longer needed, so that the stream can drop the tokens kept for it.
+// The checkpoint must not be restored afterwards. Discarding a checkpoint more than once has no effect.
+func (stream *TokenStream) Discard(checkpoint StreamCheckpoint) {
+	state := checkpoint.state
+	if state == nil || state.stream != stream || state.discarded {
+		return
+	}
+	state.discarded = true
+	stream.checkpoints--
+	stream.compact()
+}
+
+// Try runs fn on the TokenStream and returns its result. If fn returns false, the tokens it consumed are given back,
+// as if fn had never run. If fn returns true, they stay consumed.
+func (stream *TokenStream) Try(fn func(*TokenStream) bool) bool {
+	checkpoint := stream.Save()
+	defer stream.Discard(checkpoint)
+
+	if !fn(stream) {
+		stream.Restore(checkpoint)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,179 @@
+package scanner
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTokenStreamPeek(t *testing.T) {
+	stream := NewTokenStream(NewTokenizer(NewScanner("a < 1"), testRules()...))
+
+	for k, expected := range []TokenKind{testIdent, testOperator, testNumber, TokenEOF, TokenEOF} {
+		token, err := stream.Peek(k)
+		if err != nil {
+			t.Fatalf("Peek(%d) unexpected error: %v", k, err)
+		}
+		if token.Kind != expected {
+			t.Errorf("Peek(%d) = %s, expected %s", k, token, expected)
+		}
+	}
+
+	if token, _ := stream.Next(); token.Lexeme != "a" {
+		t.Errorf("Next() = %s, expected the first token after peeking", token)
+	}
+	if token, _ := stream.Peek(1); token.Lexeme != "1" {
+		t.Errorf("Peek(1) = %s, expected \"1\"", token)
+	}
+}
+
+func TestTokenStreamAcceptExpect(t *testing.T) {
+	stream := NewTokenStream(NewTokenizer(NewScanner("x = 3"), testRules()...))
+
+	if token, ok := stream.Accept(testNumber); ok {
+		t.Errorf("Accept(number) = %s, true, expected false", token)
+	}
+	if token, ok := stream.Accept(testIdent); !ok || token.Lexeme != "x" {
+		t.Errorf("Accept(ident) = %s, %v, expected \"x\", true", token, ok)
+	}
+
+	_, err := stream.Expect(testIdent)
+	if !errors.Is(err, ErrUnexpected) {
+		t.Fatalf("Expect(ident) error = %v, expected ErrUnexpected", err)
+	}
+	if message := `unexpected input: expected Ident but found Operator "=" at 1:3`; err.Error() != message {
+		t.Errorf("Expect(ident) error = %q, expected %q", err.Error(), message)
+	}
+	var positional *Error
+	if !errors.As(err, &positional) || positional.Span.Start.Offset != 2 || positional.Span.End.Offset != 3 {
+		t.Errorf("Expect(ident) error = %#v, expected an *Error about the span of \"=\"", err)
+	}
+
+	if token, err := stream.Expect(testOperator); err != nil || token.Lexeme != "=" {
+		t.Errorf("Expect(operator) = %s, %v, expected \"=\", nil", token, err)
+	}
+	if token, err := stream.Expect(testNumber); err != nil || token.Lexeme != "3" {
+		t.Errorf("Expect(number) = %s, %v, expected \"3\", nil", token, err)
+	}
+	if token, ok := stream.Accept(TokenEOF); !ok {
+		t.Errorf("Accept(EOF) = %s, false, expected true", token)
+	}
+	if token, _ := stream.Next(); token.Kind != TokenEOF {
+		t.Errorf("Next() after EOF = %s, expected EOF", token)
+	}
+}
+
+// failingSource is a TokenSource returning its tokens and then failing with its error.
+type failingSource struct {
+	tokens []Token
+	err    error
+}
+
+func (source *failingSource) Next() (Token, error) {
+	if len(source.tokens) == 0 {
+		return Token{}, source.err
+	}
+	token := source.tokens[0]
+	source.tokens = source.tokens[1:]
+	return token, nil
+}
+
+func TestTokenStreamSourceError(t *testing.T) {
+	stream := NewTokenStream(&failingSource{tokens: []Token{{Kind: testIdent, Lexeme: "a"}}, err: ErrUnexpected})
+
+	if _, err := stream.Peek(1); !errors.Is(err, ErrUnexpected) {
+		t.Errorf("Peek(1) error = %v, expected ErrUnexpected", err)
+	}
+	if token, err := stream.Next(); err != nil || token.Lexeme != "a" {
+		t.Errorf("Next() = %s, %v, expected the token before the error", token, err)
+	}
+	if _, ok := stream.Accept(testIdent); ok {
+		t.Errorf("Accept() = true, expected false at the error")
+	}
+	for range 2 {
+		if _, err := stream.Next(); !errors.Is(err, ErrUnexpected) {
+			t.Errorf("Next() error = %v, expected the error to persist", err)
+		}
+	}
+}
+
+func TestTokenStreamSaveRestore(t *testing.T) {
+	stream := NewTokenStream(NewTokenizer(NewScanner("a < b = c"), testRules()...))
+	stream.Next()
+
+	outer := stream.Save()
+	stream.Next()
+	inner := stream.Save()
+	stream.Next()
+	stream.Next()
+
+	stream.Restore(inner)
+	if token, _ := stream.Next(); token.Lexeme != "b" {
+		t.Errorf("Next() after restoring the inner checkpoint = %s, expected \"b\"", token)
+	}
+	stream.Discard(inner)
+
+	stream.Restore(outer)
+	if token, _ := stream.Next(); token.Lexeme != "<" {
+		t.Errorf("Next() after restoring the outer checkpoint = %s, expected \"<\"", token)
+	}
+	stream.Restore(outer)
+	stream.Discard(outer)
+	stream.Discard(outer)
+
+	var lexemes []string
+	for {
+		token, _ := stream.Next()
+		if token.Kind == TokenEOF {
+			break
+		}
+		lexemes = append(lexemes, token.Lexeme)
+	}
+	if result := strings.Join(lexemes, " "); result != "< b = c" {
+		t.Errorf("remaining tokens = %q, expected %q", result, "< b = c")
+	}
+	if len(stream.buffer) != 1 {
+		t.Errorf("buffer holds %d tokens after discarding all checkpoints, expected only the TokenEOF token", len(stream.buffer))
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Restore() of a discarded checkpoint did not panic")
+		}
+	}()
+	stream.Restore(outer)
+}
+
+func TestTokenStreamTry(t *testing.T) {
+	// Tells a cast "(T) x" from a parenthesized expression "(a)" by speculatively parsing the cast first.
+	cast := func(stream *TokenStream) bool {
+		_, open := stream.Accept(testOperator)
+		_, typ := stream.Accept(testIdent)
+		_, closing := stream.Accept(testOperator)
+		_, operand := stream.Accept(testIdent)
+		return open && typ && closing && operand
+	}
+	rules := append(testRules(), LiteralRule(testOperator, "("), LiteralRule(testOperator, ")"))
+
+	tests := []struct {
+		name     string
+		input    string
+		expected bool
+		next     string
+	}{
+		{name: "cast", input: "(T) x", expected: true, next: ""},
+		{name: "expression", input: "(a) < 1", expected: false, next: "("},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := NewTokenStream(NewTokenizer(NewScanner(tt.input), rules...))
+			if result := stream.Try(cast); result != tt.expected {
+				t.Errorf("Try() = %v, expected %v", result, tt.expected)
+			}
+			if token, _ := stream.Next(); token.Lexeme != tt.next {
+				t.Errorf("Next() = %s, expected %q", token, tt.next)
+			}
+		})
+	}
+}
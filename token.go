@@ -0,0 +1,214 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// TokenKind identifies the kind of a Token. Kinds are created with NewTokenKind or KindNamespace.NewKind, which give them a name
+// and number them from 1 on. Kinds must not be defined as plain constants, which would alias the kinds created with the same number.
+type TokenKind int
+
+const (
+	// TokenEOF is the kind of the token a Tokenizer produces at the end of the text.
+	TokenEOF TokenKind = -1
+	// TokenError is the kind of the tokens a Tokenizer produces for input none of its rules recognize.
+	TokenError TokenKind = -2
+)
+
+// ErrUnknownTokenKind is returned when a name does not identify a TokenKind, see TokenKind.UnmarshalText.
+var ErrUnknownTokenKind = errors.New("unknown token kind")
+
+// tokenKinds holds the kinds created by NewTokenKind and KindNamespace.NewKind, the kind n being described by kinds[n-1].
+var tokenKinds struct {
+	sync.RWMutex
+	kinds      []kindInfo
+	byName     map[string]TokenKind // by qualified name, 0 if several kinds share the name
+	namespaces map[string]bool
+}
+
+// kindInfo describes a TokenKind.
+type kindInfo struct {
+	namespace string
+	name      string
+}
+
+// newKind registers a kind with the given namespace and name. The lock of tokenKinds must be held.
+func newKind(namespace, name string) TokenKind {
+	tokenKinds.kinds = append(tokenKinds.kinds, kindInfo{namespace: namespace, name: name})
+	kind := TokenKind(len(tokenKinds.kinds))
+
+	if tokenKinds.byName == nil {
+		tokenKinds.byName = make(map[string]TokenKind)
+	}
+	qualified := qualifiedName(namespace, name)
+	if _, ok := tokenKinds.byName[qualified]; ok {
+		tokenKinds.byName[qualified] = 0
+	} else {
+		tokenKinds.byName[qualified] = kind
+	}
+	return kind
+}
+
+// qualifiedName returns the name of a kind qualified by its namespace, if any.
+func qualifiedName(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
+
+// NewTokenKind creates a new TokenKind with the given name, which is returned by its String method.
+// Each call returns a distinct kind, starting at 1, so packages can define their kinds without coordinating with each other.
+// Kinds of different packages may share a name though, e.g. "String"; libraries should create their kinds in a KindNamespace,
+// which keeps their names apart when kinds are serialized by name.
+// NewTokenKind is safe for concurrent use, but is usually called when initializing package-level variables.
+func NewTokenKind(name string) TokenKind {
+	tokenKinds.Lock()
+	defer tokenKinds.Unlock()
+
+	return newKind("", name)
+}
+
+// String returns the name of the kind given to NewTokenKind or KindNamespace.NewKind, "EOF" for TokenEOF, "Error" for TokenError
+// or "TokenKind(n)" for kinds without a name.
+func (kind TokenKind) String() string {
+	if info, ok := kind.info(); ok {
+		return info.name
+	}
+	return kind.fallbackName()
+}
+
+// QualifiedName returns the name of the kind prefixed with the name of its KindNamespace and a dot, e.g. "json.String",
+// or the same as TokenKind.String for kinds without a namespace.
+func (kind TokenKind) QualifiedName() string {
+	if info, ok := kind.info(); ok {
+		return qualifiedName(info.namespace, info.name)
+	}
+	return kind.fallbackName()
+}
+
+// info returns the description of a kind created by NewTokenKind or KindNamespace.NewKind.
+func (kind TokenKind) info() (kindInfo, bool) {
+	tokenKinds.RLock()
+	defer tokenKinds.RUnlock()
+	if kind >= 1 && int(kind) <= len(tokenKinds.kinds) {
+		return tokenKinds.kinds[kind-1], true
+	}
+	return kindInfo{}, false
+}
+
+// fallbackName returns the name of a kind not created by NewTokenKind or KindNamespace.NewKind.
+func (kind TokenKind) fallbackName() string {
+	switch kind {
+	case TokenEOF:
+		return "EOF"
+	case TokenError:
+		return "Error"
+	}
+	return "TokenKind(" + strconv.Itoa(int(kind)) + ")"
+}
+
+// MarshalText implements encoding.TextMarshaler, encoding the kind as its TokenKind.QualifiedName,
+// so that kinds can be exchanged by name with other processes, in which the numbers of the kinds may differ.
+func (kind TokenKind) MarshalText() ([]byte, error) {
+	return []byte(kind.QualifiedName()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, decoding a kind from its TokenKind.QualifiedName like LookupTokenKind.
+// If no single kind has the name, an error wrapping ErrUnknownTokenKind is returned.
+func (kind *TokenKind) UnmarshalText(text []byte) error {
+	found, ok := LookupTokenKind(string(text))
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownTokenKind, text)
+	}
+	*kind = found
+	return nil
+}
+
+// A KindNamespace groups the TokenKinds of a library under a unique name, e.g. "json" for a JSON lexer,
+// so that their qualified names, such as "json.String", identify them across libraries and processes.
+type KindNamespace struct {
+	name string
+}
+
+// NewKindNamespace creates a KindNamespace with the given name.
+// NewKindNamespace panics if the name is empty or a namespace with the same name already exists, as two libraries would collide.
+func NewKindNamespace(name string) *KindNamespace {
+	tokenKinds.Lock()
+	defer tokenKinds.Unlock()
+
+	if name == "" {
+		panic("scanner: empty KindNamespace name")
+	}
+	if tokenKinds.namespaces[name] {
+		panic(fmt.Sprintf("scanner: KindNamespace %q already exists", name))
+	}
+	if tokenKinds.namespaces == nil {
+		tokenKinds.namespaces = make(map[string]bool)
+	}
+	tokenKinds.namespaces[name] = true
+	return &KindNamespace{name: name}
+}
+
+// Name returns the name of the namespace.
+func (namespace *KindNamespace) Name() string {
+	return namespace.name
+}
+
+// NewKind creates a new TokenKind with the given name in the namespace. Its String method returns the name,
+// while its TokenKind.QualifiedName is prefixed with the name of the namespace and a dot.
+// NewKind panics if the namespace already has a kind with the same name.
+func (namespace *KindNamespace) NewKind(name string) TokenKind {
+	tokenKinds.Lock()
+	defer tokenKinds.Unlock()
+
+	if _, ok := tokenKinds.byName[qualifiedName(namespace.name, name)]; ok {
+		panic(fmt.Sprintf("scanner: token kind %q already exists in KindNamespace %q", name, namespace.name))
+	}
+	return newKind(namespace.name, name)
+}
+
+// LookupTokenKind returns the TokenKind with the given qualified name, as returned by TokenKind.QualifiedName, and whether there is one.
+// Kinds created by NewTokenKind are found by their name only if no other kind has the same qualified name.
+func LookupTokenKind(qualifiedName string) (TokenKind, bool) {
+	switch qualifiedName {
+	case "EOF":
+		return TokenEOF, true
+	case "Error":
+		return TokenError, true
+	}
+
+	tokenKinds.RLock()
+	defer tokenKinds.RUnlock()
+	kind := tokenKinds.byName[qualifiedName]
+	return kind, kind != 0
+}
+
+// A Token is a lexeme of the text recognized by a Tokenizer.
+type Token struct {
+	// Kind is the kind of the token, set by the rule that recognized it.
+	Kind TokenKind
+	// Lexeme is the text of the token, normalized the same way Scanner.Slice does.
+	Lexeme string
+	// Span is the range the token covers.
+	Span
+	// Value is an optional value derived from the lexeme, such as the number a number token stands for. See TokenRule.WithValue.
+	// For tokens of kind TokenError, it is the error describing the unrecognized input.
+	Value any
+	// Mode is the name of the mode of the Tokenizer the token was recognized in, "" for the default mode. See Tokenizer.DefineMode.
+	Mode string
+	// Leading holds the trivia in front of the token, i.e. the tokens of skipped rules such as whitespace and comments,
+	// if the Tokenizer is configured with Tokenizer.SetAttachTrivia. It includes the trivia on the lines before the token.
+	Leading []Token
+	// Trailing holds the trivia after the token up to the end of its line, if the Tokenizer is configured with Tokenizer.SetAttachTrivia.
+	// Trivia containing a line break belongs to the leading trivia of the next token.
+	Trailing []Token
+}
+
+// String returns the token formatted as "kind lexeme at line:col", with the lexeme quoted, e.g. `Ident "x" at 1:5`.
+func (token Token) String() string {
+	return token.Kind.String() + " " + strconv.Quote(token.Lexeme) + " at " + token.Start.String()
+}
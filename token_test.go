@@ -0,0 +1,128 @@
+package scanner
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+	"unicode"
+)
+
+func TestNewTokenKind(t *testing.T) {
+	ident := NewTokenKind("Ident")
+	number := NewTokenKind("Number")
+
+	if ident == number || ident < 1 || number < 1 {
+		t.Errorf("NewTokenKind() = %d, %d, expected distinct positive kinds", ident, number)
+	}
+	if ident.String() != "Ident" || number.String() != "Number" {
+		t.Errorf("String() = %q, %q, expected \"Ident\", \"Number\"", ident.String(), number.String())
+	}
+	if s := TokenEOF.String(); s != "EOF" {
+		t.Errorf("TokenEOF.String() = %q, expected \"EOF\"", s)
+	}
+	if s := TokenKind(-42).String(); s != "TokenKind(-42)" {
+		t.Errorf("String() = %q, expected \"TokenKind(-42)\"", s)
+	}
+}
+
+// Namespaces must be unique within the process, so the tests create theirs once.
+var (
+	testNamespace    = NewKindNamespace("test.namespace")
+	testNamespaceStr = testNamespace.NewKind("String")
+	testMarshal      = NewKindNamespace("test.marshal")
+	testMarshalKinds = []TokenKind{testMarshal.NewKind("Ident"), TokenEOF, testMarshal.NewKind("Number")}
+)
+
+func TestKindNamespace(t *testing.T) {
+	namespace, str := testNamespace, testNamespaceStr
+	plain := NewTokenKind("String")
+
+	if str == plain || str.String() != "String" {
+		t.Errorf("NewKind() = %d named %q, expected a new kind named \"String\"", str, str.String())
+	}
+	if name := str.QualifiedName(); name != "test.namespace.String" {
+		t.Errorf("QualifiedName() = %q, expected %q", name, "test.namespace.String")
+	}
+	if name := TokenEOF.QualifiedName(); name != "EOF" {
+		t.Errorf("TokenEOF.QualifiedName() = %q, expected \"EOF\"", name)
+	}
+
+	tests := []struct {
+		name     string
+		expected TokenKind
+		ok       bool
+	}{
+		{name: "test.namespace.String", expected: str, ok: true},
+		{name: "EOF", expected: TokenEOF, ok: true},
+		{name: "Error", expected: TokenError, ok: true},
+		{name: "String", expected: 0, ok: false},
+		{name: "test.namespace.Number", expected: 0, ok: false},
+	}
+	for _, tt := range tests {
+		if kind, ok := LookupTokenKind(tt.name); kind != tt.expected || ok != tt.ok {
+			t.Errorf("LookupTokenKind(%q) = %d, %v, expected %d, %v", tt.name, kind, ok, tt.expected, tt.ok)
+		}
+	}
+
+	for _, fn := range []func(){
+		func() { NewKindNamespace("test.namespace") },
+		func() { NewKindNamespace("") },
+		func() { namespace.NewKind("String") },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected a panic on a colliding or empty name")
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+func TestTokenKindMarshalText(t *testing.T) {
+	kinds := testMarshalKinds
+
+	data, err := json.Marshal(kinds)
+	if err != nil {
+		t.Fatalf("Marshal() unexpected error: %v", err)
+	}
+	if expected := `["test.marshal.Ident","EOF","test.marshal.Number"]`; string(data) != expected {
+		t.Errorf("Marshal() = %s, expected %s", data, expected)
+	}
+
+	var decoded []TokenKind
+	if err := json.Unmarshal(data, &decoded); err != nil || !slices.Equal(decoded, kinds) {
+		t.Errorf("Unmarshal() = %v, %v, expected %v", decoded, err, kinds)
+	}
+
+	var kind TokenKind
+	if err := kind.UnmarshalText([]byte("test.marshal.Missing")); !errors.Is(err, ErrUnknownTokenKind) {
+		t.Errorf("UnmarshalText() error = %v, expected ErrUnknownTokenKind", err)
+	}
+}
+
+func TestTokenString(t *testing.T) {
+	token := Token{Kind: NewTokenKind("String"), Lexeme: `"a"`, Span: Span{Start: TextPosition{Line: 2, Col: 5}}}
+	if s, expected := token.String(), `String "\"a\"" at 2:5`; s != expected {
+		t.Errorf("String() = %q, expected %q", s, expected)
+	}
+}
+
+func TestTokenRuleWithValue(t *testing.T) {
+	number := NewTokenKind("Number")
+	rule := PredicateRule(number, unicode.IsDigit).WithValue(func(lexeme string) any {
+		n, _ := strconv.Atoi(lexeme)
+		return n
+	})
+
+	token, err := NewTokenizer(NewScanner("42"), rule).Next()
+	if err != nil {
+		t.Fatalf("Next() unexpected error: %v", err)
+	}
+	if token.Kind != number || token.Value != 42 {
+		t.Errorf("Next() = %s with value %v, expected Number with value 42", token, token.Value)
+	}
+}
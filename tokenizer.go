@@ -0,0 +1,651 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// A TokenRule recognizes tokens of one kind for a Tokenizer.
+type TokenRule struct {
+	kind  TokenKind
+	match func(*Scanner) bool
+	skip  bool
+	value func(lexeme string) any // set by WithValue
+
+	re     *regexp.Regexp                   // set by RegexpRule
+	choose func(*Scanner) (TokenRule, bool) // set by CompileLexer, matches in place of match and returns the rule that matched
+
+	keywords    map[string]TokenKind // set by WithKeywords and WithKeywordsFold
+	keywordFold bool                 // keys of keywords are case folded
+
+	embed func(region Token) []TokenRule // set by Embed and EmbedFunc
+
+	enterMode string // set by EnterMode, the mode pushed after a token of the rule
+	exitMode  bool   // set by ExitMode, whether the mode is popped after a token of the rule
+}
+
+// LiteralRule creates a TokenRule recognizing the given literal text, such as an operator or a keyword, compared like by Scanner.ConsumeString.
+func LiteralRule(kind TokenKind, literal string) TokenRule {
+	return TokenRule{kind: kind, match: func(scanner *Scanner) bool { return scanner.ConsumeString(literal) }}
+}
+
+// PredicateRule creates a TokenRule recognizing the longest non-empty run of runes for which pred returns true, like Scanner.TakeWhile.
+func PredicateRule(kind TokenKind, pred func(rune) bool) TokenRule {
+	return TokenRule{kind: kind, match: func(scanner *Scanner) bool {
+		_, span := scanner.TakeWhile(pred)
+		return span.Len() > 0
+	}}
+}
+
+// RegexpRule creates a TokenRule recognizing the text matched by re at the current position, like Scanner.Match.
+func RegexpRule(kind TokenKind, re *regexp.Regexp) TokenRule {
+	return TokenRule{kind: kind, re: re, match: func(scanner *Scanner) bool {
+		_, _, ok := scanner.Match(re)
+		return ok
+	}}
+}
+
+// FuncRule creates a TokenRule recognizing the text consumed by fn if it returns true.
+// If fn returns false, any text it consumed is given back, so fn does not need to restore the state of the Scanner itself.
+func FuncRule(kind TokenKind, fn func(*Scanner) bool) TokenRule {
+	return TokenRule{kind: kind, match: fn}
+}
+
+// Skipped returns a copy of the rule whose tokens are discarded by the Tokenizer instead of being produced, e.g. for whitespace.
+func (rule TokenRule) Skipped() TokenRule {
+	rule.skip = true
+	return rule
+}
+
+// WithValue returns a copy of the rule that sets the Value of its tokens to the result of convert called with their lexeme,
+// e.g. to attach the parsed number to a number token.
+func (rule TokenRule) WithValue(convert func(lexeme string) any) TokenRule {
+	rule.value = convert
+	return rule
+}
+
+// WithKeywords returns a copy of the rule that reclassifies its tokens whose lexeme is a key of keywords into the mapped kind,
+// e.g. an identifier rule producing keyword tokens for "if" and "for". Other tokens keep the kind of the rule.
+func (rule TokenRule) WithKeywords(keywords map[string]TokenKind) TokenRule {
+	rule.keywords, rule.keywordFold = keywords, false
+	return rule
+}
+
+// WithKeywordsFold is like TokenRule.WithKeywords, but lexemes are matched to the keys of keywords under Unicode simple case folding,
+// as in languages with case-insensitive keywords like SQL or Pascal.
+func (rule TokenRule) WithKeywordsFold(keywords map[string]TokenKind) TokenRule {
+	folded := make(map[string]TokenKind, len(keywords))
+	for keyword, kind := range keywords {
+		folded[foldCase(keyword)] = kind
+	}
+	rule.keywords, rule.keywordFold = folded, true
+	return rule
+}
+
+// EnterMode returns a copy of the rule that makes the Tokenizer push the given mode after each of its tokens, like Tokenizer.PushMode,
+// e.g. a rule for "${" inside a string literal entering the mode of the embedded expression.
+func (rule TokenRule) EnterMode(mode string) TokenRule {
+	rule.enterMode, rule.exitMode = mode, false
+	return rule
+}
+
+// ExitMode returns a copy of the rule that makes the Tokenizer pop the current mode after each of its tokens, like Tokenizer.PopMode,
+// e.g. a rule for the "}" closing an embedded expression.
+func (rule TokenRule) ExitMode() TokenRule {
+	rule.enterMode, rule.exitMode = "", true
+	return rule
+}
+
+// kindOf returns the kind of a token with the given lexeme recognized by the rule.
+func (rule TokenRule) kindOf(lexeme string) TokenKind {
+	if rule.keywordFold {
+		lexeme = foldCase(lexeme)
+	}
+	if kind, ok := rule.keywords[lexeme]; ok {
+		return kind
+	}
+	return rule.kind
+}
+
+// foldCase maps each rune of s to the smallest rune equal to it under Unicode simple case folding,
+// so that two strings are equal after folding if and only if they match under strings.EqualFold.
+func foldCase(s string) string {
+	return strings.Map(func(r rune) rune {
+		smallest := r
+		for folded := unicode.SimpleFold(r); folded != r; folded = unicode.SimpleFold(folded) {
+			if folded < smallest {
+				smallest = folded
+			}
+		}
+		return smallest
+	}, s)
+}
+
+// A Tokenizer splits the text of a Scanner into Tokens according to a list of ordered rules.
+type Tokenizer struct {
+	scanner     *Scanner
+	rules       []TokenRule
+	resync      Resync // set by SetResync
+	stopOnError bool   // set by SetStopOnError
+	trivia      bool   // set by SetAttachTrivia
+	firstMatch  bool   // set by SetFirstMatch
+	lastRule    int    // index of the rule that matched last, -1 if none
+
+	modes     map[string][]TokenRule // set by DefineMode
+	modeStack []string               // the modes pushed on top of the default mode
+
+	terminator TerminatorHook // set by SetTerminatorHook
+	listener   TokenListener  // set by SetListener
+	previous   Token          // the last token produced, if hasPrevious
+	pending    Token          // the token to produce after an inserted terminator, if hasPending
+	embedded   []Token        // the tokens of an embedded region not produced yet
+
+	hasPrevious bool // false at the start and the end of the text
+	hasPending  bool
+}
+
+// A TerminatorHook decides whether a Tokenizer inserts a terminator token at the end of a line, given the last token on the line,
+// and returns the kind of the token to insert. See Tokenizer.SetTerminatorHook.
+type TerminatorHook func(previous Token) (TokenKind, bool)
+
+// A Resync skips the input a Tokenizer could not recognize, starting at the first unrecognized rune, to the position it resumes at.
+type Resync func(*Scanner)
+
+// ResyncRune skips only the unrecognized rune, so the Tokenizer produces one error token per unrecognized rune. This is the default.
+func ResyncRune(scanner *Scanner) {
+	scanner.Pop()
+}
+
+// ResyncWhitespace skips to the next whitespace rune, as skipped by Scanner.SkipWhitespace, or line break.
+func ResyncWhitespace(scanner *Scanner) {
+	scanner.TakeWhile(func(r rune) bool { return r != '\n' && !scanner.whitespace.isSpace(r) })
+}
+
+// ResyncLine skips to the end of the line, leaving the line break in front of the scanner.
+func ResyncLine(scanner *Scanner) {
+	scanner.SkipUntil('\n')
+}
+
+// NewTokenizer creates a Tokenizer producing tokens from the current position of the given Scanner on, according to the given rules.
+// At each position, the rule that recognizes the longest non-empty token wins (maximal munch), so "<=" is never split into "<" and "=",
+// whatever the order of the rules. If several rules recognize a token of the same length, the earliest of them wins,
+// e.g. a keyword rule listed before an identifier rule. Tokenizer.SetFirstMatch switches to the first rule that recognizes any token.
+// Tokenizer.LastRule exposes which rule produced the last token.
+func NewTokenizer(scanner *Scanner, rules ...TokenRule) *Tokenizer {
+	return &Tokenizer{scanner: scanner, rules: rules, lastRule: -1}
+}
+
+// SetResync sets how the Tokenizer skips input no rule recognizes. If resync does not consume anything, a single rune is skipped.
+func (tokenizer *Tokenizer) SetResync(resync Resync) {
+	tokenizer.resync = resync
+}
+
+// SetStopOnError sets whether the Tokenizer stops at input no rule recognizes instead of skipping it as configured with Tokenizer.SetResync,
+// for tools that give up at the first problem, such as compilers. Tokenizer.Next then returns an error wrapping ErrUnexpected along with
+// the error token, which is empty, and nothing is consumed, so that every further call fails the same way.
+func (tokenizer *Tokenizer) SetStopOnError(stop bool) {
+	tokenizer.stopOnError = stop
+}
+
+// SetFirstMatch sets whether the Tokenizer produces the token of the first rule in order that recognizes a non-empty token,
+// instead of the longest token recognized by any rule. Trying the rules in order is faster, but the rules must be ordered
+// so that no rule recognizes a prefix of a token of a later rule, e.g. "<" must come after "<=".
+func (tokenizer *Tokenizer) SetFirstMatch(first bool) {
+	tokenizer.firstMatch = first
+}
+
+// SetAttachTrivia sets whether the Tokenizer attaches the tokens of skipped rules to the tokens it produces as Token.Leading
+// and Token.Trailing trivia instead of discarding them, e.g. for formatters or to extract doc comments.
+func (tokenizer *Tokenizer) SetAttachTrivia(attach bool) {
+	tokenizer.trivia = attach
+}
+
+// SetTerminatorHook sets a hook the Tokenizer calls at the end of each line containing tokens and at the end of the text
+// with the last token produced, to implement automatic semicolon insertion like in Go or JavaScript. If the hook returns true,
+// a token of the returned kind with an empty lexeme and an empty span at the end of the previous token is produced before the next one.
+// Lines joined by escaped line breaks count as a single line, lines without tokens are ignored.
+func (tokenizer *Tokenizer) SetTerminatorHook(hook TerminatorHook) {
+	tokenizer.terminator = hook
+}
+
+// A TokenListener observes a Tokenizer, e.g. for an IDE integration or a debugger, without wrapping the tokens it produces.
+// See Tokenizer.SetListener.
+type TokenListener interface {
+	// OnToken is called with each token the Tokenizer produces, except error tokens, including inserted terminators and the TokenEOF token.
+	OnToken(token Token)
+	// OnError is called with each token of kind TokenError the Tokenizer produces.
+	OnError(token Token)
+	// OnModeChange is called when the Tokenizer changes from one mode to another, by a rule or by Tokenizer.PushMode or Tokenizer.PopMode.
+	OnModeChange(from, to string)
+}
+
+// ListenerFuncs implements TokenListener by calling the function of the same name, if it is not nil,
+// so that a listener only observing some events needs to provide only those.
+type ListenerFuncs struct {
+	Token      func(token Token)
+	Error      func(token Token)
+	ModeChange func(from, to string)
+}
+
+// OnToken calls funcs.Token if it is not nil.
+func (funcs ListenerFuncs) OnToken(token Token) {
+	if funcs.Token != nil {
+		funcs.Token(token)
+	}
+}
+
+// OnError calls funcs.Error if it is not nil.
+func (funcs ListenerFuncs) OnError(token Token) {
+	if funcs.Error != nil {
+		funcs.Error(token)
+	}
+}
+
+// OnModeChange calls funcs.ModeChange if it is not nil.
+func (funcs ListenerFuncs) OnModeChange(from, to string) {
+	if funcs.ModeChange != nil {
+		funcs.ModeChange(from, to)
+	}
+}
+
+// SetListener sets the TokenListener notified of the tokens the Tokenizer produces and the modes it changes to, nil for none.
+// Tokens produced by Tokenizer.NextSpan are reported without their lexeme and value.
+func (tokenizer *Tokenizer) SetListener(listener TokenListener) {
+	tokenizer.listener = listener
+}
+
+// DefineMode defines a named mode, in which the Tokenizer recognizes tokens by the given rules instead of the rules given to NewTokenizer.
+// Modes let a language switch rule sets mid-stream, e.g. between the text and the embedded expressions of an interpolated string,
+// by pushing a mode with Tokenizer.PushMode or TokenRule.EnterMode and returning to the previous one with Tokenizer.PopMode or TokenRule.ExitMode.
+// The rules given to NewTokenizer form the default mode, named "". Defining a mode again replaces its rules.
+func (tokenizer *Tokenizer) DefineMode(mode string, rules ...TokenRule) {
+	if mode == "" {
+		tokenizer.rules = rules
+		return
+	}
+	if tokenizer.modes == nil {
+		tokenizer.modes = make(map[string][]TokenRule)
+	}
+	tokenizer.modes[mode] = rules
+}
+
+// PushMode makes the Tokenizer recognize the following tokens in the given mode until it is popped again with Tokenizer.PopMode.
+// PushMode panics if the mode was not defined with Tokenizer.DefineMode.
+func (tokenizer *Tokenizer) PushMode(mode string) {
+	if _, ok := tokenizer.modes[mode]; !ok && mode != "" {
+		panic(fmt.Sprintf("scanner: push of undefined mode %q", mode))
+	}
+	from := tokenizer.Mode()
+	tokenizer.modeStack = append(tokenizer.modeStack, mode)
+	if tokenizer.listener != nil {
+		tokenizer.listener.OnModeChange(from, mode)
+	}
+}
+
+// PopMode returns the Tokenizer to the mode it was in before the current mode was pushed and returns whether there was a mode to pop.
+// The default mode is never popped.
+func (tokenizer *Tokenizer) PopMode() bool {
+	if len(tokenizer.modeStack) == 0 {
+		return false
+	}
+	from := tokenizer.Mode()
+	tokenizer.modeStack = tokenizer.modeStack[:len(tokenizer.modeStack)-1]
+	if tokenizer.listener != nil {
+		tokenizer.listener.OnModeChange(from, tokenizer.Mode())
+	}
+	return true
+}
+
+// Mode returns the name of the mode the Tokenizer is currently in, "" for the default mode.
+func (tokenizer *Tokenizer) Mode() string {
+	if len(tokenizer.modeStack) == 0 {
+		return ""
+	}
+	return tokenizer.modeStack[len(tokenizer.modeStack)-1]
+}
+
+// currentRules returns the rules of the current mode.
+func (tokenizer *Tokenizer) currentRules() []TokenRule {
+	if mode := tokenizer.Mode(); mode != "" {
+		return tokenizer.modes[mode]
+	}
+	return tokenizer.rules
+}
+
+// LastRule returns the index within the rules of the mode it was recognized in of the rule that recognized the last token consumed,
+// including the tokens of skipped rules, or -1 if no rule recognized the input, e.g. for error tokens.
+func (tokenizer *Tokenizer) LastRule() int {
+	return tokenizer.lastRule
+}
+
+// Scanner returns the Scanner the Tokenizer reads from. Its position is right after the last token produced.
+func (tokenizer *Tokenizer) Scanner() *Scanner {
+	return tokenizer.scanner
+}
+
+// Next consumes and returns the next token, skipping the tokens of skipped rules.
+// At the end of the text, a token of kind TokenEOF with an empty lexeme and span is returned, any number of times.
+// If no rule recognizes a token at the position, the input is skipped as configured with Tokenizer.SetResync and returned
+// as a token of kind TokenError, whose Value is an error wrapping ErrUnexpected describing the unrecognized rune and its position.
+// The returned error is nil unless the Tokenizer stops on errors (see Tokenizer.SetStopOnError), in which case it is the Value
+// of the error token.
+func (tokenizer *Tokenizer) Next() (Token, error) {
+	token := tokenizer.nextToken(true)
+	if token.Kind == TokenError && tokenizer.stopOnError {
+		return token, token.Value.(error)
+	}
+	return token, nil
+}
+
+// A TokenSpan is the compact form of a Token produced by Tokenizer.NextSpan, holding only its kind and span.
+type TokenSpan struct {
+	// Kind is the kind of the token.
+	Kind TokenKind
+	// Span is the range the token covers, from which the lexeme can be resolved with Scanner.TextOf when needed.
+	Span
+}
+
+// NextSpan consumes the next token like Tokenizer.Next, but returns only its kind and span. Neither the lexeme nor the value
+// of the token are computed and no trivia is attached, so that tokenizing does not allocate memory, unless the rules do,
+// e.g. RegexpRule, WithValue conversions run by a TerminatorHook or embedded regions, or the Tokenizer meets unrecognized input.
+func (tokenizer *Tokenizer) NextSpan() TokenSpan {
+	token := tokenizer.nextToken(false)
+	return TokenSpan{Kind: token.Kind, Span: token.Span}
+}
+
+// nextToken consumes and returns the next token like Tokenizer.nextUnobserved and reports it to the TokenListener.
+func (tokenizer *Tokenizer) nextToken(resolve bool) Token {
+	token := tokenizer.nextUnobserved(resolve)
+	if listener := tokenizer.listener; listener != nil {
+		if token.Kind == TokenError {
+			listener.OnError(token)
+		} else {
+			listener.OnToken(token)
+		}
+	}
+	return token
+}
+
+// nextUnobserved consumes and returns the next token, inserting terminators as configured with Tokenizer.SetTerminatorHook.
+// Unless resolve is true, the lexeme and value of the token are only computed as far as needed by the TerminatorHook,
+// and no trivia is attached.
+func (tokenizer *Tokenizer) nextUnobserved(resolve bool) Token {
+	if tokenizer.hasPending {
+		tokenizer.hasPending = false
+		return tokenizer.pending
+	}
+
+	hook := tokenizer.terminator
+	token := tokenizer.next(resolve || hook != nil)
+	previous, hasPrevious := tokenizer.previous, tokenizer.hasPrevious
+	tokenizer.previous, tokenizer.hasPrevious = token, token.Kind != TokenEOF
+
+	if hook == nil || !hasPrevious {
+		return token
+	}
+	if token.Kind != TokenEOF && !tokenizer.scanner.startsLogicalLine(token.Start.Line, previous.End.Line) {
+		return token
+	}
+	if kind, ok := hook(previous); ok {
+		tokenizer.pending, tokenizer.hasPending = token, true
+		terminator := emptyToken(kind, previous.End)
+		terminator.Mode = previous.Mode
+		return terminator
+	}
+	return token
+}
+
+// Tokens returns an iterator over the tokens produced by Tokenizer.Next, which ends at the end of the text
+// without yielding the TokenEOF token, or at the first unrecognized input if the Tokenizer stops on errors. Breaking out of the loop leaves the Tokenizer right after the last token yielded.
+func (tokenizer *Tokenizer) Tokens() iter.Seq[Token] {
+	return func(yield func(Token) bool) {
+		for {
+			token, err := tokenizer.Next()
+			if err != nil || token.Kind == TokenEOF || !yield(token) {
+				return
+			}
+		}
+	}
+}
+
+// StreamCtx returns a channel of the tokens produced by Tokenizer.Next, which is closed at the end of the text
+// without sending the TokenEOF token, or at the first unrecognized input if the Tokenizer stops on errors. Once the given context is canceled, the Tokenizer stops producing tokens,
+// closes the channel and releases its goroutine, even if the receiver stopped reading.
+// The Tokenizer and its Scanner must not be used otherwise until the channel is closed.
+func (tokenizer *Tokenizer) StreamCtx(ctx context.Context) <-chan Token {
+	ch := make(chan Token)
+
+	go func() {
+		defer close(ch)
+		for ctx.Err() == nil {
+			token, err := tokenizer.Next()
+			if err != nil || token.Kind == TokenEOF {
+				return
+			}
+			select {
+			case ch <- token:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// next consumes and returns the next token, without inserting terminators.
+// Unless resolve is true, the lexeme and value of the token are not computed and no trivia is attached.
+func (tokenizer *Tokenizer) next(resolve bool) Token {
+	if len(tokenizer.embedded) > 0 {
+		token := tokenizer.embedded[0]
+		tokenizer.embedded = tokenizer.embedded[1:]
+		return token
+	}
+
+	scanner := tokenizer.scanner
+	trivia := resolve && tokenizer.trivia
+	var leading []Token
+	for {
+		if tokenizer.atEnd() {
+			end := scanner.TextPosition
+			return Token{Kind: TokenEOF, Span: Span{Start: end, End: end}, Mode: tokenizer.Mode(), Leading: leading}
+		}
+		start := scanner.TextPosition
+
+		rule, ok := tokenizer.match()
+		if !ok {
+			complex := scanner.isComplexSinceMark
+			token := tokenizer.errorToken()
+			if tokenizer.stopOnError {
+				// the unrecognized input stays in front of the scanner
+				scanner.TextPosition, scanner.isComplexSinceMark = start, complex
+				token.Lexeme, token.Span = "", Span{Start: start, End: start}
+			}
+			token.Mode = tokenizer.Mode()
+			if trivia {
+				token.Leading, token.Trailing = leading, tokenizer.trailingTrivia()
+			}
+			return token
+		}
+
+		token := tokenizer.produce(rule, start, resolve || rule.embed != nil)
+		if rule.embed != nil {
+			if rules := rule.embed(token); len(rules) > 0 {
+				tokens, regionTrivia := tokenizer.tokenizeRegion(token.Span, rules)
+				if len(tokens) == 0 {
+					if trivia {
+						leading = append(leading, regionTrivia...)
+					}
+					continue
+				}
+				if trivia {
+					tokens[0].Leading = append(leading, tokens[0].Leading...)
+					last := &tokens[len(tokens)-1]
+					last.Trailing = append(last.Trailing, tokenizer.trailingTrivia()...)
+				}
+				tokenizer.embedded = tokens[1:]
+				return tokens[0]
+			}
+		}
+		if rule.skip {
+			if trivia {
+				leading = append(leading, token)
+			}
+			continue
+		}
+
+		if trivia {
+			token.Leading, token.Trailing = leading, tokenizer.trailingTrivia()
+		}
+		return token
+	}
+}
+
+// produce returns the token recognized by the rule from start to the current scanner position in the current mode
+// and switches the mode as configured on the rule. Unless resolve is true, the lexeme and value of the token are not computed.
+func (tokenizer *Tokenizer) produce(rule TokenRule, start TextPosition, resolve bool) Token {
+	token := rule.token(tokenizer.scanner, start, resolve)
+	token.Mode = tokenizer.Mode()
+	if rule.enterMode != "" {
+		tokenizer.PushMode(rule.enterMode)
+	} else if rule.exitMode {
+		tokenizer.PopMode()
+	}
+	return token
+}
+
+// token returns the token recognized by the rule from start to the current scanner position.
+// Unless resolve is true, the lexeme is only computed if it determines the kind of the token and the value is not computed.
+func (rule TokenRule) token(scanner *Scanner, start TextPosition, resolve bool) Token {
+	span := Span{Start: start, End: scanner.TextPosition}
+	if !resolve && rule.keywords == nil {
+		return Token{Kind: rule.kind, Span: span}
+	}
+
+	lexeme := scanner.TextOf(span)
+	token := Token{Kind: rule.kindOf(lexeme), Lexeme: lexeme, Span: span}
+	if resolve && rule.value != nil {
+		token.Value = rule.value(token.Lexeme)
+	}
+	return token
+}
+
+// atEnd returns whether the text is exhausted. Escaped line breaks at the end of the text, which Scanner.Pop skips
+// and thus no rule can match, are consumed.
+func (tokenizer *Tokenizer) atEnd() bool {
+	scanner := tokenizer.scanner
+	if scanner.IsEOF() {
+		return true
+	}
+	if scanner.text[scanner.Offset] != '\\' {
+		return false
+	}
+
+	savedPos := scanner.TextPosition
+	savedComplex := scanner.isComplexSinceMark
+	if scanner.Pop() == EOF {
+		return true
+	}
+	scanner.TextPosition = savedPos
+	scanner.isComplexSinceMark = savedComplex
+	return false
+}
+
+// trailingTrivia consumes the trivia following a token up to the end of its line and returns it, if trivia is attached.
+// The trivia containing the line break is left for the next token.
+func (tokenizer *Tokenizer) trailingTrivia() []Token {
+	if !tokenizer.trivia {
+		return nil
+	}
+
+	scanner := tokenizer.scanner
+	var trailing []Token
+	for !scanner.IsEOF() {
+		start := scanner.TextPosition
+		savedComplex := scanner.isComplexSinceMark
+		lastRule := tokenizer.lastRule
+
+		rule, ok := tokenizer.match()
+		if !ok || !rule.skip || scanner.Line != start.Line {
+			scanner.TextPosition = start
+			scanner.isComplexSinceMark = savedComplex
+			tokenizer.lastRule = lastRule
+			break
+		}
+		trailing = append(trailing, tokenizer.produce(rule, start, true))
+	}
+	return trailing
+}
+
+// errorToken skips the unrecognized input at the current position and returns it as a token of kind TokenError.
+func (tokenizer *Tokenizer) errorToken() Token {
+	scanner := tokenizer.scanner
+	start := scanner.PeekSpan()
+	err := scanner.ErrorfAt(start.Span, "%w: no token rule matches %s", ErrUnexpected, describeRune(start.Rune))
+
+	resync := tokenizer.resync
+	if resync == nil {
+		resync = ResyncRune
+	}
+	if resync(scanner); scanner.Offset <= start.Start.Offset {
+		scanner.TextPosition = start.End
+	}
+
+	span := Span{Start: start.Start, End: scanner.TextPosition}
+	return Token{Kind: TokenError, Lexeme: scanner.TextOf(span), Span: span, Value: err}
+}
+
+// match consumes the token recognized by the longest or first matching rule and returns the rule.
+// Rules that do not consume anything do not match.
+func (tokenizer *Tokenizer) match() (TokenRule, bool) {
+	scanner := tokenizer.scanner
+	start := scanner.state()
+	var best *TokenRule
+	var bestEnd scannerState
+
+	tokenizer.lastRule = -1
+	rules := tokenizer.currentRules()
+	for i := range rules {
+		rule := &rules[i]
+		if rule.choose != nil {
+			// matchAt replaces the rule by the rule chosen, which must not affect the rules of the Tokenizer
+			chosen := *rule
+			rule = &chosen
+		}
+
+		scanner.restoreState(start)
+		if !rule.matchAt(scanner) || scanner.Offset <= start.pos.Offset {
+			continue
+		}
+		if best == nil || scanner.Offset > bestEnd.pos.Offset {
+			tokenizer.lastRule, best, bestEnd = i, rule, scanner.state()
+			if tokenizer.firstMatch {
+				break
+			}
+		}
+	}
+
+	if best == nil {
+		scanner.restoreState(start)
+		return TokenRule{}, false
+	}
+	scanner.restoreState(bestEnd)
+	return *best, true
+}
+
+// matchAt consumes the token recognized by the rule and reports whether it recognized one.
+// For rules created by CompileLexer, the rule is replaced by the rule that matched.
+func (rule *TokenRule) matchAt(scanner *Scanner) bool {
+	if rule.choose == nil {
+		return rule.match(scanner)
+	}
+
+	chosen, ok := rule.choose(scanner)
+	if ok {
+		*rule = chosen
+	}
+	return ok
+}
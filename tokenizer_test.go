@@ -0,0 +1,584 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+var (
+	testIdent    = NewTokenKind("Ident")
+	testNumber   = NewTokenKind("Number")
+	testOperator = NewTokenKind("Operator")
+	testString   = NewTokenKind("String")
+	testSpace    = NewTokenKind("Space")
+)
+
+// testRules returns the rules of a small expression language used by the tokenizer tests.
+func testRules() []TokenRule {
+	return []TokenRule{
+		PredicateRule(testSpace, unicode.IsSpace).Skipped(),
+		RegexpRule(testNumber, regexp.MustCompile(`[0-9]+(\.[0-9]+)?`)),
+		FuncRule(testString, func(s *Scanner) bool {
+			_, _, err := s.ReadQuotedString('"')
+			return err == nil
+		}),
+		LiteralRule(testOperator, "<="),
+		LiteralRule(testOperator, "<"),
+		LiteralRule(testOperator, "="),
+		PredicateRule(testIdent, func(r rune) bool { return unicode.IsLetter(r) || r == '_' }),
+	}
+}
+
+func TestTokenizerNext(t *testing.T) {
+	tokenizer := NewTokenizer(NewScanner("x <= 3.5\n\"a b\"<y"), testRules()...)
+	expected := []struct {
+		kind   TokenKind
+		lexeme string
+		start  string
+	}{
+		{kind: testIdent, lexeme: "x", start: "1:1"},
+		{kind: testOperator, lexeme: "<=", start: "1:3"},
+		{kind: testNumber, lexeme: "3.5", start: "1:6"},
+		{kind: testString, lexeme: `"a b"`, start: "2:1"},
+		{kind: testOperator, lexeme: "<", start: "2:6"},
+		{kind: testIdent, lexeme: "y", start: "2:7"},
+		{kind: TokenEOF, lexeme: "", start: "2:8"},
+		{kind: TokenEOF, lexeme: "", start: "2:8"},
+	}
+
+	for i, want := range expected {
+		token, err := tokenizer.Next()
+		if err != nil {
+			t.Fatalf("token %d: Next() unexpected error: %v", i, err)
+		}
+		if token.Kind != want.kind || token.Lexeme != want.lexeme || token.Start.String() != want.start {
+			t.Errorf("token %d: Next() = %s, expected %s %q at %s", i, token, want.kind, want.lexeme, want.start)
+		}
+	}
+}
+
+func TestTokenizerTrailingEscapedLineBreak(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		kinds []TokenKind
+		end   int
+	}{
+		{name: "after token", input: "foo\\\n", kinds: []TokenKind{testIdent, TokenEOF}, end: 5},
+		{name: "after trivia", input: "foo \\\r\n", kinds: []TokenKind{testIdent, TokenEOF}, end: 7},
+		{name: "only", input: "\\\n\\\n", kinds: []TokenKind{TokenEOF}, end: 4},
+		{name: "continuing a token", input: "fo\\\no", kinds: []TokenKind{testIdent, TokenEOF}, end: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenizer := NewTokenizer(NewScanner(tt.input), testRules()...)
+			var kinds []TokenKind
+			for token := range tokenizer.Tokens() {
+				kinds = append(kinds, token.Kind)
+			}
+			last, _ := tokenizer.Next()
+			kinds = append(kinds, last.Kind)
+			if !slices.Equal(kinds, tt.kinds) {
+				t.Errorf("kinds = %v, expected %v", kinds, tt.kinds)
+			}
+			if last.Start.Offset != tt.end {
+				t.Errorf("TokenEOF at offset %d, expected %d", last.Start.Offset, tt.end)
+			}
+
+			spans := NewTokenizer(NewScanner(tt.input), testRules()...)
+			for span := spans.NextSpan(); span.Kind != TokenEOF; span = spans.NextSpan() {
+				if span.Kind == TokenError {
+					t.Errorf("NextSpan() = %v, expected no error token", span)
+				}
+			}
+		})
+	}
+}
+
+func TestTokenizerErrorTokens(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		resync   Resync
+		expected []string
+	}{
+		{name: "default", input: "a ?! b", resync: nil, expected: []string{`Ident "a"`, `Error "?"`, `Error "!"`, `Ident "b"`}},
+		{name: "whitespace", input: "a ?!b c", resync: ResyncWhitespace, expected: []string{`Ident "a"`, `Error "?!b"`, `Ident "c"`}},
+		{name: "line", input: "a ? b\nc", resync: ResyncLine, expected: []string{`Ident "a"`, `Error "? b"`, `Ident "c"`}},
+		{name: "resync consuming nothing", input: "??", resync: func(*Scanner) {}, expected: []string{`Error "?"`, `Error "?"`}},
+		{name: "failed rule gives back input", input: `"abc`, resync: nil, expected: []string{`Error "\""`, `Ident "abc"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenizer := NewTokenizer(NewScanner(tt.input), testRules()...)
+			tokenizer.SetResync(tt.resync)
+			for i, expected := range append(tt.expected, `EOF ""`) {
+				token, err := tokenizer.Next()
+				if err != nil {
+					t.Fatalf("token %d: Next() unexpected error: %v", i, err)
+				}
+				if result := token.Kind.String() + " " + strconv.Quote(token.Lexeme); result != expected {
+					t.Errorf("token %d: Next() = %s, expected %s", i, result, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestTokenizerErrorTokenValue(t *testing.T) {
+	tokenizer := NewTokenizer(NewScanner("a ?"), testRules()...)
+	tokenizer.Next()
+
+	token, _ := tokenizer.Next()
+	err, ok := token.Value.(error)
+	if token.Kind != TokenError || !ok || !errors.Is(err, ErrUnexpected) {
+		t.Fatalf("Next() = %s with value %v, expected an error token wrapping ErrUnexpected", token, token.Value)
+	}
+	if message := "unexpected input: no token rule matches '?' at 1:3"; err.Error() != message {
+		t.Errorf("error = %q, expected %q", err.Error(), message)
+	}
+	if token.Start.Offset != 2 || token.End.Offset != 3 {
+		t.Errorf("error token span [%d, %d), expected [2, 3)", token.Start.Offset, token.End.Offset)
+	}
+}
+
+func TestTokenizerStopOnError(t *testing.T) {
+	tokenizer := NewTokenizer(NewScanner("a ?b"), testRules()...)
+	tokenizer.SetStopOnError(true)
+	if token, err := tokenizer.Next(); err != nil || token.Lexeme != "a" {
+		t.Fatalf("Next() = %s, %v, expected \"a\", nil", token, err)
+	}
+
+	for range 2 {
+		token, err := tokenizer.Next()
+		if !errors.Is(err, ErrUnexpected) {
+			t.Fatalf("Next() error = %v, expected ErrUnexpected", err)
+		}
+		if token.Kind != TokenError || token.Lexeme != "" || token.Start.Offset != 2 || token.End.Offset != 2 {
+			t.Errorf("Next() = %s %+v, expected an empty error token at offset 2", token, token.Span)
+		}
+		if offset := tokenizer.Scanner().Offset; offset != 2 {
+			t.Errorf("unrecognized input consumed up to offset %d", offset)
+		}
+	}
+
+	tokenizer = NewTokenizer(NewScanner("a ?b"), testRules()...)
+	tokenizer.SetStopOnError(true)
+	var lexemes []string
+	for token := range tokenizer.Tokens() {
+		lexemes = append(lexemes, token.Lexeme)
+	}
+	if !slices.Equal(lexemes, []string{"a"}) {
+		t.Errorf("Tokens() = %q, expected [\"a\"]", lexemes)
+	}
+}
+
+func TestTokenizerLongestMatch(t *testing.T) {
+	keywordIn := NewTokenKind("In")
+	letters := func(r rune) bool { return unicode.IsLetter(r) }
+	rules := []TokenRule{
+		PredicateRule(testSpace, unicode.IsSpace).Skipped(),
+		LiteralRule(testOperator, "<"),
+		LiteralRule(testOperator, "="),
+		LiteralRule(testOperator, "<="),
+		LiteralRule(keywordIn, "in"),
+		PredicateRule(testIdent, letters),
+	}
+
+	tests := []struct {
+		name       string
+		firstMatch bool
+		input      string
+		expected   []string
+		lastRules  []int
+	}{
+		{name: "longest wins over order", input: "a<=b", expected: []string{`Ident "a"`, `Operator "<="`, `Ident "b"`}, lastRules: []int{5, 3, 5}},
+		{name: "tie won by earlier rule", input: "in inner", expected: []string{`In "in"`, `Ident "inner"`}, lastRules: []int{4, 5}},
+		{name: "first match splits", firstMatch: true, input: "a<=b", expected: []string{`Ident "a"`, `Operator "<"`, `Operator "="`, `Ident "b"`}, lastRules: []int{5, 1, 2, 5}},
+		{name: "first match keyword prefix", firstMatch: true, input: "inner", expected: []string{`In "in"`, `Ident "ner"`}, lastRules: []int{4, 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenizer := NewTokenizer(NewScanner(tt.input), rules...)
+			tokenizer.SetFirstMatch(tt.firstMatch)
+			for i, expected := range tt.expected {
+				token, err := tokenizer.Next()
+				if err != nil {
+					t.Fatalf("token %d: Next() unexpected error: %v", i, err)
+				}
+				if result := token.Kind.String() + " " + strconv.Quote(token.Lexeme); result != expected {
+					t.Errorf("token %d: Next() = %s, expected %s", i, result, expected)
+				}
+				if rule := tokenizer.LastRule(); rule != tt.lastRules[i] {
+					t.Errorf("token %d: LastRule() = %d, expected %d", i, rule, tt.lastRules[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTokenizerKeywords(t *testing.T) {
+	keywordIf := NewTokenKind("If")
+	keywordSelect := NewTokenKind("Select")
+	letters := func(r rune) bool { return unicode.IsLetter(r) }
+
+	tests := []struct {
+		name     string
+		rule     TokenRule
+		input    string
+		expected []TokenKind
+	}{
+		{name: "exact", rule: PredicateRule(testIdent, letters).WithKeywords(map[string]TokenKind{"if": keywordIf}), input: "if iff If", expected: []TokenKind{keywordIf, testIdent, testIdent}},
+		{name: "fold", rule: PredicateRule(testIdent, letters).WithKeywordsFold(map[string]TokenKind{"select": keywordSelect}), input: "SELECT Select selects", expected: []TokenKind{keywordSelect, keywordSelect, testIdent}},
+		{name: "fold Kelvin sign", rule: PredicateRule(testIdent, letters).WithKeywordsFold(map[string]TokenKind{"kelvin": keywordIf}), input: "\u212Aelvin", expected: []TokenKind{keywordIf}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenizer := NewTokenizer(NewScanner(tt.input), PredicateRule(testSpace, unicode.IsSpace).Skipped(), tt.rule)
+			for i, expected := range tt.expected {
+				token, err := tokenizer.Next()
+				if err != nil {
+					t.Fatalf("Next() unexpected error: %v", err)
+				}
+				if token.Kind != expected {
+					t.Errorf("token %d: Next() = %s, expected %s", i, token, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestTokenizerAttachTrivia(t *testing.T) {
+	comment := NewTokenKind("Comment")
+	rules := append([]TokenRule{FuncRule(comment, func(s *Scanner) bool {
+		if !s.ConsumeString("//") {
+			return false
+		}
+		s.SkipUntil('\n')
+		return true
+	}).Skipped()}, testRules()...)
+
+	tokenizer := NewTokenizer(NewScanner("// doc\nx = 1 // one\n  y"), rules...)
+	tokenizer.SetAttachTrivia(true)
+
+	expected := []struct {
+		lexeme   string
+		leading  []string
+		trailing []string
+	}{
+		{lexeme: "x", leading: []string{"// doc", "\n"}, trailing: []string{" "}},
+		{lexeme: "=", leading: nil, trailing: []string{" "}},
+		{lexeme: "1", leading: nil, trailing: []string{" ", "// one"}},
+		{lexeme: "y", leading: []string{"\n  "}, trailing: nil},
+		{lexeme: "", leading: nil, trailing: nil},
+	}
+
+	lexemes := func(tokens []Token) []string {
+		var result []string
+		for _, token := range tokens {
+			result = append(result, token.Lexeme)
+		}
+		return result
+	}
+	for i, want := range expected {
+		token, _ := tokenizer.Next()
+		leading, trailing := lexemes(token.Leading), lexemes(token.Trailing)
+		if token.Lexeme != want.lexeme || !slices.Equal(leading, want.leading) || !slices.Equal(trailing, want.trailing) {
+			t.Errorf("token %d: Next() = %q with trivia %q, %q, expected %q with %q, %q", i, token.Lexeme, leading, trailing, want.lexeme, want.leading, want.trailing)
+		}
+	}
+}
+
+func TestTokenizerTriviaDroppedByDefault(t *testing.T) {
+	token, _ := NewTokenizer(NewScanner("  x  "), testRules()...).Next()
+	if token.Leading != nil || token.Trailing != nil {
+		t.Errorf("Next() = %s with trivia %v, %v, expected none", token, token.Leading, token.Trailing)
+	}
+}
+
+func TestTokenizerTerminatorHook(t *testing.T) {
+	semicolon := NewTokenKind("Semicolon")
+	// like in Go, a line ending in an identifier, number or string is terminated
+	hook := func(previous Token) (TokenKind, bool) {
+		return semicolon, previous.Kind == testIdent || previous.Kind == testNumber || previous.Kind == testString
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{name: "lines", input: "a = 1\nb", expected: []string{"Ident:a", "Operator:=", "Number:1", "Semicolon:", "Ident:b", "Semicolon:", "EOF:"}},
+		{name: "line ending in operator", input: "a =\n1", expected: []string{"Ident:a", "Operator:=", "Number:1", "Semicolon:", "EOF:"}},
+		{name: "blank lines", input: "a\n\n\nb\n", expected: []string{"Ident:a", "Semicolon:", "Ident:b", "Semicolon:", "EOF:"}},
+		{name: "continuation", input: "a \\\nb", expected: []string{"Ident:a", "Ident:b", "Semicolon:", "EOF:"}},
+		{name: "empty", input: "\n", expected: []string{"EOF:"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenizer := NewTokenizer(NewScanner(tt.input), testRules()...)
+			tokenizer.SetTerminatorHook(hook)
+			if tokens := collectTokens(t, tokenizer); !slices.Equal(tokens, tt.expected) {
+				t.Errorf("got tokens %q, expected %q", tokens, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTokenizerTerminatorPosition(t *testing.T) {
+	tokenizer := NewTokenizer(NewScanner("ab  \ncd"), testRules()...)
+	tokenizer.SetTerminatorHook(func(Token) (TokenKind, bool) { return testOperator, true })
+
+	tokenizer.Next()
+	token, _ := tokenizer.Next()
+	if token.Kind != testOperator || token.Start.String() != "1:3" || token.End.String() != "1:3" {
+		t.Errorf("Next() = %s ending at %s, expected the terminator at 1:3", token, token.End)
+	}
+	if token, _ := tokenizer.Next(); token.Lexeme != "cd" {
+		t.Errorf("Next() = %s, expected \"cd\"", token)
+	}
+	if token, _ := tokenizer.Next(); token.Kind != testOperator || token.Start.String() != "2:3" {
+		t.Errorf("Next() = %s, expected the terminator at the end of the text", token)
+	}
+	if token, _ := tokenizer.Next(); token.Kind != TokenEOF {
+		t.Errorf("Next() = %s, expected EOF", token)
+	}
+	if token, _ := tokenizer.Next(); token.Kind != TokenEOF {
+		t.Errorf("Next() = %s, expected EOF again without another terminator", token)
+	}
+}
+
+func TestTokenizerModes(t *testing.T) {
+	quote := NewTokenKind("Quote")
+	text := NewTokenKind("Text")
+	open := NewTokenKind("InterpolationOpen")
+	closing := NewTokenKind("InterpolationClose")
+	space := PredicateRule(testSpace, unicode.IsSpace).Skipped()
+	ident := PredicateRule(testIdent, unicode.IsLetter)
+
+	tokenizer := NewTokenizer(NewScanner(`x "a ${b "c"} d" y`), space, ident, LiteralRule(quote, `"`).EnterMode("string"))
+	tokenizer.DefineMode("string",
+		FuncRule(text, func(s *Scanner) bool {
+			for !s.IsEOF() && s.Peek() != '"' && !s.HasPrefix("${") {
+				s.Pop()
+			}
+			return true
+		}),
+		LiteralRule(open, "${").EnterMode("expr"),
+		LiteralRule(quote, `"`).ExitMode(),
+	)
+	tokenizer.DefineMode("expr", space, ident, LiteralRule(closing, "}").ExitMode(), LiteralRule(quote, `"`).EnterMode("string"))
+
+	expected := []string{
+		`Ident "x" in ""`, `Quote "\"" in ""`, `Text "a " in "string"`, `InterpolationOpen "${" in "string"`,
+		`Ident "b" in "expr"`, `Quote "\"" in "expr"`, `Text "c" in "string"`, `Quote "\"" in "string"`,
+		`InterpolationClose "}" in "expr"`, `Text " d" in "string"`, `Quote "\"" in "string"`, `Ident "y" in ""`, `EOF "" in ""`,
+	}
+	for i, expected := range expected {
+		token, err := tokenizer.Next()
+		if err != nil {
+			t.Fatalf("token %d: Next() unexpected error: %v", i, err)
+		}
+		if result := token.Kind.String() + " " + strconv.Quote(token.Lexeme) + " in " + strconv.Quote(token.Mode); result != expected {
+			t.Errorf("token %d: Next() = %s, expected %s", i, result, expected)
+		}
+	}
+}
+
+func TestTokenizerPushPopMode(t *testing.T) {
+	tokenizer := NewTokenizer(NewScanner("ab"), testRules()...)
+	tokenizer.DefineMode("letters", PredicateRule(testString, func(r rune) bool { return r == 'a' }))
+
+	if tokenizer.PopMode() {
+		t.Errorf("PopMode() = true in the default mode, expected false")
+	}
+	tokenizer.PushMode("letters")
+	if token, _ := tokenizer.Next(); token.Kind != testString || token.Mode != "letters" {
+		t.Errorf("Next() = %s in mode %q, expected String \"a\" in mode \"letters\"", token, token.Mode)
+	}
+	if !tokenizer.PopMode() || tokenizer.Mode() != "" {
+		t.Errorf("PopMode() did not return to the default mode, Mode() = %q", tokenizer.Mode())
+	}
+	if token, _ := tokenizer.Next(); token.Kind != testIdent || token.Lexeme != "b" {
+		t.Errorf("Next() = %s, expected Ident \"b\"", token)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("PushMode() of an undefined mode did not panic")
+		}
+	}()
+	tokenizer.PushMode("undefined")
+}
+
+func TestTokenizerTokens(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		limit    int
+		expected []string
+		rest     string
+	}{
+		{name: "all", input: "x <= 1", limit: -1, expected: []string{"x", "<=", "1"}, rest: ""},
+		{name: "break", input: "x <= 1", limit: 2, expected: []string{"x", "<="}, rest: " 1"},
+		{name: "empty", input: "  ", limit: -1, expected: nil, rest: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenizer := NewTokenizer(NewScanner(tt.input), testRules()...)
+			var result []string
+			for token := range tokenizer.Tokens() {
+				result = append(result, token.Lexeme)
+				if len(result) == tt.limit {
+					break
+				}
+			}
+			if !slices.Equal(result, tt.expected) {
+				t.Errorf("Tokens() = %q, expected %q", result, tt.expected)
+			}
+			if rest := tokenizer.Scanner().Text()[tokenizer.Scanner().Offset:]; rest != tt.rest {
+				t.Errorf("rest = %q, expected %q", rest, tt.rest)
+			}
+		})
+	}
+}
+
+func TestTokenizerStreamCtx(t *testing.T) {
+	var result []string
+	for token := range NewTokenizer(NewScanner("x <= 1"), testRules()...).StreamCtx(context.Background()) {
+		result = append(result, token.Lexeme)
+	}
+	if expected := []string{"x", "<=", "1"}; !slices.Equal(result, expected) {
+		t.Errorf("StreamCtx() = %q, expected %q", result, expected)
+	}
+}
+
+func TestTokenizerStreamCtxCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	tokenizer := NewTokenizer(NewScanner("a b c d e f"), testRules()...)
+	ch := tokenizer.StreamCtx(ctx)
+
+	if token := <-ch; token.Lexeme != "a" {
+		t.Errorf("first token = %s, expected Ident \"a\"", token)
+	}
+	cancel()
+
+	count := 0
+	for range ch {
+		count++
+	}
+	if count > 1 {
+		t.Errorf("expected at most 1 token after cancellation, got %d", count)
+	}
+	if offset := tokenizer.Scanner().Offset; offset > 5 {
+		t.Errorf("Scanner().Offset = %d after cancellation, expected the tokenizer to stop early", offset)
+	}
+}
+
+// benchmarkRules returns rules of a small expression language that do not allocate when matching.
+func benchmarkRules() []TokenRule {
+	return []TokenRule{
+		PredicateRule(testSpace, unicode.IsSpace).Skipped(),
+		PredicateRule(testNumber, unicode.IsDigit),
+		LiteralRule(testOperator, "<="),
+		LiteralRule(testOperator, "<"),
+		LiteralRule(testOperator, "="),
+		PredicateRule(testIdent, func(r rune) bool { return unicode.IsLetter(r) || r == '_' }),
+	}
+}
+
+// benchmarkInput returns a source file of the given number of lines for benchmarkRules.
+func benchmarkInput(lines int) string {
+	return strings.Repeat("total_count <= limit = 12345 < value\n", lines)
+}
+
+func TestTokenizerNextSpanAllocations(t *testing.T) {
+	input := benchmarkInput(100)
+	allocs := testing.AllocsPerRun(10, func() {
+		tokenizer := NewTokenizer(NewScanner(input), benchmarkRules()...)
+		for tokenizer.NextSpan().Kind != TokenEOF {
+		}
+	})
+	// the Scanner, the Tokenizer and the rules are allocated once per run
+	if allocs > 10 {
+		t.Errorf("tokenizing %d lines allocated %v times, expected no allocations per token", 100, allocs)
+	}
+}
+
+func TestTokenizerNextSpanMatchesNext(t *testing.T) {
+	input := "x <= 3.5\n\"a b\"<y ?"
+	full := NewTokenizer(NewScanner(input), testRules()...)
+	lean := NewTokenizer(NewScanner(input), testRules()...)
+	for {
+		token, _ := full.Next()
+		span := lean.NextSpan()
+		if span.Kind != token.Kind || span.Span != token.Span {
+			t.Fatalf("NextSpan() = %s at %s, expected %s", span.Kind, span.Start, token)
+		}
+		if lexeme := lean.Scanner().TextOf(span.Span); lexeme != token.Lexeme {
+			t.Errorf("TextOf(NextSpan()) = %q, expected %q", lexeme, token.Lexeme)
+		}
+		if token.Kind == TokenEOF {
+			break
+		}
+	}
+}
+
+func BenchmarkTokenizerNext(b *testing.B) {
+	input := benchmarkInput(10000)
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	for b.Loop() {
+		tokenizer := NewTokenizer(NewScanner(input), benchmarkRules()...)
+		for token, _ := tokenizer.Next(); token.Kind != TokenEOF; token, _ = tokenizer.Next() {
+		}
+	}
+}
+
+func BenchmarkTokenizerNextSpan(b *testing.B) {
+	input := benchmarkInput(10000)
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	for b.Loop() {
+		tokenizer := NewTokenizer(NewScanner(input), benchmarkRules()...)
+		for tokenizer.NextSpan().Kind != TokenEOF {
+		}
+	}
+}
+
+func TestTokenizerListener(t *testing.T) {
+	quote := NewTokenKind("Quote")
+	tokenizer := NewTokenizer(NewScanner(`a "b" ?`), append(testRules()[:1], PredicateRule(testIdent, unicode.IsLetter), LiteralRule(quote, `"`).EnterMode("string"))...)
+	tokenizer.DefineMode("string", PredicateRule(testString, unicode.IsLetter), LiteralRule(quote, `"`).ExitMode())
+
+	var events []string
+	tokenizer.SetListener(ListenerFuncs{
+		Token:      func(token Token) { events = append(events, "token "+token.Lexeme) },
+		Error:      func(token Token) { events = append(events, "error "+token.Lexeme) },
+		ModeChange: func(from, to string) { events = append(events, "mode "+strconv.Quote(from)+" to "+strconv.Quote(to)) },
+	})
+	for range tokenizer.Tokens() {
+	}
+
+	expected := []string{`token a`, `mode "" to "string"`, `token "`, `token b`, `mode "string" to ""`, `token "`, `error ?`, `token `}
+	if !slices.Equal(events, expected) {
+		t.Errorf("events = %q\nexpected %q", events, expected)
+	}
+
+	// functions left nil are not called
+	tokenizer.SetListener(ListenerFuncs{})
+	tokenizer.PushMode("string")
+	tokenizer.Next()
+}
@@ -0,0 +1,126 @@
+package scanner
+
+import (
+	"iter"
+	"unicode"
+)
+
+// WhitespacePolicy determines which runes Scanner.SkipWhitespace skips. Policies can be combined with |.
+type WhitespacePolicy int
+
+const (
+	// WhitespaceUnicode skips all runes for which unicode.IsSpace returns true.
+	// Without it, only the ASCII whitespace runes space, tab, line feed, vertical tab, form feed and carriage return are skipped.
+	WhitespaceUnicode WhitespacePolicy = 1 << iota
+	// WhitespaceStopAtNewline stops skipping at line breaks, so that a lexer with significant line breaks can handle them itself.
+	// Escaped line breaks are still skipped, as Scanner.Pop never returns them.
+	WhitespaceStopAtNewline
+)
+
+// WithWhitespacePolicy sets which runes are skipped by Scanner.SkipWhitespace.
+// The default is to skip ASCII whitespace including line breaks.
+func WithWhitespacePolicy(policy WhitespacePolicy) Option {
+	return func(scanner *Scanner) {
+		scanner.whitespace = policy
+	}
+}
+
+// WhitespacePolicy returns which runes are skipped by Scanner.SkipWhitespace.
+func (scanner *Scanner) WhitespacePolicy() WhitespacePolicy {
+	return scanner.whitespace
+}
+
+// isSpace returns whether the given rune is whitespace to be skipped according to the policy.
+func (policy WhitespacePolicy) isSpace(r rune) bool {
+	if r == '\n' && policy&WhitespaceStopAtNewline != 0 {
+		return false
+	}
+	if policy&WhitespaceUnicode != 0 {
+		return unicode.IsSpace(r)
+	}
+
+	switch r {
+	case ' ', '\t', '\n', '\v', '\f', '\r':
+		return true
+	}
+	return false
+}
+
+// SkipWhitespace consumes the whitespace starting at the current scanner position and returns the span it covers.
+// Which runes count as whitespace is configured with WithWhitespacePolicy. If there is no whitespace, an empty span is returned.
+func (scanner *Scanner) SkipWhitespace() Span {
+	_, span := scanner.TakeWhile(scanner.whitespace.isSpace)
+	return span
+}
+
+// WithTabWidth sets the distance between tab stops used by Scanner.IndentationWidth. The default is 8.
+func WithTabWidth(width int) Option {
+	return func(scanner *Scanner) {
+		scanner.tabWidth = width
+	}
+}
+
+// ReadIndentation consumes the spaces and tabs starting at the current scanner position, usually the start of a line,
+// and returns how many of each there are along with the span they cover. Escaped line breaks are skipped the same way Scanner.Pop does,
+// so the indentation of a continued line is not read as part of it. Use Scanner.IndentationWidth to compare mixed indentation.
+func (scanner *Scanner) ReadIndentation() (spaces, tabs int, span Span) {
+	_, span = scanner.TakeWhile(func(r rune) bool {
+		switch r {
+		case ' ':
+			spaces++
+		case '\t':
+			tabs++
+		default:
+			return false
+		}
+		return true
+	})
+	return spaces, tabs, span
+}
+
+// IndentationWidth returns the width of the indentation covered by the given span, as returned by Scanner.ReadIndentation,
+// with each tab advancing the width to the next multiple of the tab width configured with WithTabWidth.
+func (scanner *Scanner) IndentationWidth(span Span) int {
+	width := 0
+	for _, r := range scanner.TextOf(span) {
+		if r == '\t' && scanner.tabWidth > 0 {
+			width += scanner.tabWidth - width%scanner.tabWidth
+		} else {
+			width++
+		}
+	}
+	return width
+}
+
+// A FieldSpan represents a whitespace-separated field within text, as yielded by Fields.
+type FieldSpan struct {
+	// Text is the content of the field.
+	// Line breaks are normalized and escaped line breaks are skipped the same way Scanner.Pop does.
+	Text string
+	// Span is the range the field covers.
+	Span
+}
+
+// NextField skips the whitespace starting at the current scanner position and consumes the field of non-whitespace runes after it.
+// Which runes count as whitespace is configured with WithWhitespacePolicy, a field never extends over a line break.
+// If there is no field before the end of the text, or before the next line break under WhitespaceStopAtNewline,
+// false is returned and only the whitespace is consumed.
+func (scanner *Scanner) NextField() (FieldSpan, bool) {
+	scanner.SkipWhitespace()
+	text, span := scanner.TakeWhile(func(r rune) bool { return r != '\n' && !scanner.whitespace.isSpace(r) })
+	return FieldSpan{Text: text, Span: span}, span.Len() > 0
+}
+
+// Fields returns an iterator over the fields of the given piece of text separated by Unicode whitespace, like strings.Fields,
+// along with their spans. The same skipping rules as for Scanner.Pop are applied.
+func Fields(text string) iter.Seq[FieldSpan] {
+	return func(yield func(FieldSpan) bool) {
+		scanner := NewScanner(text, WithWhitespacePolicy(WhitespaceUnicode))
+		for {
+			field, ok := scanner.NextField()
+			if !ok || !yield(field) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package scanner
+
+import "testing"
+
+func TestScannerSkipWhitespace(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		policy   WhitespacePolicy
+		expected int
+	}{
+		{name: "none", input: "x", expected: 0},
+		{name: "ASCII", input: " \t\v\f\r\n\nx", expected: 7},
+		{name: "ASCII ignores non-ASCII space", input: " \u00A0x", expected: 1},
+		{name: "unicode", input: " \u00A0\u2003x", policy: WhitespaceUnicode, expected: 6},
+		{name: "stop at LF", input: " \t\n x", policy: WhitespaceStopAtNewline, expected: 2},
+		{name: "stop at CRLF", input: " \r\n x", policy: WhitespaceStopAtNewline, expected: 1},
+		{name: "escaped line break skipped", input: " \\\n x", policy: WhitespaceStopAtNewline, expected: 4},
+		{name: "unicode stop at newline", input: "\u2003\n", policy: WhitespaceUnicode | WhitespaceStopAtNewline, expected: 3},
+		{name: "to EOF", input: "  \n", expected: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input, WithWhitespacePolicy(tt.policy))
+			span := scanner.SkipWhitespace()
+			if span.Start.Offset != 0 || span.End.Offset != tt.expected {
+				t.Errorf("SkipWhitespace() = [%d, %d), expected [0, %d)", span.Start.Offset, span.End.Offset, tt.expected)
+			}
+			if scanner.Offset != tt.expected {
+				t.Errorf("expected offset %d, got %d", tt.expected, scanner.Offset)
+			}
+		})
+	}
+}
+
+func TestScannerSkipWhitespacePosition(t *testing.T) {
+	scanner := NewScanner("a  \n  b")
+	scanner.Pop()
+
+	span := scanner.SkipWhitespace()
+	if span.Start.String() != "1:2" || span.End.String() != "2:3" {
+		t.Errorf("SkipWhitespace() = %s-%s, expected 1:2-2:3", span.Start, span.End)
+	}
+	if r := scanner.Peek(); r != 'b' {
+		t.Errorf("Peek() = %q, expected 'b'", r)
+	}
+}
+
+func TestFields(t *testing.T) {
+	input := "  GET /index.html HTTP/1.1\r\n 200 "
+	expected := []struct {
+		text       string
+		start, end string
+	}{
+		{text: "GET", start: "1:3", end: "1:6"},
+		{text: "/index.html", start: "1:7", end: "1:18"},
+		{text: "HTTP/1.1", start: "1:19", end: "1:27"},
+		{text: "200", start: "2:2", end: "2:5"},
+	}
+
+	var fields []FieldSpan
+	for field := range Fields(input) {
+		fields = append(fields, field)
+	}
+	if len(fields) != len(expected) {
+		t.Fatalf("Fields() yielded %d fields, expected %d", len(fields), len(expected))
+	}
+	for i, field := range fields {
+		if field.Text != expected[i].text || field.Start.String() != expected[i].start || field.End.String() != expected[i].end {
+			t.Errorf("field %d = %q at %s-%s, expected %q at %s-%s", i, field.Text, field.Start, field.End, expected[i].text, expected[i].start, expected[i].end)
+		}
+	}
+}
+
+func TestScannerNextFieldStopAtNewline(t *testing.T) {
+	scanner := NewScanner("a b \nc", WithWhitespacePolicy(WhitespaceStopAtNewline))
+
+	for _, expected := range []string{"a", "b"} {
+		if field, ok := scanner.NextField(); !ok || field.Text != expected {
+			t.Errorf("NextField() = %q, %v, expected %q, true", field.Text, ok, expected)
+		}
+	}
+	if field, ok := scanner.NextField(); ok {
+		t.Errorf("NextField() = %q, true, expected no field before the line break", field.Text)
+	}
+	if r := scanner.Peek(); r != '\n' {
+		t.Errorf("Peek() = %q, expected line break", r)
+	}
+}
+
+func TestScannerReadIndentation(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		tabWidth     int
+		spaces, tabs int
+		width        int
+	}{
+		{name: "none", input: "x", tabWidth: 8, spaces: 0, tabs: 0, width: 0},
+		{name: "spaces", input: "    x", tabWidth: 8, spaces: 4, tabs: 0, width: 4},
+		{name: "tabs", input: "\t\tx", tabWidth: 8, spaces: 0, tabs: 2, width: 16},
+		{name: "space before tab", input: "  \tx", tabWidth: 8, spaces: 2, tabs: 1, width: 8},
+		{name: "tab before space", input: "\t  x", tabWidth: 4, spaces: 2, tabs: 1, width: 6},
+		{name: "stops at line break", input: "  \n  x", tabWidth: 8, spaces: 2, tabs: 0, width: 2},
+		{name: "blank line at EOF", input: " \t", tabWidth: 2, spaces: 1, tabs: 1, width: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.input, WithTabWidth(tt.tabWidth))
+			spaces, tabs, span := scanner.ReadIndentation()
+			if spaces != tt.spaces || tabs != tt.tabs {
+				t.Errorf("ReadIndentation() = %d spaces, %d tabs, expected %d, %d", spaces, tabs, tt.spaces, tt.tabs)
+			}
+			if span.End.Offset != tt.spaces+tt.tabs || scanner.Offset != span.End.Offset {
+				t.Errorf("ReadIndentation() span [%d, %d) at offset %d, expected [0, %d)", span.Start.Offset, span.End.Offset, scanner.Offset, tt.spaces+tt.tabs)
+			}
+			if width := scanner.IndentationWidth(span); width != tt.width {
+				t.Errorf("IndentationWidth() = %d, expected %d", width, tt.width)
+			}
+		})
+	}
+}